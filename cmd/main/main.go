@@ -5,6 +5,7 @@ import (
 	"final/internal/app"
 	"final/internal/config"
 	"final/internal/logger"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -26,6 +27,18 @@ func main() {
 
 	ctx := context.Background()
 
+	// main [flags] migrate up|down|status runs the migration command instead of the app
+	if args := flag.Args(); len(args) > 0 {
+		if len(args) != 2 || args[0] != "migrate" {
+			l.Fatalf("unknown command %q, expected migrate up|down|status\n", args)
+		}
+
+		if err := app.Migrate(ctx, conf, l, args[1]); err != nil {
+			l.Fatalf("failed to migrate: %v\n", err)
+		}
+		return
+	}
+
 	a, err := app.New(conf, l)
 	if err != nil {
 		l.Fatalf("failed to init app: %v\n", err)
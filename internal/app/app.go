@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"final/internal/config"
+	"final/internal/domain"
 	"final/internal/monitoring"
 	"final/internal/repository"
 	"final/internal/service"
+	"final/internal/spool"
 	"final/internal/telemetry"
 	"final/internal/transport/gen"
 	grpc2 "final/internal/transport/grpc"
+	"final/migrations"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -20,11 +23,32 @@ import (
 	"google.golang.org/grpc"
 	"net"
 	"net/http"
+	"path/filepath"
+	"time"
 )
 
 const (
 	PostgresDriver = "postgres"
 	TCPNetwork     = "tcp"
+	// DeadLetterDir is a subdirectory of the spool directory keeping rates rejected by the storage
+	DeadLetterDir = "dead"
+)
+
+// Rate strategies.
+const (
+	// StrategySingle serves rates of the primary provider.
+	StrategySingle = "single"
+	// StrategyConsensus serves rates aggregated across all providers.
+	StrategyConsensus = "consensus"
+	// StrategyFailover serves rates of the first available provider in the configured order.
+	StrategyFailover = "failover"
+)
+
+// Migration commands.
+const (
+	MigrateUp     = "up"
+	MigrateDown   = "down"
+	MigrateStatus = "status"
 )
 
 // App is a struct with Run, Shutdown methods, which represents application.
@@ -35,33 +59,221 @@ type App struct {
 	cfg           *config.Config
 	metricsServer *http.Server
 	traceProvider *sdktrace.TracerProvider
+	// scheduler is nil when polling is disabled
+	scheduler *service.Scheduler
+	// retention is nil when the retention is disabled
+	retention *service.RetentionJob
+	// partitions is nil when creation of partitions is disabled
+	partitions *service.PartitionMaintainer
+	// writer is nil when rates are saved synchronously
+	writer *service.BatchWriter
+	// spool, deadLetters and replayer are nil when spooling is disabled
+	spool       *spool.Spool
+	deadLetters *spool.Spool
+	replayer    *service.SpoolReplayer
+	// replica and replicaMonitor are nil when reads go to the primary
+	replica        *repository.ReadReplica
+	replicaMonitor *service.ReplicaMonitor
 }
 
-// New creates connection to db, registers grpc endpoints, telemetry and returns new App instance.
-// Returns error if failed to connect to db.
+// New opens the configured storage, applies migrations if enabled, registers grpc endpoints, telemetry and returns new App instance.
+// Returns error if failed to open the storage.
 func New(cfg *config.Config, l *zap.SugaredLogger) (*App, error) {
 
-	l.Debugf("starting app with config: %v", *cfg)
+	l.Debugf("starting app with config: %v", cfg.Redacted())
 
 	metricsServer := monitoring.CreateMetricsServer(cfg.MetricsEndpoint)
 
-	connStr := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable", cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBName, cfg.DBPassword)
+	retry := service.RetryPolicy{
+		MaxAttempts: cfg.RetryAttempts,
+		BaseDelay:   cfg.RetryBaseDelay,
+		MaxDelay:    cfg.RetryMaxDelay,
+	}
 
-	db, err := sqlx.Connect(PostgresDriver, connStr)
+	providers, err := service.NewDefaultProviderRegistry(retry).Select(cfg.Providers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to select rate providers: %w", err)
+	}
+
+	quoteRules := service.QuoteRules{
+		MaxSpread:     cfg.QuoteMaxSpread,
+		MarketSpreads: cfg.QuoteMarketSpreads,
+		MaxAge:        cfg.QuoteMaxAge,
+		MaxClockSkew:  cfg.QuoteClockSkew,
+	}
+
+	// invalid quotes count as provider failures, so validation is wrapped by circuit breakers
+	// order books of a provider go through its validation and circuit breaker as well
+	for i, p := range providers {
+		validator := service.NewValidatingFetcher(p.Name, p.Fetcher, quoteRules, l)
+		providers[i].Fetcher = validator
+		if p.Books != nil {
+			providers[i].Books = validator.OrderBooks(p.Books)
+		}
+	}
+
+	if cfg.BreakerFailures > 0 {
+		for i, p := range providers {
+			breaker := service.NewCircuitBreakerFetcher(p.Name, p.Fetcher, cfg.BreakerFailures, cfg.BreakerCooldown)
+			providers[i].Fetcher = breaker
+			if p.Books != nil {
+				providers[i].Books = breaker.OrderBooks(p.Books)
+			}
+		}
+	}
+
+	rateFetcher, err := newRateFetcher(cfg, providers, l)
+	if err != nil {
+		return nil, err
+	}
+
+	// order books are served by the first configured provider which supports the market
+	orderBookFetcher, err := service.NewFailoverFetcher(providers, l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order book fetcher: %w", err)
+	}
+
+	db, storage, err := openStorage(cfg, l)
+	if err != nil {
+		return nil, err
+	}
+
+	var replica *repository.ReadReplica
+
+	// the memory backend has no db
+	closeDB := func() {
+		if replica != nil {
+			replica.Close()
+		}
+		if db != nil {
+			db.Close()
+		}
 	}
 
 	g := grpc.NewServer(
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 	)
 
-	rateRepo := repository.NewRateRepository(db)
-	rateFetcher := service.NewGarantexFetcher()
+	// retention and partitions are maintained only by the postgres backend
+	rateRepo, isPostgres := storage.(*repository.RateRepository)
+	if !isPostgres && (cfg.RetentionInterval > 0 || cfg.PartitionInterval > 0) {
+		l.Warnf("rates retention and partitions are not supported by the %s storage backend", cfg.StorageBackend)
+	}
+
+	var replicaMonitor *service.ReplicaMonitor
+	if isPostgres && cfg.DBReadDSN != "" {
+		if cfg.ReplicaCheckInterval <= 0 {
+			closeDB()
+			return nil, fmt.Errorf("failed to create read replica: check interval must be positive")
+		}
+
+		replicaDB, err := openReplicaDB(cfg)
+		if err != nil {
+			closeDB()
+			return nil, err
+		}
 
-	rateService := service.NewRateService(rateRepo, rateFetcher, l)
+		replica = repository.NewReadReplica(replicaDB, cfg.ReplicaMaxLag)
+		replicaMonitor = service.NewReplicaMonitor(replica, cfg.ReplicaCheckInterval, l)
 
-	rateServiceServer := grpc2.NewRateServiceServer(rateService)
+		rateRepo = repository.NewReplicatedRateRepository(db, replica)
+		storage = rateRepo
+	}
+
+	var retention *service.RetentionJob
+	if isPostgres && cfg.RetentionInterval > 0 {
+		policy := service.RetentionPolicy{
+			Raw:     time.Duration(cfg.RetentionRawDays) * 24 * time.Hour,
+			Minute:  time.Duration(cfg.RetentionMinuteDays) * 24 * time.Hour,
+			Horizon: time.Duration(cfg.RetentionHorizonDays) * 24 * time.Hour,
+		}
+
+		retention, err = service.NewRetentionJob(rateRepo, policy, cfg.RetentionInterval, l)
+		if err != nil {
+			closeDB()
+			return nil, fmt.Errorf("failed to create retention job: %w", err)
+		}
+	}
+
+	var partitions *service.PartitionMaintainer
+	if isPostgres && cfg.PartitionInterval > 0 {
+		period, err := domain.ParsePartitionPeriod(cfg.PartitionPeriod)
+		if err != nil {
+			closeDB()
+			return nil, fmt.Errorf("failed to create partition maintainer: %w", err)
+		}
+
+		partitions = service.NewPartitionMaintainer(rateRepo, period, cfg.PartitionAhead, cfg.PartitionInterval, l)
+	}
+
+	if cfg.WriteBatchSize > 0 && (cfg.WriteBatchSize > repository.MaxSaveBatch || cfg.WriteQueueSize < 1 || cfg.WriteFlushInterval <= 0) {
+		closeDB()
+		return nil, fmt.Errorf("failed to create batch writer: batch size must be at most %d, queue size and flush interval must be positive", repository.MaxSaveBatch)
+	}
+
+	var batchStore service.RateBatchStore = storage
+
+	var rateSpool, deadLetters *spool.Spool
+	var replayer *service.SpoolReplayer
+	if cfg.SpoolDir != "" {
+		if cfg.SpoolMaxAttempts < 1 {
+			closeDB()
+			return nil, fmt.Errorf("failed to open spool: max attempts must be positive")
+		}
+
+		rateSpool, err = spool.Open(cfg.SpoolDir, int64(cfg.SpoolSegmentSize))
+		if err != nil {
+			closeDB()
+			return nil, fmt.Errorf("failed to open spool: %w", err)
+		}
+
+		// rates rejected by the storage are kept for inspection in a spool of a subdirectory
+		deadLetters, err = spool.Open(filepath.Join(cfg.SpoolDir, DeadLetterDir), int64(cfg.SpoolSegmentSize))
+		if err != nil {
+			rateSpool.Close()
+			closeDB()
+			return nil, fmt.Errorf("failed to open dead letter spool: %w", err)
+		}
+
+		replayBatch := cfg.WriteBatchSize
+		if replayBatch <= 0 {
+			replayBatch = config.DefaultWriteBatchSize
+		}
+
+		policy := service.SpoolReplayPolicy{
+			MaxAttempts: cfg.SpoolMaxAttempts,
+			IsTransient: repository.IsTransient,
+		}
+
+		replayer = service.NewSpoolReplayer(storage, rateSpool, deadLetters, policy, replayBatch, cfg.SpoolReplayInterval, l)
+		batchStore = service.NewSpoolingStore(storage, rateSpool, l)
+	}
+
+	var rateStore service.RateStore = batchStore
+
+	var writer *service.BatchWriter
+	if cfg.WriteBatchSize > 0 {
+		writer = service.NewBatchWriter(batchStore, cfg.WriteQueueSize, cfg.WriteBatchSize, cfg.WriteFlushInterval, l)
+		rateStore = writer
+	}
+
+	rateCache := service.NewRateCache(cfg.CacheTTL)
+
+	var jumpGuard *service.JumpGuard
+	if cfg.JumpThreshold > 0 {
+		jumpGuard = service.NewJumpGuard(cfg.JumpThreshold, l)
+	}
+
+	rateService := service.NewRateService(rateStore, rateFetcher, rateCache, jumpGuard, cfg.MaxStaleness, l)
+
+	var scheduler *service.Scheduler
+	if cfg.PollInterval > 0 {
+		scheduler = service.NewScheduler(rateService, cfg.PollMarkets, cfg.PollInterval, l)
+	}
+
+	orderBookService := service.NewOrderBookService(orderBookFetcher)
+
+	rateServiceServer := grpc2.NewRateServiceServer(rateService, orderBookService)
 
 	gen.RegisterRateServiceServer(g, rateServiceServer)
 
@@ -70,17 +282,204 @@ func New(cfg *config.Config, l *zap.SugaredLogger) (*App, error) {
 	gen.RegisterHealthServiceServer(g, healthServiceServer)
 
 	app := &App{
-		l:             l,
-		db:            db,
-		grpcServer:    g,
-		cfg:           cfg,
-		metricsServer: metricsServer,
+		l:              l,
+		db:             db,
+		grpcServer:     g,
+		cfg:            cfg,
+		metricsServer:  metricsServer,
+		scheduler:      scheduler,
+		retention:      retention,
+		partitions:     partitions,
+		writer:         writer,
+		spool:          rateSpool,
+		deadLetters:    deadLetters,
+		replayer:       replayer,
+		replica:        replica,
+		replicaMonitor: replicaMonitor,
 	}
 
 	return app, nil
 }
 
-// Run starts the app.
+// Migrate connects to db and runs the migration command: up, down or status.
+func Migrate(ctx context.Context, cfg *config.Config, l *zap.SugaredLogger, command string) error {
+	db, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch command {
+	case MigrateUp:
+		return migrateUp(ctx, db, l)
+	case MigrateDown:
+		migrator, err := newMigrator(db)
+		if err != nil {
+			return err
+		}
+
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to revert migration: %w", err)
+		}
+
+		if m == nil {
+			l.Infoln("no applied migrations to revert")
+			return nil
+		}
+
+		l.Infof("reverted migration %04d_%s", m.Version, m.Name)
+		return nil
+	case MigrateStatus:
+		migrator, err := newMigrator(db)
+		if err != nil {
+			return err
+		}
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get migrations status: %w", err)
+		}
+
+		for _, s := range statuses {
+			if s.AppliedAt.IsZero() {
+				l.Infof("%04d_%s pending", s.Version, s.Name)
+			} else {
+				l.Infof("%04d_%s applied at %s", s.Version, s.Name, s.AppliedAt.Format(time.RFC3339))
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migration command %q, expected %s, %s or %s", command, MigrateUp, MigrateDown, MigrateStatus)
+	}
+}
+
+// openStorage opens the configured storage backend, the returned db is nil for the memory backend.
+func openStorage(cfg *config.Config, l *zap.SugaredLogger) (*sqlx.DB, repository.Storage, error) {
+	switch cfg.StorageBackend {
+	case repository.BackendPostgres:
+		db, err := connectDB(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if cfg.AutoMigrate {
+			if err := migrateUp(context.Background(), db, l); err != nil {
+				db.Close()
+				return nil, nil, err
+			}
+		}
+
+		return db, repository.NewRateRepository(db), nil
+	case repository.BackendSQLite:
+		db, err := repository.OpenSQLite(context.Background(), cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		l.Infof("storing rates in sqlite database %s", cfg.SQLitePath)
+
+		return db, repository.NewSQLiteRepository(db), nil
+	case repository.BackendMemory:
+		l.Warnln("storing rates in memory, they are lost on restart")
+
+		return nil, repository.NewMemoryRepository(), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q, expected %s, %s or %s",
+			cfg.StorageBackend, repository.BackendPostgres, repository.BackendSQLite, repository.BackendMemory)
+	}
+}
+
+func connectDB(cfg *config.Config) (*sqlx.DB, error) {
+	connStr := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable", cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBName, cfg.DBPassword)
+
+	db, err := sqlx.Connect(PostgresDriver, connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	configurePool(db, cfg)
+
+	return db, nil
+}
+
+// openReplicaDB opens the read replica pool without connecting, so that the app starts while the replica is down,
+// reads go to the primary until the ReplicaMonitor checks the replica.
+func openReplicaDB(cfg *config.Config) (*sqlx.DB, error) {
+	db, err := sqlx.Open(PostgresDriver, cfg.DBReadDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read replica database: %w", err)
+	}
+
+	configurePool(db, cfg)
+
+	return db, nil
+}
+
+// configurePool applies the configured pool size and connection lifetimes to the db pool.
+func configurePool(db *sqlx.DB, cfg *config.Config) {
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+}
+
+// newMigrator creates a migrator of the migrations embedded into the binary.
+func newMigrator(db *sqlx.DB) (*repository.Migrator, error) {
+	ms, err := repository.LoadMigrations(migrations.FS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return repository.NewMigrator(db, ms), nil
+}
+
+// migrateUp applies pending migrations.
+func migrateUp(ctx context.Context, db *sqlx.DB, l *zap.SugaredLogger) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	for _, m := range applied {
+		l.Infof("applied migration %04d_%s", m.Version, m.Name)
+	}
+
+	if len(applied) == 0 {
+		l.Infoln("schema is up to date")
+	}
+
+	return nil
+}
+
+// newRateFetcher combines providers according to the configured rate strategy.
+func newRateFetcher(cfg *config.Config, providers []service.Provider, l *zap.SugaredLogger) (service.RateFetcher, error) {
+	switch cfg.RateStrategy {
+	case StrategySingle:
+		return providers[0].Fetcher, nil
+	case StrategyConsensus:
+		fetcher, err := service.NewConsensusFetcher(providers, cfg.ConsensusMethod, cfg.ConsensusBand, cfg.ProviderWeights, l)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consensus fetcher: %w", err)
+		}
+		return fetcher, nil
+	case StrategyFailover:
+		fetcher, err := service.NewFailoverFetcher(providers, l)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create failover fetcher: %w", err)
+		}
+		return fetcher, nil
+	default:
+		return nil, fmt.Errorf("unknown rate strategy %q", cfg.RateStrategy)
+	}
+}
+
+// Run starts the app, partition maintainer, replica monitor, spool replayer, rate scheduler and rates retention.
 // Returns an error if failed to listen port or failed to serve.
 func (a *App) Run(ctx context.Context) error {
 
@@ -102,6 +501,26 @@ func (a *App) Run(ctx context.Context) error {
 
 	a.l.Infof("grpc server is listening on %s", addr)
 
+	if a.partitions != nil {
+		a.partitions.Start(ctx)
+	}
+
+	if a.replicaMonitor != nil {
+		a.replicaMonitor.Start(ctx)
+	}
+
+	if a.replayer != nil {
+		a.replayer.Start(ctx)
+	}
+
+	if a.scheduler != nil {
+		a.scheduler.Start(ctx)
+	}
+
+	if a.retention != nil {
+		a.retention.Start(ctx)
+	}
+
 	go func() {
 		a.l.Infof("metrics server is listening on %s", a.metricsServer.Addr)
 		if err := a.metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
@@ -116,16 +535,68 @@ func (a *App) Run(ctx context.Context) error {
 	return nil
 }
 
-// Shutdown gracefully shuts down db connection and grpc server.
+// Shutdown gracefully shuts down grpc server, rate scheduler, rates retention, partition maintainer,
+// saves queued rates, closes the spool, replica and db connections.
 // Returns an error if failed to close db connection.
 func (a *App) Shutdown(ctx context.Context) error {
 
 	a.l.Infoln("shutting down grpc server")
 	a.grpcServer.GracefulStop()
 
-	a.l.Infoln("shutting down db connection")
-	if err := a.db.Close(); err != nil {
-		return fmt.Errorf("failed to close db connection: %w", err)
+	if a.scheduler != nil {
+		a.l.Infoln("stopping rate scheduler")
+		a.scheduler.Stop()
+	}
+
+	if a.retention != nil {
+		a.l.Infoln("stopping rates retention")
+		a.retention.Stop()
+	}
+
+	if a.partitions != nil {
+		a.l.Infoln("stopping partition maintainer")
+		a.partitions.Stop()
+	}
+
+	if a.writer != nil {
+		a.l.Infoln("saving queued rates")
+		if err := a.writer.Close(ctx); err != nil {
+			a.l.Errorf("failed to save queued rates: %v", err)
+		}
+	}
+
+	if a.replayer != nil {
+		a.l.Infoln("stopping spool replayer")
+		a.replayer.Stop()
+	}
+
+	if a.spool != nil {
+		if err := a.spool.Close(); err != nil {
+			a.l.Errorf("failed to close spool: %v", err)
+		}
+
+		if err := a.deadLetters.Close(); err != nil {
+			a.l.Errorf("failed to close dead letter spool: %v", err)
+		}
+	}
+
+	if a.replicaMonitor != nil {
+		a.l.Infoln("stopping replica monitor")
+		a.replicaMonitor.Stop()
+	}
+
+	if a.replica != nil {
+		a.l.Infoln("shutting down read replica connection")
+		if err := a.replica.Close(); err != nil {
+			a.l.Errorf("failed to close read replica connection: %v", err)
+		}
+	}
+
+	if a.db != nil {
+		a.l.Infoln("shutting down db connection")
+		if err := a.db.Close(); err != nil {
+			return fmt.Errorf("failed to close db connection: %w", err)
+		}
 	}
 
 	a.l.Infoln("shutting down metrics server")
@@ -4,6 +4,58 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultProvider        = "garantex"
+	DefaultRateStrategy    = "single"
+	DefaultConsensusMethod = "median"
+	DefaultConsensusBand   = 0.02
+	DefaultPollMarket      = "usdtrub"
+	DefaultPollInterval    = 10 * time.Second
+	DefaultBreakerFailures = 5
+	DefaultBreakerCooldown = 30 * time.Second
+	DefaultRetryAttempts   = 3
+	DefaultRetryBaseDelay  = 200 * time.Millisecond
+	DefaultRetryMaxDelay   = 2 * time.Second
+	DefaultCacheTTL        = 30 * time.Second
+	DefaultMaxStaleness    = 5 * time.Minute
+	DefaultQuoteMaxSpread  = 0.05
+	DefaultQuoteMaxAge     = 10 * time.Minute
+	DefaultQuoteClockSkew  = 30 * time.Second
+	DefaultJumpThreshold   = 0.1
+
+	// retention deletes rates, it is disabled unless enabled explicitly
+	DefaultRetentionInterval    = 0
+	DefaultRetentionRawDays     = 7
+	DefaultRetentionMinuteDays  = 30
+	DefaultRetentionHorizonDays = 365
+
+	DefaultPartitionPeriod   = "day"
+	DefaultPartitionAhead    = 3
+	DefaultPartitionInterval = time.Hour
+
+	DefaultWriteBatchSize     = 500
+	DefaultWriteQueueSize     = 10000
+	DefaultWriteFlushInterval = time.Second
+
+	DefaultSpoolSegmentSize    = 16 << 20
+	DefaultSpoolReplayInterval = 5 * time.Second
+	DefaultSpoolMaxAttempts    = 5
+
+	DefaultDBMaxOpenConns    = 20
+	DefaultDBMaxIdleConns    = 10
+	DefaultDBConnMaxLifetime = 30 * time.Minute
+	DefaultDBConnMaxIdleTime = 5 * time.Minute
+
+	DefaultReplicaMaxLag        = 5 * time.Second
+	DefaultReplicaCheckInterval = 5 * time.Second
+
+	DefaultStorageBackend = "postgres"
+	DefaultSQLitePath     = "rates.db"
 )
 
 // Config is a struct that holds all configuration variables
@@ -11,18 +63,101 @@ type Config struct {
 	// App
 	AppIP   string
 	AppPort string
-	// DB
+	// Storage
+	// StorageBackend is a storage of rates: postgres, sqlite or memory
+	StorageBackend string
+	// SQLitePath is a database file of the sqlite backend
+	SQLitePath string
+	// DB of the postgres backend
 	DBName     string
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
+	// AutoMigrate applies pending schema migrations on startup
+	AutoMigrate bool
+	// Connection pools of the primary and the read replica
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	// Read replica
+	// DBReadDSN is a connection string of the read replica of query RPCs, empty reads from the primary
+	DBReadDSN string
+	// ReplicaMaxLag is a max replication lag of the read replica, reads fall back to the primary while it is exceeded
+	ReplicaMaxLag time.Duration
+	// ReplicaCheckInterval is an interval of checking the replication lag
+	ReplicaCheckInterval time.Duration
 	// Log
 	Mode string
 	// Telemetry
 	TelemetryEndpoint string
 	// Metrics
 	MetricsEndpoint string
+	// Providers
+	Providers []string
+	// RateStrategy is a way of combining providers: single, consensus or failover
+	RateStrategy    string
+	ConsensusMethod string
+	// ConsensusBand is a max relative deviation from the median, e.g. 0.02 for 2%
+	ConsensusBand   float64
+	ProviderWeights map[string]float64
+	// Polling
+	PollMarkets []string
+	// PollInterval is an interval of polling, zero disables polling
+	PollInterval time.Duration
+	// Circuit breaker
+	// BreakerFailures is a number of consecutive provider failures opening its circuit, zero disables breakers
+	BreakerFailures int
+	BreakerCooldown time.Duration
+	// Retries of upstream requests
+	// RetryAttempts is a total number of attempts, 1 disables retries
+	RetryAttempts  int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// CacheTTL is a max age of a cached rate served without fetching, it should exceed PollInterval
+	CacheTTL time.Duration
+	// MaxStaleness is a max age of the last saved rate served when providers are unavailable, zero disables the fallback
+	MaxStaleness time.Duration
+	// Quote validation, zero values disable the checks
+	// QuoteMaxSpread is a max relative spread of a quote, e.g. 0.05 for 5%
+	QuoteMaxSpread     float64
+	QuoteMarketSpreads map[string]float64
+	QuoteMaxAge        time.Duration
+	QuoteClockSkew     time.Duration
+	// JumpThreshold is a relative move of the mid price held until confirmed, e.g. 0.1 for 10%, zero disables the guard
+	JumpThreshold float64
+	// Retention
+	// RetentionInterval is an interval of applying the retention, zero disables it
+	RetentionInterval time.Duration
+	// RetentionRawDays is a number of days raw rates are kept before rolling them up into minute and hour candles
+	RetentionRawDays    int
+	RetentionMinuteDays int
+	// RetentionHorizonDays is a number of days hour candles are kept, nothing older is kept
+	RetentionHorizonDays int
+	// Partitions
+	// PartitionPeriod is a time range of rates in a partition: day or month
+	PartitionPeriod string
+	// PartitionAhead is a number of partitions created in advance after the current one
+	PartitionAhead int
+	// PartitionInterval is an interval of creating partitions, zero disables it, partitions then must be created externally
+	PartitionInterval time.Duration
+	// Write-behind
+	// WriteBatchSize is a max number of rates saved at once in the background, zero saves every rate synchronously
+	WriteBatchSize int
+	// WriteQueueSize is a max number of rates waiting to be saved in the background
+	WriteQueueSize int
+	// WriteFlushInterval is a max time rates wait to be saved in an incomplete batch
+	WriteFlushInterval time.Duration
+	// Spool
+	// SpoolDir is a directory of rates which failed to save, empty disables spooling
+	SpoolDir string
+	// SpoolSegmentSize is a size of a spool file in bytes after which a new one is started
+	SpoolSegmentSize int
+	// SpoolReplayInterval is an interval of saving spooled rates
+	SpoolReplayInterval time.Duration
+	// SpoolMaxAttempts is a max number of replays of a spooled rate rejected by the storage before it is moved to the dead letters
+	SpoolMaxAttempts int
 }
 
 // Load parses environment variables and flags, flags have higher priority
@@ -32,17 +167,62 @@ func Load() (*Config, error) {
 	appIPFlag := flag.String("app-ip", "", "IP address for the application")
 	appPortFlag := flag.String("app-port", "", "Port for the application")
 
+	storageBackendFlag := flag.String("storage-backend", "", "Storage of rates (postgres, sqlite, memory)")
+	sqlitePathFlag := flag.String("sqlite-path", "", "Database file of the sqlite storage backend")
+
 	dbNameFlag := flag.String("db-name", "", "Database name")
 	dbHostFlag := flag.String("db-host", "", "Database host")
 	dbPortFlag := flag.String("db-port", "", "Database port")
 	dbUserFlag := flag.String("db-user", "", "Database user")
 	dbPasswordFlag := flag.String("db-password", "", "Database password")
+	autoMigrateFlag := flag.String("auto-migrate", "", "Apply pending schema migrations on startup (true, false)")
+	dbMaxOpenConnsFlag := flag.String("db-max-open-conns", "", "Max number of open connections of a database pool, 0 is unlimited")
+	dbMaxIdleConnsFlag := flag.String("db-max-idle-conns", "", "Max number of idle connections of a database pool")
+	dbConnMaxLifetimeFlag := flag.String("db-conn-max-lifetime", "", "Max time a database connection is reused (e.g. 30m), 0 is unlimited")
+	dbConnMaxIdleTimeFlag := flag.String("db-conn-max-idle-time", "", "Max time a database connection is idle (e.g. 5m), 0 is unlimited")
+	dbReadDSNFlag := flag.String("db-read-dsn", "", "Connection string of the read replica of query RPCs, empty reads from the primary")
+	replicaMaxLagFlag := flag.String("replica-max-lag", "", "Max replication lag of the read replica before reads fall back to the primary (e.g. 5s)")
+	replicaCheckIntervalFlag := flag.String("replica-check-interval", "", "Interval of checking the replication lag of the read replica (e.g. 5s)")
 
 	modeFlag := flag.String("mode", "", "Application mode (e.g., devцццelopment, production)")
 
 	telemetryEndpointFlag := flag.String("telemetry-endpoint", "", "Telemetry endpoint URL")
 	metricsEndpointFlag := flag.String("metrics-endpoint", "", "Metrics endpoint URL")
 
+	providersFlag := flag.String("providers", "", "Comma-separated list of active rate providers, the first one is primary")
+	rateStrategyFlag := flag.String("rate-strategy", "", "Rate strategy (single, consensus, failover)")
+	consensusMethodFlag := flag.String("consensus-method", "", "Consensus method (median, weighted)")
+	consensusBandFlag := flag.String("consensus-band", "", "Max relative deviation of a quote from the median (e.g. 0.02)")
+	pollMarketsFlag := flag.String("poll-markets", "", "Comma-separated list of markets polled in background")
+	pollIntervalFlag := flag.String("poll-interval", "", "Interval of background polling (e.g. 10s), 0 disables polling")
+	breakerFailuresFlag := flag.String("breaker-failures", "", "Consecutive provider failures opening its circuit, 0 disables circuit breakers")
+	breakerCooldownFlag := flag.String("breaker-cooldown", "", "Time an open circuit waits before a trial request (e.g. 30s)")
+	retryAttemptsFlag := flag.String("retry-attempts", "", "Total number of attempts of an upstream request, 1 disables retries")
+	retryBaseDelayFlag := flag.String("retry-base-delay", "", "Delay before the first retry, doubled on every next one (e.g. 200ms)")
+	retryMaxDelayFlag := flag.String("retry-max-delay", "", "Max delay between retries (e.g. 2s), requests asked to retry after longer are not retried")
+	cacheTTLFlag := flag.String("cache-ttl", "", "Max age of a cached rate served without fetching (e.g. 30s)")
+	maxStalenessFlag := flag.String("max-staleness", "", "Max age of the last saved rate served when providers are unavailable (e.g. 5m), 0 disables the fallback")
+	quoteMaxSpreadFlag := flag.String("quote-max-spread", "", "Max relative spread of a provider quote (e.g. 0.05), 0 disables the check")
+	quoteMarketSpreadsFlag := flag.String("quote-market-spreads", "", "Comma-separated max spreads overriding the default one (e.g. usdtrub=0.01,btcrub=0.03)")
+	quoteMaxAgeFlag := flag.String("quote-max-age", "", "Max age of a provider quote timestamp (e.g. 10m), 0 disables the check")
+	quoteClockSkewFlag := flag.String("quote-clock-skew", "", "Max time a provider quote timestamp may be ahead of the local clock (e.g. 30s), 0 disables the check")
+	jumpThresholdFlag := flag.String("jump-threshold", "", "Relative price move held until a second observation confirms it (e.g. 0.1), 0 disables the guard")
+	retentionIntervalFlag := flag.String("retention-interval", "", "Interval of applying the rates retention (e.g. 1h), 0 disables the retention (default)")
+	retentionRawDaysFlag := flag.String("retention-raw-days", "", "Days raw rates are kept before rolling them up into minute and hour candles")
+	retentionMinuteDaysFlag := flag.String("retention-minute-days", "", "Days minute candles are kept")
+	retentionHorizonDaysFlag := flag.String("retention-horizon-days", "", "Days hour candles are kept, nothing older is kept")
+	partitionPeriodFlag := flag.String("partition-period", "", "Time range of rates in a partition: day or month")
+	partitionAheadFlag := flag.String("partition-ahead", "", "Number of partitions created in advance after the current one")
+	partitionIntervalFlag := flag.String("partition-interval", "", "Interval of creating partitions (e.g. 1h), 0 disables it")
+	writeBatchSizeFlag := flag.String("write-batch-size", "", "Max number of rates saved at once in the background, 0 saves every rate synchronously")
+	writeQueueSizeFlag := flag.String("write-queue-size", "", "Max number of rates waiting to be saved in the background")
+	writeFlushIntervalFlag := flag.String("write-flush-interval", "", "Max time rates wait to be saved in an incomplete batch (e.g. 1s)")
+	spoolDirFlag := flag.String("spool-dir", "", "Directory of rates which failed to save, empty disables spooling")
+	spoolSegmentSizeFlag := flag.String("spool-segment-size", "", "Size of a spool file in bytes after which a new one is started")
+	spoolReplayIntervalFlag := flag.String("spool-replay-interval", "", "Interval of saving spooled rates (e.g. 5s)")
+	spoolMaxAttemptsFlag := flag.String("spool-max-attempts", "", "Max number of replays of a spooled rate rejected by the storage before it is moved to the dead letters")
+	providerWeightsFlag := flag.String("provider-weights", "", "Comma-separated provider weights for weighted consensus (e.g. garantex=2,binance=1)")
+
 	flag.Parse()
 
 	getValue := func(flagValue *string, envVar string) string {
@@ -60,11 +240,204 @@ func Load() (*Config, error) {
 		DBPort:            getValue(dbPortFlag, "DB_PORT"),
 		DBUser:            getValue(dbUserFlag, "DB_USER"),
 		DBPassword:        getValue(dbPasswordFlag, "DB_PASSWORD"),
+		DBReadDSN:         getValue(dbReadDSNFlag, "DB_READ_DSN"),
 		Mode:              getValue(modeFlag, "MODE"),
 		TelemetryEndpoint: getValue(telemetryEndpointFlag, "TELEMETRY_ENDPOINT"),
 		MetricsEndpoint:   getValue(metricsEndpointFlag, "METRICS_ENDPOINT"),
+		Providers:         splitList(getValue(providersFlag, "PROVIDERS")),
+	}
+
+	if len(config.Providers) == 0 {
+		config.Providers = []string{DefaultProvider}
+	}
+
+	var err error
+
+	config.AutoMigrate, err = parseBool(getValue(autoMigrateFlag, "AUTO_MIGRATE"), false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AutoMigrate (flag: -auto-migrate or env: AUTO_MIGRATE): %w", err)
+	}
+
+	config.DBMaxOpenConns, err = parseInt(getValue(dbMaxOpenConnsFlag, "DB_MAX_OPEN_CONNS"), DefaultDBMaxOpenConns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DBMaxOpenConns (flag: -db-max-open-conns or env: DB_MAX_OPEN_CONNS): %w", err)
+	}
+
+	config.DBMaxIdleConns, err = parseInt(getValue(dbMaxIdleConnsFlag, "DB_MAX_IDLE_CONNS"), DefaultDBMaxIdleConns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DBMaxIdleConns (flag: -db-max-idle-conns or env: DB_MAX_IDLE_CONNS): %w", err)
+	}
+
+	config.DBConnMaxLifetime, err = parseDuration(getValue(dbConnMaxLifetimeFlag, "DB_CONN_MAX_LIFETIME"), DefaultDBConnMaxLifetime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DBConnMaxLifetime (flag: -db-conn-max-lifetime or env: DB_CONN_MAX_LIFETIME): %w", err)
+	}
+
+	config.DBConnMaxIdleTime, err = parseDuration(getValue(dbConnMaxIdleTimeFlag, "DB_CONN_MAX_IDLE_TIME"), DefaultDBConnMaxIdleTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DBConnMaxIdleTime (flag: -db-conn-max-idle-time or env: DB_CONN_MAX_IDLE_TIME): %w", err)
+	}
+
+	config.ReplicaMaxLag, err = parseDuration(getValue(replicaMaxLagFlag, "REPLICA_MAX_LAG"), DefaultReplicaMaxLag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ReplicaMaxLag (flag: -replica-max-lag or env: REPLICA_MAX_LAG): %w", err)
+	}
+
+	config.ReplicaCheckInterval, err = parseDuration(getValue(replicaCheckIntervalFlag, "REPLICA_CHECK_INTERVAL"), DefaultReplicaCheckInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ReplicaCheckInterval (flag: -replica-check-interval or env: REPLICA_CHECK_INTERVAL): %w", err)
+	}
+
+	config.RateStrategy = getDefault(getValue(rateStrategyFlag, "RATE_STRATEGY"), DefaultRateStrategy)
+	config.ConsensusMethod = getDefault(getValue(consensusMethodFlag, "CONSENSUS_METHOD"), DefaultConsensusMethod)
+
+	config.ConsensusBand, err = parseFloat(getValue(consensusBandFlag, "CONSENSUS_BAND"), DefaultConsensusBand)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ConsensusBand (flag: -consensus-band or env: CONSENSUS_BAND): %w", err)
+	}
+
+	config.ProviderWeights, err = parseFloatMap(getValue(providerWeightsFlag, "PROVIDER_WEIGHTS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ProviderWeights (flag: -provider-weights or env: PROVIDER_WEIGHTS): %w", err)
+	}
+
+	config.PollMarkets = splitList(getValue(pollMarketsFlag, "POLL_MARKETS"))
+	if len(config.PollMarkets) == 0 {
+		config.PollMarkets = []string{DefaultPollMarket}
+	}
+
+	config.PollInterval, err = parseDuration(getValue(pollIntervalFlag, "POLL_INTERVAL"), DefaultPollInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PollInterval (flag: -poll-interval or env: POLL_INTERVAL): %w", err)
+	}
+
+	config.BreakerFailures, err = parseInt(getValue(breakerFailuresFlag, "BREAKER_FAILURES"), DefaultBreakerFailures)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BreakerFailures (flag: -breaker-failures or env: BREAKER_FAILURES): %w", err)
+	}
+
+	config.BreakerCooldown, err = parseDuration(getValue(breakerCooldownFlag, "BREAKER_COOLDOWN"), DefaultBreakerCooldown)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BreakerCooldown (flag: -breaker-cooldown or env: BREAKER_COOLDOWN): %w", err)
+	}
+
+	config.RetryAttempts, err = parseInt(getValue(retryAttemptsFlag, "RETRY_ATTEMPTS"), DefaultRetryAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RetryAttempts (flag: -retry-attempts or env: RETRY_ATTEMPTS): %w", err)
+	}
+
+	config.RetryBaseDelay, err = parseDuration(getValue(retryBaseDelayFlag, "RETRY_BASE_DELAY"), DefaultRetryBaseDelay)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RetryBaseDelay (flag: -retry-base-delay or env: RETRY_BASE_DELAY): %w", err)
+	}
+
+	config.RetryMaxDelay, err = parseDuration(getValue(retryMaxDelayFlag, "RETRY_MAX_DELAY"), DefaultRetryMaxDelay)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RetryMaxDelay (flag: -retry-max-delay or env: RETRY_MAX_DELAY): %w", err)
+	}
+
+	config.CacheTTL, err = parseDuration(getValue(cacheTTLFlag, "CACHE_TTL"), DefaultCacheTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CacheTTL (flag: -cache-ttl or env: CACHE_TTL): %w", err)
+	}
+
+	config.MaxStaleness, err = parseDuration(getValue(maxStalenessFlag, "MAX_STALENESS"), DefaultMaxStaleness)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MaxStaleness (flag: -max-staleness or env: MAX_STALENESS): %w", err)
+	}
+
+	config.QuoteMaxSpread, err = parseFloat(getValue(quoteMaxSpreadFlag, "QUOTE_MAX_SPREAD"), DefaultQuoteMaxSpread)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QuoteMaxSpread (flag: -quote-max-spread or env: QUOTE_MAX_SPREAD): %w", err)
+	}
+
+	config.QuoteMarketSpreads, err = parseFloatMap(getValue(quoteMarketSpreadsFlag, "QUOTE_MARKET_SPREADS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid QuoteMarketSpreads (flag: -quote-market-spreads or env: QUOTE_MARKET_SPREADS): %w", err)
+	}
+
+	config.QuoteMaxAge, err = parseDuration(getValue(quoteMaxAgeFlag, "QUOTE_MAX_AGE"), DefaultQuoteMaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QuoteMaxAge (flag: -quote-max-age or env: QUOTE_MAX_AGE): %w", err)
+	}
+
+	config.QuoteClockSkew, err = parseDuration(getValue(quoteClockSkewFlag, "QUOTE_CLOCK_SKEW"), DefaultQuoteClockSkew)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QuoteClockSkew (flag: -quote-clock-skew or env: QUOTE_CLOCK_SKEW): %w", err)
+	}
+
+	config.JumpThreshold, err = parseFloat(getValue(jumpThresholdFlag, "JUMP_THRESHOLD"), DefaultJumpThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JumpThreshold (flag: -jump-threshold or env: JUMP_THRESHOLD): %w", err)
+	}
+
+	config.RetentionInterval, err = parseDuration(getValue(retentionIntervalFlag, "RETENTION_INTERVAL"), DefaultRetentionInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RetentionInterval (flag: -retention-interval or env: RETENTION_INTERVAL): %w", err)
+	}
+
+	config.RetentionRawDays, err = parseInt(getValue(retentionRawDaysFlag, "RETENTION_RAW_DAYS"), DefaultRetentionRawDays)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RetentionRawDays (flag: -retention-raw-days or env: RETENTION_RAW_DAYS): %w", err)
+	}
+
+	config.RetentionMinuteDays, err = parseInt(getValue(retentionMinuteDaysFlag, "RETENTION_MINUTE_DAYS"), DefaultRetentionMinuteDays)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RetentionMinuteDays (flag: -retention-minute-days or env: RETENTION_MINUTE_DAYS): %w", err)
+	}
+
+	config.RetentionHorizonDays, err = parseInt(getValue(retentionHorizonDaysFlag, "RETENTION_HORIZON_DAYS"), DefaultRetentionHorizonDays)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RetentionHorizonDays (flag: -retention-horizon-days or env: RETENTION_HORIZON_DAYS): %w", err)
+	}
+
+	config.PartitionPeriod = getDefault(getValue(partitionPeriodFlag, "PARTITION_PERIOD"), DefaultPartitionPeriod)
+
+	config.PartitionAhead, err = parseInt(getValue(partitionAheadFlag, "PARTITION_AHEAD"), DefaultPartitionAhead)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PartitionAhead (flag: -partition-ahead or env: PARTITION_AHEAD): %w", err)
+	}
+
+	config.PartitionInterval, err = parseDuration(getValue(partitionIntervalFlag, "PARTITION_INTERVAL"), DefaultPartitionInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PartitionInterval (flag: -partition-interval or env: PARTITION_INTERVAL): %w", err)
+	}
+
+	config.WriteBatchSize, err = parseInt(getValue(writeBatchSizeFlag, "WRITE_BATCH_SIZE"), DefaultWriteBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WriteBatchSize (flag: -write-batch-size or env: WRITE_BATCH_SIZE): %w", err)
+	}
+
+	config.WriteQueueSize, err = parseInt(getValue(writeQueueSizeFlag, "WRITE_QUEUE_SIZE"), DefaultWriteQueueSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WriteQueueSize (flag: -write-queue-size or env: WRITE_QUEUE_SIZE): %w", err)
+	}
+
+	config.WriteFlushInterval, err = parseDuration(getValue(writeFlushIntervalFlag, "WRITE_FLUSH_INTERVAL"), DefaultWriteFlushInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WriteFlushInterval (flag: -write-flush-interval or env: WRITE_FLUSH_INTERVAL): %w", err)
+	}
+
+	config.SpoolDir = getValue(spoolDirFlag, "SPOOL_DIR")
+
+	config.SpoolSegmentSize, err = parseInt(getValue(spoolSegmentSizeFlag, "SPOOL_SEGMENT_SIZE"), DefaultSpoolSegmentSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SpoolSegmentSize (flag: -spool-segment-size or env: SPOOL_SEGMENT_SIZE): %w", err)
 	}
 
+	config.SpoolReplayInterval, err = parseDuration(getValue(spoolReplayIntervalFlag, "SPOOL_REPLAY_INTERVAL"), DefaultSpoolReplayInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SpoolReplayInterval (flag: -spool-replay-interval or env: SPOOL_REPLAY_INTERVAL): %w", err)
+	}
+
+	config.SpoolMaxAttempts, err = parseInt(getValue(spoolMaxAttemptsFlag, "SPOOL_MAX_ATTEMPTS"), DefaultSpoolMaxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SpoolMaxAttempts (flag: -spool-max-attempts or env: SPOOL_MAX_ATTEMPTS): %w", err)
+	}
+
+	config.StorageBackend = getDefault(getValue(storageBackendFlag, "STORAGE_BACKEND"), DefaultStorageBackend)
+	config.SQLitePath = getDefault(getValue(sqlitePathFlag, "SQLITE_PATH"), DefaultSQLitePath)
+
 	missingFields := []string{}
 
 	if config.AppIP == "" {
@@ -73,20 +446,23 @@ func Load() (*Config, error) {
 	if config.AppPort == "" {
 		missingFields = append(missingFields, "AppPort (flag: -app-port or env: APP_PORT)")
 	}
-	if config.DBName == "" {
-		missingFields = append(missingFields, "DBName (flag: -db-name or env: DB_NAME)")
-	}
-	if config.DBHost == "" {
-		missingFields = append(missingFields, "DBHost (flag: -db-host or env: DB_HOST)")
-	}
-	if config.DBPort == "" {
-		missingFields = append(missingFields, "DBPort (flag: -db-port or env: DB_PORT)")
-	}
-	if config.DBUser == "" {
-		missingFields = append(missingFields, "DBUser (flag: -db-user or env: DB_USER)")
-	}
-	if config.DBPassword == "" {
-		missingFields = append(missingFields, "DBPassword (flag: -db-password or env: DB_PASSWORD)")
+	// only the postgres backend needs the database
+	if config.StorageBackend == "postgres" {
+		if config.DBName == "" {
+			missingFields = append(missingFields, "DBName (flag: -db-name or env: DB_NAME)")
+		}
+		if config.DBHost == "" {
+			missingFields = append(missingFields, "DBHost (flag: -db-host or env: DB_HOST)")
+		}
+		if config.DBPort == "" {
+			missingFields = append(missingFields, "DBPort (flag: -db-port or env: DB_PORT)")
+		}
+		if config.DBUser == "" {
+			missingFields = append(missingFields, "DBUser (flag: -db-user or env: DB_USER)")
+		}
+		if config.DBPassword == "" {
+			missingFields = append(missingFields, "DBPassword (flag: -db-password or env: DB_PASSWORD)")
+		}
 	}
 	if config.Mode == "" {
 		missingFields = append(missingFields, "Mode (flag: -mode or env: MODE)")
@@ -104,3 +480,92 @@ func Load() (*Config, error) {
 
 	return config, nil
 }
+
+// redacted replaces secrets of the config in its logged copy.
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy of the config safe to log, the database password and the replica connection string
+// carrying its credentials are replaced.
+func (c Config) Redacted() Config {
+	if c.DBPassword != "" {
+		c.DBPassword = redacted
+	}
+	if c.DBReadDSN != "" {
+		c.DBReadDSN = redacted
+	}
+	return c
+}
+
+// splitList splits comma-separated value into trimmed non-empty items
+func splitList(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// getDefault returns defaultValue if value is empty
+func getDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// parseFloat parses value as float, returns defaultValue if value is empty
+func parseFloat(value string, defaultValue float64) (float64, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseInt parses value as int, returns defaultValue if value is empty
+func parseInt(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// parseBool parses value as bool, returns defaultValue if value is empty
+func parseBool(value string, defaultValue bool) (bool, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// parseDuration parses value as duration, returns defaultValue if value is empty
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// parseFloatMap parses comma-separated name=value pairs
+func parseFloatMap(value string) (map[string]float64, error) {
+	values := make(map[string]float64)
+
+	for _, item := range splitList(value) {
+		name, number, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected name=value, got %q", item)
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %q: %w", name, err)
+		}
+
+		values[strings.TrimSpace(name)] = v
+	}
+
+	return values, nil
+}
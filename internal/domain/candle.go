@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+// CandleQuery selects candles of a market with start times in [Start(), To).
+// Candles are aligned to multiples of Interval since the unix epoch in UTC, which is a whole number of seconds.
+type CandleQuery struct {
+	Market   string
+	Interval time.Duration
+	From     time.Time
+	To       time.Time
+	// FillGaps adds candles of intervals without rates repeating the previous close, otherwise they are skipped.
+	FillGaps bool
+}
+
+// Start returns From aligned down to the interval, the first candle covers its whole interval rather than
+// only the rates from From onward.
+func (q CandleQuery) Start() time.Time {
+	interval := int64(q.Interval / time.Second)
+	if interval <= 0 {
+		return q.From
+	}
+
+	// seconds since the epoch do not overflow for any time unlike nanoseconds
+	secs := q.From.Unix()
+
+	offset := secs % interval
+	if offset < 0 {
+		offset += interval
+	}
+
+	return time.Unix(secs-offset, 0).In(q.From.Location())
+}
+
+// Candle is an aggregation of rates saved within an interval.
+type Candle struct {
+	Start time.Time
+	Mid   OHLC
+	Ask   OHLC
+	Bid   OHLC
+	// Count is a number of aggregated rates, zero for a filled gap.
+	Count int64
+	// Filled is true for an interval without rates.
+	Filled bool
+}
+
+// OHLC is the open, high, low and close prices of an interval.
+type OHLC struct {
+	Open  Decimal
+	High  Decimal
+	Low   Decimal
+	Close Decimal
+}
+
+// Flat returns OHLC with all prices equal to the close price, it is used for intervals without rates.
+func (o OHLC) Flat() OHLC {
+	return OHLC{Open: o.Close, High: o.Close, Low: o.Close, Close: o.Close}
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCandleQuery_Start(t *testing.T) {
+	hour := time.Unix(1700000000, 0).UTC().Truncate(time.Hour)
+
+	tests := []struct {
+		name     string
+		from     time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{
+			name:     "Aligned",
+			from:     hour,
+			interval: time.Hour,
+			want:     hour,
+		},
+		{
+			name:     "Unaligned",
+			from:     hour.Add(17*time.Minute + 500*time.Millisecond),
+			interval: 5 * time.Minute,
+			want:     hour.Add(15 * time.Minute),
+		},
+		{
+			name:     "Day in UTC",
+			from:     hour.Add(-time.Nanosecond).In(time.FixedZone("UTC+3", 3*60*60)),
+			interval: 24 * time.Hour,
+			want:     hour.Truncate(24 * time.Hour),
+		},
+		{
+			name:     "Before the epoch",
+			from:     time.Date(1, time.January, 1, 0, 30, 0, 0, time.UTC),
+			interval: time.Hour,
+			want:     time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CandleQuery{From: tt.from, Interval: tt.interval}.Start()
+
+			assert.True(t, tt.want.Equal(got), "Start() = %s, want %s", got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode defines how a decimal is rounded when digits are dropped.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest neighbour, ties go to the even one.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest neighbour, ties go away from zero.
+	RoundHalfUp
+	// RoundDown rounds towards zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor
+	// RoundCeiling rounds towards positive infinity.
+	RoundCeiling
+)
+
+// maxExponent bounds the exponent of a parsed decimal, so a short input cannot allocate a huge number.
+const maxExponent = 1000
+
+// ErrDivisionByZero is returned when a decimal is divided by zero.
+var ErrDivisionByZero = errors.New("division by zero")
+
+var bigTen = big.NewInt(10)
+
+// Decimal is an arbitrary-precision decimal number, coefficient * 10^-scale.
+// The zero value is 0. Decimals are immutable, operations return new values.
+// The scale is kept as given, so "100.50" is formatted back as "100.50".
+type Decimal struct {
+	// coef is nil for zero and never mutated after construction
+	coef  *big.Int
+	scale int32
+}
+
+// NewDecimal returns coef * 10^-scale.
+func NewDecimal(coef int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(coef), scale)
+}
+
+// DecimalFromFloat returns the shortest decimal representing f.
+func DecimalFromFloat(f float64) (Decimal, error) {
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// ParseDecimal parses a decimal in plain or exponent notation, e.g. "-12.345" or "1.5e-3".
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exponent := s, int64(0)
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil || exp > maxExponent || exp < -maxExponent {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		mantissa, exponent = s[:i], exp
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+	digits := integer + fraction
+
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	coef, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	scale := int64(len(fraction)) - exponent
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+
+	return newDecimal(coef, int32(scale)), nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a decimal.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func newDecimal(coef *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		coef = new(big.Int).Mul(coef, pow10(int64(-scale)))
+		scale = 0
+	}
+
+	if coef.Sign() == 0 {
+		coef = nil
+	}
+
+	return Decimal{coef: coef, scale: scale}
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(n), nil)
+}
+
+// bigInt returns the coefficient, it must not be modified.
+func (d Decimal) bigInt() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+
+	return d.coef
+}
+
+// rescaled returns the coefficient of d at a scale not less than d.scale.
+func (d Decimal) rescaled(scale int32) *big.Int {
+	return new(big.Int).Mul(d.bigInt(), pow10(int64(scale-d.scale)))
+}
+
+// Scale returns the number of fractional digits.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	return d.bigInt().Sign()
+}
+
+// IsZero reports whether d is zero.
+func (d Decimal) IsZero() bool {
+	return d.coef == nil
+}
+
+// Cmp compares d and other numerically, returns -1, 0 or +1.
+func (d Decimal) Cmp(other Decimal) int {
+	scale := max(d.scale, other.scale)
+
+	return d.rescaled(scale).Cmp(other.rescaled(scale))
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return newDecimal(new(big.Int).Neg(d.bigInt()), d.scale)
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return newDecimal(new(big.Int).Abs(d.bigInt()), d.scale)
+}
+
+// Add returns d + other exactly.
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+
+	return newDecimal(new(big.Int).Add(d.rescaled(scale), other.rescaled(scale)), scale)
+}
+
+// Sub returns d - other exactly.
+func (d Decimal) Sub(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+
+	return newDecimal(new(big.Int).Sub(d.rescaled(scale), other.rescaled(scale)), scale)
+}
+
+// Mul returns d * other exactly.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return newDecimal(new(big.Int).Mul(d.bigInt(), other.bigInt()), d.scale+other.scale)
+}
+
+// Div returns d / other with scale fractional digits rounded by mode, a negative scale rounds to tens, hundreds and so on.
+// Returns ErrDivisionByZero if other is zero.
+func (d Decimal) Div(other Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	if other.IsZero() {
+		return Decimal{}, ErrDivisionByZero
+	}
+
+	// d / other * 10^scale = d.coef * 10^exp / other.coef, where exp = scale + other.scale - d.scale,
+	// the power of ten goes to the divisor when exp is negative as pow10 of a negative exponent is 1
+	num, den := new(big.Int).Set(d.bigInt()), new(big.Int).Set(other.bigInt())
+	if exp := int64(scale) + int64(other.scale) - int64(d.scale); exp >= 0 {
+		num.Mul(num, pow10(exp))
+	} else {
+		den.Mul(den, pow10(-exp))
+	}
+
+	return newDecimal(divRound(num, den, mode), scale), nil
+}
+
+// Round returns d rounded by mode to at most scale fractional digits.
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if scale >= d.scale {
+		return d
+	}
+
+	return newDecimal(divRound(d.bigInt(), pow10(int64(d.scale-scale)), mode), scale)
+}
+
+// divRound returns num / den rounded by mode.
+func divRound(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	sign := num.Sign() * den.Sign()
+
+	// half compares the remainder with a half of the divisor
+	twice := new(big.Int).Abs(r)
+	half := twice.Lsh(twice, 1).Cmp(new(big.Int).Abs(den))
+
+	var away bool
+	switch mode {
+	case RoundHalfEven:
+		away = half > 0 || half == 0 && q.Bit(0) == 1
+	case RoundHalfUp:
+		away = half >= 0
+	case RoundDown:
+		away = false
+	case RoundUp:
+		away = true
+	case RoundFloor:
+		away = sign < 0
+	case RoundCeiling:
+		away = sign > 0
+	}
+
+	if away {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+
+	return q
+}
+
+// Half returns d / 2 exactly, adding a fractional digit only if needed.
+func (d Decimal) Half() Decimal {
+	scale := d.scale
+	if d.bigInt().Bit(0) == 1 {
+		scale++
+	}
+
+	h, _ := d.Div(NewDecimal(2, 0), scale, RoundHalfEven)
+
+	return h
+}
+
+// Float64 returns the nearest float64 value of d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+
+	return f
+}
+
+// String formats d in plain notation with exactly Scale fractional digits.
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.bigInt()).String()
+
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	}
+
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+
+	return digits
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
+
+// Scan implements sql.Scanner, NULL is scanned as zero.
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case string:
+		return d.UnmarshalText([]byte(v))
+	case []byte:
+		return d.UnmarshalText(v)
+	case int64:
+		*d = NewDecimal(v, 0)
+		return nil
+	case float64:
+		parsed, err := DecimalFromFloat(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+}
+
+// Value implements driver.Valuer, the decimal is passed as its exact string.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
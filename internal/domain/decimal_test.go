@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      string
+		errorText string
+	}{
+		{input: "100.5", want: "100.5"},
+		{input: "100.50", want: "100.50"},
+		{input: "-0.001", want: "-0.001"},
+		{input: "+7", want: "7"},
+		{input: ".5", want: "0.5"},
+		{input: "5.", want: "5"},
+		{input: "0.000", want: "0.000"},
+		{input: "1.5e-3", want: "0.0015"},
+		{input: "1.5E3", want: "1500"},
+		{input: "123456789012345678901234567890.123456789", want: "123456789012345678901234567890.123456789"},
+		{input: "", errorText: `invalid decimal ""`},
+		{input: "-", errorText: `invalid decimal "-"`},
+		{input: "1.2.3", errorText: `invalid decimal "1.2.3"`},
+		{input: "Inf", errorText: `invalid decimal "Inf"`},
+		{input: "NaN", errorText: `invalid decimal "NaN"`},
+		{input: "1e", errorText: `invalid decimal "1e"`},
+		{input: "1e100000", errorText: `invalid decimal "1e100000"`},
+		{input: " 1", errorText: `invalid decimal " 1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDecimal(tt.input)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a := MustParseDecimal("100.25")
+	b := MustParseDecimal("-0.5")
+
+	assert.Equal(t, "99.75", a.Add(b).String())
+	assert.Equal(t, "100.75", a.Sub(b).String())
+	assert.Equal(t, "-50.125", a.Mul(b).String())
+	assert.Equal(t, "50.125", a.Mul(b).Neg().String())
+	assert.Equal(t, "0.5", b.Abs().String())
+	assert.Equal(t, "0.00", a.Sub(a).String())
+	assert.True(t, a.Sub(a).IsZero())
+	assert.Equal(t, Decimal{}, a.Sub(a).Round(0, RoundHalfEven), "zero has a single representation")
+
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(a))
+	assert.Equal(t, 0, MustParseDecimal("1.50").Cmp(MustParseDecimal("1.5")))
+	assert.Equal(t, 0, Decimal{}.Cmp(MustParseDecimal("0.00")))
+
+	assert.Equal(t, "50.125", a.Half().String())
+	assert.Equal(t, "2.5", NewDecimal(5, 0).Half().String())
+	assert.Equal(t, "2", NewDecimal(4, 0).Half().String())
+
+	assert.Equal(t, "1.23", NewDecimal(123, 2).String())
+	assert.Equal(t, "1200", NewDecimal(12, -2).String())
+	assert.Equal(t, 100.25, a.Float64())
+}
+
+func TestDecimal_Div(t *testing.T) {
+	got, err := NewDecimal(1, 0).Div(NewDecimal(3, 0), 4, RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.3333", got.String())
+
+	got, err = MustParseDecimal("1.5").Div(MustParseDecimal("0.25"), 0, RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, "6", got.String())
+
+	got, err = MustParseDecimal("-2").Div(NewDecimal(3, 0), 2, RoundHalfUp)
+	assert.NoError(t, err)
+	assert.Equal(t, "-0.67", got.String())
+
+	got, err = MustParseDecimal("1.5").Div(MustParseDecimal("0.125"), 3, RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, "12.000", got.String())
+
+	// negative scales round to tens and hundreds
+	got, err = NewDecimal(1250, 0).Div(NewDecimal(1, 0), -2, RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, "1200", got.String())
+
+	got, err = NewDecimal(1250, 0).Div(NewDecimal(1, 0), -2, RoundHalfUp)
+	assert.NoError(t, err)
+	assert.Equal(t, "1300", got.String())
+
+	got, err = MustParseDecimal("123.45").Div(MustParseDecimal("0.5"), -1, RoundDown)
+	assert.NoError(t, err)
+	assert.Equal(t, "240", got.String())
+
+	_, err = NewDecimal(1, 0).Div(Decimal{}, 2, RoundHalfEven)
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+}
+
+func TestDecimal_Round(t *testing.T) {
+	tests := []struct {
+		value string
+		mode  RoundingMode
+		want  string
+	}{
+		{value: "2.45", mode: RoundHalfEven, want: "2.4"},
+		{value: "2.55", mode: RoundHalfEven, want: "2.6"},
+		{value: "2.451", mode: RoundHalfEven, want: "2.5"},
+		{value: "-2.45", mode: RoundHalfEven, want: "-2.4"},
+		{value: "2.45", mode: RoundHalfUp, want: "2.5"},
+		{value: "-2.45", mode: RoundHalfUp, want: "-2.5"},
+		{value: "2.44", mode: RoundHalfUp, want: "2.4"},
+		{value: "2.49", mode: RoundDown, want: "2.4"},
+		{value: "-2.49", mode: RoundDown, want: "-2.4"},
+		{value: "2.41", mode: RoundUp, want: "2.5"},
+		{value: "-2.41", mode: RoundUp, want: "-2.5"},
+		{value: "2.49", mode: RoundFloor, want: "2.4"},
+		{value: "-2.41", mode: RoundFloor, want: "-2.5"},
+		{value: "2.41", mode: RoundCeiling, want: "2.5"},
+		{value: "-2.49", mode: RoundCeiling, want: "-2.4"},
+		{value: "-0.01", mode: RoundFloor, want: "-0.1"},
+		{value: "2.4", mode: RoundUp, want: "2.4"},
+		{value: "2", mode: RoundUp, want: "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, MustParseDecimal(tt.value).Round(1, tt.mode).String())
+		})
+	}
+}
+
+func TestDecimal_Encoding(t *testing.T) {
+	var got struct {
+		Price Decimal `json:"price"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": "100.50"}`), &got))
+	assert.Equal(t, "100.50", got.Price.String())
+
+	data, err := json.Marshal(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price": "100.50"}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": "abc"}`), &got))
+}
+
+func TestDecimal_Scan(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       any
+		want      string
+		errorText string
+	}{
+		{name: "String", src: "100.50", want: "100.50"},
+		{name: "Bytes", src: []byte("0.001"), want: "0.001"},
+		{name: "Integer", src: int64(42), want: "42"},
+		{name: "Float", src: 99.5, want: "99.5"},
+		{name: "NULL", src: nil, want: "0"},
+		{name: "Unsupported type", src: true, errorText: "cannot scan bool into Decimal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Decimal
+			err := d.Scan(tt.src)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, d.String())
+
+			value, err := d.Value()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+func TestRate_Derived(t *testing.T) {
+	rate := &Rate{Ask: MustParseDecimal("100.5"), Bid: MustParseDecimal("99.4")}
+
+	assert.Equal(t, "99.95", rate.Mid().String())
+	assert.Equal(t, "1.1", rate.Spread().String())
+	assert.Equal(t, "110.06", rate.SpreadBps().String())
+
+	assert.Equal(t, "0", (&Rate{}).SpreadBps().String())
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+)
+
+type OrderBook struct {
+	Market    string
+	Provider  string
+	Asks      []PriceLevel
+	Bids      []PriceLevel
+	Timestamp time.Time
+}
+
+// PriceLevel is a single level of the order book.
+// Volume is in the base currency, Amount is in the quote currency.
+type PriceLevel struct {
+	Price  string
+	Volume string
+	Amount string
+	Factor string
+}
+
+// ExecutionPrice is a price of buying and selling Amount of the base currency by walking the order book.
+type ExecutionPrice struct {
+	Market    string
+	Provider  string
+	Amount    string
+	Buy       ExecutionQuote
+	Sell      ExecutionQuote
+	Timestamp time.Time
+}
+
+type ExecutionQuote struct {
+	// VWAP is a volume-weighted average price of the execution.
+	VWAP       string
+	BestPrice  string
+	WorstPrice string
+	// SlippageBps is an adverse deviation of VWAP from the best price in basis points.
+	SlippageBps string
+}
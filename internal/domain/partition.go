@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// PartitionPeriod is a time range of rates stored in a partition of the rates table.
+type PartitionPeriod string
+
+const (
+	PartitionDay   PartitionPeriod = "day"
+	PartitionMonth PartitionPeriod = "month"
+)
+
+// ParsePartitionPeriod parses "day" or "month".
+func ParsePartitionPeriod(s string) (PartitionPeriod, error) {
+	switch p := PartitionPeriod(s); p {
+	case PartitionDay, PartitionMonth:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown partition period %q, expected %q or %q", s, PartitionDay, PartitionMonth)
+	}
+}
+
+// Start returns the start of the period containing t, periods are aligned to days and months in UTC.
+func (p PartitionPeriod) Start(t time.Time) time.Time {
+	t = t.UTC()
+	if p == PartitionMonth {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Next returns the start of the period following the one starting at start.
+func (p PartitionPeriod) Next(start time.Time) time.Time {
+	if p == PartitionMonth {
+		return start.AddDate(0, 1, 0)
+	}
+
+	return start.AddDate(0, 0, 1)
+}
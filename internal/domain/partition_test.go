@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePartitionPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      PartitionPeriod
+		errorText string
+	}{
+		{name: "Day", input: "day", want: PartitionDay},
+		{name: "Month", input: "month", want: PartitionMonth},
+		{name: "Unknown period", input: "week", errorText: `unknown partition period "week", expected "day" or "month"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePartitionPeriod(tt.input)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPartitionPeriod_StartNext(t *testing.T) {
+	// 2026-01-31 23:30 in UTC is already February in Moscow
+	at := time.Date(2026, 2, 1, 2, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	tests := []struct {
+		name      string
+		period    PartitionPeriod
+		wantStart time.Time
+		wantNext  time.Time
+	}{
+		{
+			name:      "Day",
+			period:    PartitionDay,
+			wantStart: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Month",
+			period:    PartitionMonth,
+			wantStart: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := tt.period.Start(at)
+
+			assert.Equal(t, tt.wantStart, start)
+			assert.Equal(t, tt.wantNext, tt.period.Next(start))
+		})
+	}
+}
@@ -1,11 +1,75 @@
 package domain
 
 import (
+	"errors"
 	"time"
 )
 
+// DefaultMarket is the market used when a request does not specify one.
+const DefaultMarket = "usdtrub"
+
+var (
+	// ErrUnknownMarket is returned when a market is not supported by a rate provider.
+	ErrUnknownMarket = errors.New("unknown market")
+	// ErrInvalidArgument is returned when a request parameter is malformed.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrInsufficientLiquidity is returned when the order book is too thin for the requested amount.
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	// ErrRateNotFound is returned when no rate of a market has been saved yet.
+	ErrRateNotFound = errors.New("rate not found")
+)
+
+// Rate is the top of the order book of a market quoted by a provider.
 type Rate struct {
-	Ask       string
-	Bid       string
+	Market   string
+	Provider string
+	Ask      Decimal
+	Bid      Decimal
+	// AskVolume and BidVolume are amounts of the base currency at the best prices.
+	AskVolume Decimal
+	BidVolume Decimal
+	// Timestamp is the time stamped by the exchange.
 	Timestamp time.Time
+	// ReceivedAt is the local time the upstream response was received.
+	ReceivedAt time.Time
+	// Latency is the time spent on the upstream request including retries.
+	Latency time.Duration
+	// Sources explains how an aggregated rate was built, empty for a single provider rate.
+	Sources []RateSource
+	// Stale is true for the last saved rate served while the providers are unavailable.
+	Stale bool
+}
+
+// Mid returns the exact middle of ask and bid.
+func (r *Rate) Mid() Decimal {
+	return r.Ask.Add(r.Bid).Half()
+}
+
+// Spread returns ask minus bid.
+func (r *Rate) Spread() Decimal {
+	return r.Ask.Sub(r.Bid)
+}
+
+// SpreadBps returns the spread relative to the mid price in basis points rounded to 2 fractional digits.
+// Returns zero if the mid price is zero.
+func (r *Rate) SpreadBps() Decimal {
+	bps, err := r.Spread().Mul(NewDecimal(10000, 0)).Div(r.Mid(), 2, RoundHalfEven)
+	if err != nil {
+		return Decimal{}
+	}
+
+	return bps
+}
+
+// RateSource is a quote of a single provider which was considered for an aggregated rate.
+type RateSource struct {
+	Provider string
+	// Ask and Bid are zero if the provider failed to return a quote.
+	Ask Decimal
+	Bid Decimal
+	// Weight is a normalized share of the source in the aggregated rate, zero if excluded.
+	Weight   float64
+	Excluded bool
+	// Reason describes why the source was excluded.
+	Reason string
 }
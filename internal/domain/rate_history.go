@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateHistoryQuery selects saved rates of a market with timestamps in [From, To).
+type RateHistoryQuery struct {
+	Market string
+	From   time.Time
+	To     time.Time
+	// Limit is a max number of rates in a page.
+	Limit int
+	// Cursor is RateHistoryPage.NextCursor of the previous page, empty for the first page.
+	Cursor string
+}
+
+// RateHistoryPage is a page of saved rates in time order.
+type RateHistoryPage struct {
+	Rates []*Rate
+	// NextCursor is an opaque position after the last rate, empty if there are no more rates.
+	NextCursor string
+}
+
+// HistoryCursor is a position in the rate history, rates are ordered by timestamp and then by storage id.
+type HistoryCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// String encodes the cursor as an opaque url-safe string.
+func (c HistoryCursor) String() string {
+	raw := fmt.Sprintf("%d:%d", c.Timestamp.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseHistoryCursor decodes a cursor encoded by HistoryCursor.String.
+// Returns ErrInvalidArgument if the cursor is malformed.
+func ParseHistoryCursor(value string) (HistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return HistoryCursor{}, fmt.Errorf("%w: malformed cursor %q", ErrInvalidArgument, value)
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return HistoryCursor{}, fmt.Errorf("%w: malformed cursor %q", ErrInvalidArgument, value)
+	}
+
+	ts, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return HistoryCursor{}, fmt.Errorf("%w: malformed cursor %q", ErrInvalidArgument, value)
+	}
+
+	c := HistoryCursor{Timestamp: time.Unix(0, ts).UTC()}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return HistoryCursor{}, fmt.Errorf("%w: malformed cursor %q", ErrInvalidArgument, value)
+	}
+
+	return c, nil
+}
+
+// After returns the position the page starts after, the cursor of the previous page
+// or the start of the range for the first page, ids are positive so rates stamped exactly at From are included.
+// Returns ErrInvalidArgument if the cursor is malformed or out of [From, To).
+func (q RateHistoryQuery) After() (HistoryCursor, error) {
+	if q.Cursor == "" {
+		return HistoryCursor{Timestamp: q.From}, nil
+	}
+
+	c, err := ParseHistoryCursor(q.Cursor)
+	if err != nil {
+		return HistoryCursor{}, err
+	}
+
+	if c.Timestamp.Before(q.From) || !c.Timestamp.Before(q.To) {
+		return HistoryCursor{}, fmt.Errorf("%w: cursor %q is out of the requested time range", ErrInvalidArgument, q.Cursor)
+	}
+
+	return c, nil
+}
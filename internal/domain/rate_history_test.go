@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryCursor(t *testing.T) {
+	c := HistoryCursor{Timestamp: time.Unix(1700000000, 123456000).UTC(), ID: 42}
+
+	got, err := ParseHistoryCursor(c.String())
+	assert.NoError(t, err)
+	assert.Equal(t, c, got)
+
+	for _, value := range []string{"!", "MTIz", "YTo0Mg", "MTIzOmI"} {
+		_, err := ParseHistoryCursor(value)
+		assert.ErrorIs(t, err, ErrInvalidArgument, value)
+	}
+}
+
+func TestRateHistoryQuery_After(t *testing.T) {
+	from := time.Unix(1700000000, 0).UTC()
+	to := from.Add(time.Hour)
+
+	cursor := func(ts time.Time) string {
+		return HistoryCursor{Timestamp: ts, ID: 7}.String()
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    HistoryCursor
+		errorIs error
+	}{
+		{
+			name: "First page starts at From",
+			want: HistoryCursor{Timestamp: from},
+		},
+		{
+			name:   "Cursor within the range",
+			cursor: cursor(from.Add(time.Minute)),
+			want:   HistoryCursor{Timestamp: from.Add(time.Minute), ID: 7},
+		},
+		{
+			name:   "Cursor at From",
+			cursor: cursor(from),
+			want:   HistoryCursor{Timestamp: from, ID: 7},
+		},
+		{
+			name:    "Cursor before From",
+			cursor:  cursor(from.Add(-time.Nanosecond)),
+			errorIs: ErrInvalidArgument,
+		},
+		{
+			name:    "Cursor at To",
+			cursor:  cursor(to),
+			errorIs: ErrInvalidArgument,
+		},
+		{
+			name:    "Malformed cursor",
+			cursor:  "!",
+			errorIs: ErrInvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RateHistoryQuery{Market: "usdtrub", From: from, To: to, Limit: 10, Cursor: tt.cursor}.After()
+
+			if tt.errorIs != nil {
+				assert.ErrorIs(t, err, tt.errorIs)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// RetentionCutoffs are times before which rates are removed.
+// Raw rates before Raw are rolled up into minute and hour candles and deleted along with partitions ending before Raw,
+// minute candles before Minute and hour candles before Hour are deleted.
+type RetentionCutoffs struct {
+	Raw    time.Time
+	Minute time.Time
+	Hour   time.Time
+}
+
+// RetentionResult is a number of rows rolled up and deleted by a retention run.
+type RetentionResult struct {
+	// Skipped is true if the retention was being applied by another instance.
+	Skipped bool
+
+	RolledUpMinutes int64
+	RolledUpHours   int64
+
+	// DroppedPartitions is a number of dropped partitions of raw rates, their rates are not counted in DeletedRaw.
+	DroppedPartitions int64
+	DeletedRaw        int64
+	DeletedMinutes    int64
+	DeletedHours      int64
+}
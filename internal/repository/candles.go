@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"final/internal/domain"
+	"time"
+)
+
+// candleStart returns the start of the interval containing t, intervals are aligned to multiples of interval
+// since the unix epoch like in candlesSQL.
+func candleStart(t time.Time, interval time.Duration) time.Time {
+	ns := t.UnixNano()
+
+	offset := ns % int64(interval)
+	if offset < 0 {
+		offset += int64(interval)
+	}
+
+	return time.Unix(0, ns-offset).UTC()
+}
+
+// aggregateCandles aggregates rates ordered by timestamp and id into candles of intervals with rates
+// in order of start time. It is the in-process counterpart of candlesSQL for backends without it.
+func aggregateCandles(rates []*domain.Rate, interval time.Duration) []domain.Candle {
+	var candles []domain.Candle
+
+	for _, rate := range rates {
+		start := candleStart(rate.Timestamp, interval)
+		mid := rate.Mid()
+
+		if len(candles) == 0 || !candles[len(candles)-1].Start.Equal(start) {
+			candles = append(candles, domain.Candle{
+				Start: start,
+				Mid:   domain.OHLC{Close: mid}.Flat(),
+				Ask:   domain.OHLC{Close: rate.Ask}.Flat(),
+				Bid:   domain.OHLC{Close: rate.Bid}.Flat(),
+			})
+		}
+
+		c := &candles[len(candles)-1]
+		c.Mid = extendOHLC(c.Mid, mid)
+		c.Ask = extendOHLC(c.Ask, rate.Ask)
+		c.Bid = extendOHLC(c.Bid, rate.Bid)
+		c.Count++
+	}
+
+	return candles
+}
+
+// extendOHLC returns o with the price of a later rate of the interval.
+func extendOHLC(o domain.OHLC, price domain.Decimal) domain.OHLC {
+	if price.Cmp(o.High) > 0 {
+		o.High = price
+	}
+
+	if price.Cmp(o.Low) < 0 {
+		o.Low = price
+	}
+
+	o.Close = price
+
+	return o
+}
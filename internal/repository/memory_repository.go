@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"final/internal/domain"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository keeps saved rates in memory, they are lost on restart.
+// It lets the service run without a database, e.g. in development and tests.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	lastID int64
+	// rates of every market in order of timestamp and id
+	rates map[string][]memoryRate
+}
+
+type memoryRate struct {
+	id   int64
+	rate domain.Rate
+}
+
+func (m *memoryRate) after(c domain.HistoryCursor) bool {
+	return m.rate.Timestamp.After(c.Timestamp) || m.rate.Timestamp.Equal(c.Timestamp) && m.id > c.ID
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{rates: make(map[string][]memoryRate)}
+}
+
+func (r *MemoryRepository) SaveRate(ctx context.Context, rate *domain.Rate) (bool, error) {
+	inserted, err := r.SaveRates(ctx, []*domain.Rate{rate})
+	return inserted > 0, err
+}
+
+// SaveRates keeps the rates, only the stored fields are kept like in a database.
+// Rates with the market, provider and timestamp of a kept rate are skipped. Returns the number of new rates.
+func (r *MemoryRepository) SaveRates(ctx context.Context, rates []*domain.Rate) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inserted := 0
+
+	for _, rate := range rates {
+		market := r.rates[rate.Market]
+		ts := rate.Timestamp.UTC()
+
+		// ids grow, so the rate goes after the rates with the same timestamp
+		i := sort.Search(len(market), func(i int) bool { return market[i].rate.Timestamp.After(ts) })
+
+		if hasRate(market[:i], rate.Provider, ts) {
+			continue
+		}
+
+		r.lastID++
+		inserted++
+
+		saved := memoryRate{
+			id: r.lastID,
+			rate: domain.Rate{
+				Market:     rate.Market,
+				Provider:   rate.Provider,
+				Ask:        rate.Ask,
+				Bid:        rate.Bid,
+				AskVolume:  rate.AskVolume,
+				BidVolume:  rate.BidVolume,
+				Timestamp:  ts,
+				ReceivedAt: rate.ReceivedAt.UTC(),
+				Latency:    rate.Latency.Truncate(time.Millisecond),
+			},
+		}
+
+		r.rates[rate.Market] = slices.Insert(market, i, saved)
+	}
+
+	return inserted, nil
+}
+
+// hasRate returns whether rates ordered by timestamp, which end with the rates of ts, have a rate of the provider at ts.
+func hasRate(rates []memoryRate, provider string, ts time.Time) bool {
+	for i := len(rates) - 1; i >= 0 && rates[i].rate.Timestamp.Equal(ts); i-- {
+		if rates[i].rate.Provider == provider {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetLatestRate returns the saved rate of the market with the latest timestamp.
+// Returns domain.ErrRateNotFound if no rate of the market has been saved.
+func (r *MemoryRepository) GetLatestRate(ctx context.Context, market string) (*domain.Rate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := r.rates[market]
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: %q", domain.ErrRateNotFound, market)
+	}
+
+	rate := rates[len(rates)-1].rate
+
+	return &rate, nil
+}
+
+// GetRateHistory returns a page of saved rates of the market in order of timestamp and id.
+// Returns domain.ErrInvalidArgument if the cursor is malformed or out of the requested range.
+func (r *MemoryRepository) GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error) {
+	after, err := q.After()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := r.rates[q.Market]
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].after(after) })
+
+	page := &domain.RateHistoryPage{Rates: []*domain.Rate{}}
+
+	for ; i < len(rates) && rates[i].rate.Timestamp.Before(q.To); i++ {
+		if len(page.Rates) == q.Limit {
+			last := rates[i-1]
+			page.NextCursor = domain.HistoryCursor{Timestamp: last.rate.Timestamp, ID: last.id}.String()
+			break
+		}
+
+		rate := rates[i].rate
+		page.Rates = append(page.Rates, &rate)
+	}
+
+	return page, nil
+}
+
+// GetCandles aggregates saved rates of the market into candles of intervals with rates, in order of start time.
+func (r *MemoryRepository) GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := r.rates[q.Market]
+	start := q.Start()
+	from := sort.Search(len(rates), func(i int) bool { return !rates[i].rate.Timestamp.Before(start) })
+	to := sort.Search(len(rates), func(i int) bool { return !rates[i].rate.Timestamp.Before(q.To) })
+
+	selected := make([]*domain.Rate, 0, max(to-from, 0))
+	for i := from; i < to; i++ {
+		selected = append(selected, &rates[i].rate)
+	}
+
+	return aggregateCandles(selected, q.Interval), nil
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// migrationsLockID is a key of the postgres advisory lock serializing migrations of app instances.
+const migrationsLockID = 7_236_418_501
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus is a migration with the time it was applied, zero if it is pending.
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// LoadMigrations reads NNNN_name.up.sql and NNNN_name.down.sql files of fsys, sorted by version.
+// Every migration must have an up file, a down file is optional.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q, expected NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of migration %q: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations %q and %q have the same version %d", m.Name, match[2], version)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+func NewMigrator(db *sqlx.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Migrator applies migrations and records applied versions in the "schema_migrations" table.
+// Every run takes a postgres advisory lock, so app instances migrating at startup do not race.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+// Up applies all pending migrations in a single transaction and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.inTx(ctx, func(tx *sqlx.Tx) error {
+		versions, err := appliedVersions(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, migration.up); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			query := `INSERT INTO "schema_migrations" ("version", "name") VALUES ($1, $2)`
+			if _, err := tx.ExecContext(ctx, query, migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Down reverts the latest applied migration and returns it, nil if no migration is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+
+	err := m.inTx(ctx, func(tx *sqlx.Tx) error {
+		var versions []int64
+		query := `SELECT "version" FROM "schema_migrations" ORDER BY "version" DESC LIMIT 1`
+		if err := tx.SelectContext(ctx, &versions, query); err != nil {
+			return fmt.Errorf("failed to get the latest migration: %w", err)
+		}
+
+		if len(versions) == 0 {
+			return nil
+		}
+
+		i := slices.IndexFunc(m.migrations, func(migration Migration) bool {
+			return migration.Version == versions[0]
+		})
+		if i < 0 {
+			return fmt.Errorf("applied migration %d is unknown", versions[0])
+		}
+
+		migration := m.migrations[i]
+		if migration.down == "" {
+			return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+
+		if _, err := tx.ExecContext(ctx, migration.down); err != nil {
+			return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		query = `DELETE FROM "schema_migrations" WHERE "version" = $1`
+		if _, err := tx.ExecContext(ctx, query, migration.Version); err != nil {
+			return fmt.Errorf("failed to record revert of migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		reverted = &migration
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// Status returns all known migrations with the times they were applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := m.inTx(ctx, func(tx *sqlx.Tx) error {
+		versions, err := appliedVersions(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]MigrationStatus, len(m.migrations))
+		for i, migration := range m.migrations {
+			statuses[i] = MigrationStatus{Migration: migration, AppliedAt: versions[migration.Version]}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// inTx runs fn in a transaction holding the migrations lock, the versions table is created if needed.
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	// rollback is a no-op after commit
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationsLockID); err != nil {
+		return fmt.Errorf("failed to acquire migrations lock: %w", err)
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS "schema_migrations" (
+			"version" BIGINT PRIMARY KEY,
+			"name" VARCHAR(255) NOT NULL,
+			"applied_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration transaction: %w", err)
+	}
+
+	return nil
+}
+
+type appliedMigrationRow struct {
+	Version   int64     `db:"version"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// appliedVersions returns times the applied migrations were applied at by their versions.
+func appliedVersions(ctx context.Context, tx *sqlx.Tx) (map[int64]time.Time, error) {
+	var rows []appliedMigrationRow
+
+	query := `SELECT "version", "applied_at" FROM "schema_migrations"`
+	if err := tx.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	versions := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+
+	return versions, nil
+}
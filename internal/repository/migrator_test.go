@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"final/migrations"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(content string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(content)}
+	}
+
+	tests := []struct {
+		name      string
+		fsys      fstest.MapFS
+		want      []Migration
+		errorText string
+	}{
+		{
+			name: "Migrations are sorted by version",
+			fsys: fstest.MapFS{
+				"0010_add_index.up.sql":     file("CREATE INDEX"),
+				"0002_create_rate.up.sql":   file("CREATE TABLE"),
+				"0002_create_rate.down.sql": file("DROP TABLE"),
+				"migrations.go":             file("package migrations"),
+			},
+			want: []Migration{
+				{Version: 2, Name: "create_rate", up: "CREATE TABLE", down: "DROP TABLE"},
+				{Version: 10, Name: "add_index", up: "CREATE INDEX"},
+			},
+		},
+		{
+			name:      "Invalid file name",
+			fsys:      fstest.MapFS{"create_rate.sql": file("CREATE TABLE")},
+			errorText: `invalid migration file name "create_rate.sql", expected NNNN_name.up.sql or NNNN_name.down.sql`,
+		},
+		{
+			name: "Duplicate version",
+			fsys: fstest.MapFS{
+				"0001_create_rate.up.sql": file("CREATE TABLE"),
+				"0001_add_index.up.sql":   file("CREATE INDEX"),
+			},
+			errorText: `migrations "add_index" and "create_rate" have the same version 1`,
+		},
+		{
+			name:      "Missing up file",
+			fsys:      fstest.MapFS{"0001_create_rate.down.sql": file("DROP TABLE")},
+			errorText: "migration 1_create_rate has no up file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadMigrations(tt.fsys)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	got, err := LoadMigrations(migrations.FS)
+	assert.NoError(t, err)
+
+	for i, m := range got {
+		assert.Equal(t, int64(i+1), m.Version, "versions are sequential")
+		assert.NotEmpty(t, m.down, "migration %d_%s has a down file", m.Version, m.Name)
+	}
+}
+
+func expectMigrationTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+		WithArgs(migrationsLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "schema_migrations"`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestMigrator_Up(t *testing.T) {
+	appliedAt := time.Unix(1700000000, 0).UTC()
+
+	ms := []Migration{
+		{Version: 1, Name: "create_rate", up: "CREATE TABLE rate"},
+		{Version: 2, Name: "add_index", up: "CREATE INDEX rate_idx"},
+		{Version: 3, Name: "add_column", up: "ALTER TABLE rate"},
+	}
+
+	tests := []struct {
+		name      string
+		mock      func(mock sqlmock.Sqlmock)
+		want      []Migration
+		errorText string
+	}{
+		{
+			name: "Pending migrations are applied",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectMigrationTx(mock)
+				mock.ExpectQuery(`SELECT "version", "applied_at" FROM "schema_migrations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+				mock.ExpectExec("CREATE INDEX rate_idx").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO "schema_migrations"`).
+					WithArgs(int64(2), "add_index").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("ALTER TABLE rate").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO "schema_migrations"`).
+					WithArgs(int64(3), "add_column").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: ms[1:],
+		},
+		{
+			name: "Nothing to apply",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectMigrationTx(mock)
+				mock.ExpectQuery(`SELECT "version", "applied_at" FROM "schema_migrations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).
+						AddRow(1, appliedAt).AddRow(2, appliedAt).AddRow(3, appliedAt))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Failed migration rolls back all",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectMigrationTx(mock)
+				mock.ExpectQuery(`SELECT "version", "applied_at" FROM "schema_migrations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+				mock.ExpectExec("CREATE INDEX rate_idx").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO "schema_migrations"`).
+					WithArgs(int64(2), "add_index").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("ALTER TABLE rate").WillReturnError(errors.New("syntax error"))
+				mock.ExpectRollback()
+			},
+			errorText: "failed to apply migration 3_add_column: syntax error",
+		},
+		{
+			name: "Lock is not acquired",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WillReturnError(errors.New("canceled"))
+				mock.ExpectRollback()
+			},
+			errorText: "failed to acquire migrations lock: canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer mockDB.Close()
+
+			tt.mock(mock)
+
+			got, err := NewMigrator(sqlx.NewDb(mockDB, "sqlmock"), ms).Up(context.Background())
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	ms := []Migration{
+		{Version: 1, Name: "create_rate", up: "CREATE TABLE rate", down: "DROP TABLE rate"},
+		{Version: 2, Name: "add_index", up: "CREATE INDEX rate_idx"},
+	}
+
+	latest := `SELECT "version" FROM "schema_migrations" ORDER BY "version" DESC LIMIT 1`
+
+	tests := []struct {
+		name      string
+		mock      func(mock sqlmock.Sqlmock)
+		want      *Migration
+		errorText string
+	}{
+		{
+			name: "Latest migration is reverted",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectMigrationTx(mock)
+				mock.ExpectQuery(latest).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+				mock.ExpectExec("DROP TABLE rate").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`DELETE FROM "schema_migrations" WHERE "version" = \$1`).
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: &ms[0],
+		},
+		{
+			name: "No applied migrations",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectMigrationTx(mock)
+				mock.ExpectQuery(latest).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Migration without down file",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectMigrationTx(mock)
+				mock.ExpectQuery(latest).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+				mock.ExpectRollback()
+			},
+			errorText: "migration 2_add_index has no down file",
+		},
+		{
+			name: "Unknown applied migration",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectMigrationTx(mock)
+				mock.ExpectQuery(latest).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(7))
+				mock.ExpectRollback()
+			},
+			errorText: "applied migration 7 is unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer mockDB.Close()
+
+			tt.mock(mock)
+
+			got, err := NewMigrator(sqlx.NewDb(mockDB, "sqlmock"), ms).Down(context.Background())
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_Status(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	appliedAt := time.Unix(1700000000, 0).UTC()
+
+	ms := []Migration{
+		{Version: 1, Name: "create_rate", up: "CREATE TABLE rate"},
+		{Version: 2, Name: "add_index", up: "CREATE INDEX rate_idx"},
+	}
+
+	expectMigrationTx(mock)
+	mock.ExpectQuery(`SELECT "version", "applied_at" FROM "schema_migrations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+	mock.ExpectCommit()
+
+	got, err := NewMigrator(sqlx.NewDb(mockDB, "sqlmock"), ms).Status(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []MigrationStatus{
+		{Migration: ms[0], AppliedAt: appliedAt},
+		{Migration: ms[1]},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
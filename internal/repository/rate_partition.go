@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"final/internal/domain"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// partitionsLockID is a key of the postgres advisory lock letting a single app instance create partitions at a time.
+const partitionsLockID = 7_236_418_503
+
+// partitionPrefix starts names of partitions of the rates table created by the app,
+// day partitions are named Rate_pYYYYMMDD and month partitions Rate_pYYYYMM.
+const partitionPrefix = "Rate_p"
+
+// ratePartition is a partition of the rates table with rates of [start, end).
+type ratePartition struct {
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+func newRatePartition(period domain.PartitionPeriod, start time.Time) ratePartition {
+	layout := "20060102"
+	if period == domain.PartitionMonth {
+		layout = "200601"
+	}
+
+	return ratePartition{
+		name:  partitionPrefix + start.Format(layout),
+		start: start,
+		end:   period.Next(start),
+	}
+}
+
+// parseRatePartition returns the partition named by newRatePartition, ok is false for other names.
+func parseRatePartition(name string) (ratePartition, bool) {
+	suffix, found := strings.CutPrefix(name, partitionPrefix)
+	if !found {
+		return ratePartition{}, false
+	}
+
+	for period, layout := range map[domain.PartitionPeriod]string{domain.PartitionDay: "20060102", domain.PartitionMonth: "200601"} {
+		if len(suffix) != len(layout) {
+			continue
+		}
+
+		start, err := time.Parse(layout, suffix)
+		if err != nil {
+			return ratePartition{}, false
+		}
+
+		return newRatePartition(period, start), true
+	}
+
+	return ratePartition{}, false
+}
+
+func (p ratePartition) overlaps(start, end time.Time) bool {
+	return p.start.Before(end) && start.Before(p.end)
+}
+
+// listRatePartitions returns the partitions of the rates table created by the app.
+func listRatePartitions(ctx context.Context, tx *sqlx.Tx) ([]ratePartition, error) {
+	var names []string
+
+	query := `
+		SELECT c."relname"
+		FROM "pg_inherits" i
+		JOIN "pg_class" c ON c."oid" = i."inhrelid"
+		WHERE i."inhparent" = '"Rate"'::regclass
+	`
+
+	if err := tx.SelectContext(ctx, &names, query); err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	partitions := make([]ratePartition, 0, len(names))
+	for _, name := range names {
+		if p, ok := parseRatePartition(name); ok {
+			partitions = append(partitions, p)
+		}
+	}
+
+	return partitions, nil
+}
+
+// CreatePartitions creates partitions of the period for rates in [from, to) which are not covered by existing partitions.
+// Days of a month partially covered by existing day partitions get day partitions, so that changing the period
+// from day to month leaves no gaps. Returns names of the created partitions.
+func (r *RateRepository) CreatePartitions(ctx context.Context, period domain.PartitionPeriod, from, to time.Time) ([]string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin partitions transaction: %w", err)
+	}
+	// rollback is a no-op after commit
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, partitionsLockID); err != nil {
+		return nil, fmt.Errorf("failed to acquire partitions lock: %w", err)
+	}
+
+	existing, err := listRatePartitions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+
+	create := func(p ratePartition) error {
+		for _, e := range existing {
+			if e.overlaps(p.start, p.end) {
+				return nil
+			}
+		}
+
+		query := fmt.Sprintf(`CREATE TABLE "%s" PARTITION OF "Rate" FOR VALUES FROM ('%s') TO ('%s')`,
+			p.name, p.start.Format(time.RFC3339), p.end.Format(time.RFC3339))
+
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create partition %s: %w", p.name, err)
+		}
+
+		existing = append(existing, p)
+		created = append(created, p.name)
+
+		return nil
+	}
+
+	for start := period.Start(from); start.Before(to); start = period.Next(start) {
+		if err := create(newRatePartition(period, start)); err != nil {
+			return nil, err
+		}
+
+		if period != domain.PartitionMonth {
+			continue
+		}
+
+		for day := start; day.Before(period.Next(start)); day = domain.PartitionDay.Next(day) {
+			if err := create(newRatePartition(domain.PartitionDay, day)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit partitions transaction: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRatePartition(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   ratePartition
+		wantOk bool
+	}{
+		{
+			name:  "Day partition",
+			input: "Rate_p20260131",
+			want: ratePartition{
+				name:  "Rate_p20260131",
+				start: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+				end:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantOk: true,
+		},
+		{
+			name:  "Month partition",
+			input: "Rate_p202612",
+			want: ratePartition{
+				name:  "Rate_p202612",
+				start: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+				end:   time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantOk: true,
+		},
+		{name: "Partition not created by the app", input: "Rate_default"},
+		{name: "Malformed date", input: "Rate_p20261340"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRatePartition(tt.input)
+
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRateRepository_CreatePartitions(t *testing.T) {
+	lock := `SELECT pg_advisory_xact_lock\(\$1\)`
+	list := `SELECT c."relname" FROM "pg_inherits"`
+
+	expectCreate := func(mock sqlmock.Sqlmock, name, from, to string) *sqlmock.ExpectedExec {
+		return mock.ExpectExec(regexp.QuoteMeta(
+			fmt.Sprintf(`CREATE TABLE "%s" PARTITION OF "Rate" FOR VALUES FROM ('%s') TO ('%s')`, name, from, to)))
+	}
+
+	tests := []struct {
+		name      string
+		period    domain.PartitionPeriod
+		from      time.Time
+		to        time.Time
+		mock      func(mock sqlmock.Sqlmock)
+		want      []string
+		errorText string
+	}{
+		{
+			name:   "Uncovered days are created",
+			period: domain.PartitionDay,
+			from:   time.Date(2026, 1, 17, 10, 0, 0, 0, time.UTC),
+			to:     time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(lock).WithArgs(partitionsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(list).WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+					AddRow("Rate_default").
+					AddRow("Rate_p20260117"))
+				expectCreate(mock, "Rate_p20260118", "2026-01-18T00:00:00Z", "2026-01-19T00:00:00Z").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				expectCreate(mock, "Rate_p20260119", "2026-01-19T00:00:00Z", "2026-01-20T00:00:00Z").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: []string{"Rate_p20260118", "Rate_p20260119"},
+		},
+		{
+			name:   "Days covered by a month are skipped",
+			period: domain.PartitionDay,
+			from:   time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC),
+			to:     time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(lock).WithArgs(partitionsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(list).WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+					AddRow("Rate_p202601"))
+				expectCreate(mock, "Rate_p20260201", "2026-02-01T00:00:00Z", "2026-02-02T00:00:00Z").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: []string{"Rate_p20260201"},
+		},
+		{
+			name:   "Month partially covered by days is covered by days",
+			period: domain.PartitionMonth,
+			from:   time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC),
+			to:     time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(lock).WithArgs(partitionsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(list).WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+					AddRow("Rate_p20260226").
+					AddRow("Rate_p20260227"))
+				expectCreate(mock, "Rate_p20260201", "2026-02-01T00:00:00Z", "2026-02-02T00:00:00Z").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				for day := 2; day <= 25; day++ {
+					expectCreate(mock, fmt.Sprintf("Rate_p202602%02d", day),
+						fmt.Sprintf("2026-02-%02dT00:00:00Z", day), fmt.Sprintf("2026-02-%02dT00:00:00Z", day+1)).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+				}
+				expectCreate(mock, "Rate_p20260228", "2026-02-28T00:00:00Z", "2026-03-01T00:00:00Z").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				expectCreate(mock, "Rate_p202603", "2026-03-01T00:00:00Z", "2026-04-01T00:00:00Z").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: func() []string {
+				var names []string
+				for day := 1; day <= 28; day++ {
+					if day != 26 && day != 27 {
+						names = append(names, fmt.Sprintf("Rate_p202602%02d", day))
+					}
+				}
+				return append(names, "Rate_p202603")
+			}(),
+		},
+		{
+			name:   "Failed create rolls back",
+			period: domain.PartitionDay,
+			from:   time.Date(2026, 1, 17, 0, 0, 0, 0, time.UTC),
+			to:     time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(lock).WithArgs(partitionsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(list).WillReturnRows(sqlmock.NewRows([]string{"relname"}))
+				expectCreate(mock, "Rate_p20260117", "2026-01-17T00:00:00Z", "2026-01-18T00:00:00Z").
+					WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			errorText: "failed to create partition Rate_p20260117: db error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer mockDB.Close()
+
+			tt.mock(mock)
+
+			got, err := NewRateRepository(sqlx.NewDb(mockDB, "sqlmock")).CreatePartitions(context.Background(), tt.period, tt.from, tt.to)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
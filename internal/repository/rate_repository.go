@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"final/internal/domain"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"strings"
 	"time"
 )
 
@@ -12,21 +15,360 @@ func NewRateRepository(db *sqlx.DB) *RateRepository {
 	return &RateRepository{db: db}
 }
 
+// NewReplicatedRateRepository creates a repository writing to the primary db and reading from the replica
+// while its replication lag is within the limit.
+func NewReplicatedRateRepository(db *sqlx.DB, replica *ReadReplica) *RateRepository {
+	return &RateRepository{db: db, replica: replica}
+}
+
 type RateRepository struct {
 	db *sqlx.DB
+	// replica is nil when reads go to the primary
+	replica *ReadReplica
+}
+
+// reader returns the pool of read queries, the replica if its lag is within the limit and the primary otherwise.
+// Retention and partitions always use the primary.
+func (r *RateRepository) reader() *sqlx.DB {
+	if r.replica != nil && r.replica.healthy.Load() {
+		return r.replica.db
+	}
+
+	return r.db
+}
+
+// MaxSaveBatch is a max number of rates saved by SaveRates, it is bounded by the number of postgres query parameters.
+const MaxSaveBatch = 65535 / rateInsertParams
+
+// rateInsertParams is a number of query parameters of an inserted rate, see rateInsertArgs.
+const rateInsertParams = 9
+
+const rateInsertSQL = `INSERT INTO "Rate" ("market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms")`
+
+// rateConflictSQL skips rates with the natural key of a saved rate, the first saved observation is kept.
+const rateConflictSQL = ` ON CONFLICT ("market", "provider", "timestamp") DO NOTHING`
+
+// rateInsertArgs returns the inserted values of the rate, its times are stored in UTC and latency in milliseconds.
+func rateInsertArgs(rate *domain.Rate) []any {
+	return []any{
+		rate.Market,
+		rate.Provider,
+		rate.Ask,
+		rate.Bid,
+		rate.AskVolume,
+		rate.BidVolume,
+		rate.Timestamp.UTC(),
+		rate.ReceivedAt.UTC(),
+		rate.Latency.Milliseconds(),
+	}
+}
+
+// SaveRate inserts the rate unless a rate of the same market, provider and timestamp is saved,
+// its times are stored in UTC and latency in milliseconds.
+// Returns whether the rate was new.
+func (r *RateRepository) SaveRate(ctx context.Context, rate *domain.Rate) (bool, error) {
+	query := rateInsertSQL + `
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	` + rateConflictSQL
+
+	res, err := r.db.ExecContext(ctx, query, rateInsertArgs(rate)...)
+
+	if err != nil {
+		return false, fmt.Errorf("error while executing SaveRate sql request: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get number of saved rates: %w", err)
+	}
+
+	return inserted > 0, nil
+}
+
+// SaveRates inserts the rates with a single multi-row statement, so either all of them are saved or none.
+// Rates with the natural key of a saved rate or of an earlier rate of the batch are skipped.
+// At most MaxSaveBatch rates can be saved at once. Returns the number of new rates.
+func (r *RateRepository) SaveRates(ctx context.Context, rates []*domain.Rate) (int, error) {
+	if len(rates) == 0 {
+		return 0, nil
+	}
+
+	if len(rates) > MaxSaveBatch {
+		return 0, fmt.Errorf("too many rates to save at once: %d, max %d", len(rates), MaxSaveBatch)
+	}
+
+	var query strings.Builder
+	args := make([]any, 0, len(rates)*rateInsertParams)
+
+	query.WriteString(rateInsertSQL + ` VALUES `)
+	for i, rate := range rates {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+
+		query.WriteString("(")
+		for j := range rateInsertParams {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", len(args)+j+1)
+		}
+		query.WriteString(")")
+
+		args = append(args, rateInsertArgs(rate)...)
+	}
+
+	query.WriteString(rateConflictSQL)
+
+	res, err := r.db.ExecContext(ctx, query.String(), args...)
+	if err != nil {
+		return 0, fmt.Errorf("error while executing SaveRates sql request: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get number of saved rates: %w", err)
+	}
+
+	return int(inserted), nil
 }
 
-func (r *RateRepository) SaveRate(ctx context.Context, rate *domain.Rate) error {
+type rateRow struct {
+	Market     string         `db:"market"`
+	Provider   string         `db:"provider"`
+	Ask        domain.Decimal `db:"ask"`
+	Bid        domain.Decimal `db:"bid"`
+	AskVolume  domain.Decimal `db:"ask_volume"`
+	BidVolume  domain.Decimal `db:"bid_volume"`
+	Timestamp  time.Time      `db:"timestamp"`
+	ReceivedAt time.Time      `db:"received_at"`
+	LatencyMs  int64          `db:"latency_ms"`
+}
+
+func (row *rateRow) toRate() *domain.Rate {
+	return &domain.Rate{
+		Market:     row.Market,
+		Provider:   row.Provider,
+		Ask:        row.Ask,
+		Bid:        row.Bid,
+		AskVolume:  row.AskVolume,
+		BidVolume:  row.BidVolume,
+		Timestamp:  row.Timestamp,
+		ReceivedAt: row.ReceivedAt,
+		Latency:    time.Duration(row.LatencyMs) * time.Millisecond,
+	}
+}
+
+// GetLatestRate returns the most recent saved rate of the market.
+// Returns domain.ErrRateNotFound if no rate of the market has been saved.
+// Partitions of rates are scanned from the latest one and the scan stops at the partition with the latest rate.
+func (r *RateRepository) GetLatestRate(ctx context.Context, market string) (*domain.Rate, error) {
+	query := `
+		SELECT "market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"
+		FROM "Rate"
+		WHERE "market" = $1
+		ORDER BY "timestamp" DESC
+		LIMIT 1
+	`
+
+	var row rateRow
+
+	err := r.reader().GetContext(ctx, &row, query, market)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %q", domain.ErrRateNotFound, market)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error while executing GetLatestRate sql request: %w", err)
+	}
+
+	return row.toRate(), nil
+}
+
+type historyRow struct {
+	ID int64 `db:"id"`
+	rateRow
+}
+
+// GetRateHistory returns a page of saved rates of the market in order of timestamp and id.
+// The page starts after the query cursor, so it is read by an index range scan rather than by skipping rows.
+// Returns domain.ErrInvalidArgument if the cursor is malformed or out of the requested range.
+func (r *RateRepository) GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error) {
+	after, err := q.After()
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		INSERT INTO "Rate" ("ask", "bid", "timestamp")
-		VALUES ($1, $2, $3)
+		SELECT "id", "market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"
+		FROM "Rate"
+		WHERE "market" = $1 AND ("timestamp", "id") > ($2, $3) AND "timestamp" >= $4 AND "timestamp" < $5
+		ORDER BY "timestamp", "id"
+		LIMIT $6
 	`
 
-	_, err := r.db.ExecContext(ctx, query, rate.Ask, rate.Bid, rate.Timestamp.Format(time.RFC3339))
+	var rows []historyRow
 
+	// one more row tells whether there is a next page, the bound on From lets partitions before it be pruned
+	err = r.reader().SelectContext(ctx, &rows, query, q.Market, after.Timestamp.UTC(), after.ID, q.From.UTC(), q.To.UTC(), q.Limit+1)
 	if err != nil {
-		return fmt.Errorf("error while executing SaveRate sql request: %w", err)
+		return nil, fmt.Errorf("error while executing GetRateHistory sql request: %w", err)
+	}
+
+	page := &domain.RateHistoryPage{}
+
+	if len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = domain.HistoryCursor{Timestamp: last.Timestamp, ID: last.ID}.String()
+	}
+
+	page.Rates = make([]*domain.Rate, len(rows))
+	for i := range rows {
+		page.Rates[i] = rows[i].toRate()
+	}
+
+	return page, nil
+}
+
+type candleRow struct {
+	Market   string         `db:"market"`
+	Start    time.Time      `db:"start"`
+	MidOpen  domain.Decimal `db:"mid_open"`
+	MidHigh  domain.Decimal `db:"mid_high"`
+	MidLow   domain.Decimal `db:"mid_low"`
+	MidClose domain.Decimal `db:"mid_close"`
+	AskOpen  domain.Decimal `db:"ask_open"`
+	AskHigh  domain.Decimal `db:"ask_high"`
+	AskLow   domain.Decimal `db:"ask_low"`
+	AskClose domain.Decimal `db:"ask_close"`
+	BidOpen  domain.Decimal `db:"bid_open"`
+	BidHigh  domain.Decimal `db:"bid_high"`
+	BidLow   domain.Decimal `db:"bid_low"`
+	BidClose domain.Decimal `db:"bid_close"`
+	Count    int64          `db:"count"`
+}
+
+// ohlcColumns are the prices of candle rows and rollup tables.
+const ohlcColumns = `"mid_open", "mid_high", "mid_low", "mid_close",
+	"ask_open", "ask_high", "ask_low", "ask_close",
+	"bid_open", "bid_high", "bid_low", "bid_close"`
+
+// candleColumns are the columns of candle rows and rollup tables.
+const candleColumns = `"market", "start", ` + ohlcColumns + `, "count"`
+
+// candlesSQL returns a query aggregating rates matching the where condition into candleColumns.
+// The interval in seconds is $1, candles are aligned to multiples of it since the unix epoch.
+// Open and close are prices of the first and the last rate of an interval by timestamp and id.
+func candlesSQL(where string) string {
+	return `
+		WITH "rates" AS (
+			SELECT
+				"market",
+				date_bin(make_interval(secs => $1), "timestamp", TIMESTAMPTZ 'epoch') AS "start",
+				("ask" + "bid") * 0.5 AS "mid", "ask", "bid", "timestamp", "id"
+			FROM "Rate"
+			WHERE ` + where + `
+		)
+		SELECT
+			"market",
+			"start",
+			(array_agg("mid" ORDER BY "timestamp", "id"))[1] AS "mid_open",
+			max("mid") AS "mid_high",
+			min("mid") AS "mid_low",
+			(array_agg("mid" ORDER BY "timestamp" DESC, "id" DESC))[1] AS "mid_close",
+			(array_agg("ask" ORDER BY "timestamp", "id"))[1] AS "ask_open",
+			max("ask") AS "ask_high",
+			min("ask") AS "ask_low",
+			(array_agg("ask" ORDER BY "timestamp" DESC, "id" DESC))[1] AS "ask_close",
+			(array_agg("bid" ORDER BY "timestamp", "id"))[1] AS "bid_open",
+			max("bid") AS "bid_high",
+			min("bid") AS "bid_low",
+			(array_agg("bid" ORDER BY "timestamp" DESC, "id" DESC))[1] AS "bid_close",
+			count(*) AS "count"
+		FROM "rates"
+		GROUP BY "market", "start"
+	`
+}
+
+// candleSourcesSQL selects parts of candles of the market $2 in [$3, $4) from every source, each period from one of them:
+// raw rates as single rate parts after the last interval rolled up by the retention,
+// minute candles of the rolled up periods and hour candles before the first minute candle.
+// Raw rates saved late before the last rolled up interval are left out like they are by the roll up.
+const candleSourcesSQL = `
+	WITH "bounds" AS (
+		SELECT
+			greatest(
+				(SELECT max("start") + INTERVAL '1 minute' FROM "Rate_1m" WHERE "market" = $2),
+				(SELECT max("start") + INTERVAL '1 hour' FROM "Rate_1h" WHERE "market" = $2)
+			) AS "raw_from",
+			(SELECT date_bin(INTERVAL '1 hour', min("start"), TIMESTAMPTZ 'epoch') FROM "Rate_1m" WHERE "market" = $2) AS "minute_from"
+	)
+	SELECT
+		"market", "timestamp" AS "part_start", "id",
+		("ask" + "bid") * 0.5 AS "mid_open", ("ask" + "bid") * 0.5 AS "mid_high",
+		("ask" + "bid") * 0.5 AS "mid_low", ("ask" + "bid") * 0.5 AS "mid_close",
+		"ask" AS "ask_open", "ask" AS "ask_high", "ask" AS "ask_low", "ask" AS "ask_close",
+		"bid" AS "bid_open", "bid" AS "bid_high", "bid" AS "bid_low", "bid" AS "bid_close",
+		1 AS "count"
+	FROM "Rate", "bounds"
+	WHERE "market" = $2 AND "timestamp" >= $3 AND "timestamp" < $4
+		AND ("raw_from" IS NULL OR "timestamp" >= "raw_from")
+	UNION ALL
+	SELECT "market", "start", 0, ` + ohlcColumns + `, "count"
+	FROM "Rate_1m"
+	WHERE "market" = $2 AND "start" >= $3 AND "start" < $4
+	UNION ALL
+	SELECT "market", "start", 0, ` + ohlcColumns + `, "count"
+	FROM "Rate_1h", "bounds"
+	WHERE "market" = $2 AND "start" >= $3 AND "start" < $4
+		AND ("minute_from" IS NULL OR "start" < "minute_from")
+`
+
+// GetCandles aggregates saved rates of the market into candles of intervals with rates, in order of start time.
+// Periods whose raw rates were deleted by the retention are served from the rolled up candles,
+// periods kept only as hour candles are served at the hour resolution.
+func (r *RateRepository) GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error) {
+	query := `
+		SELECT
+			"market",
+			date_bin(make_interval(secs => $1), "part_start", TIMESTAMPTZ 'epoch') AS "start",
+			(array_agg("mid_open" ORDER BY "part_start", "id"))[1] AS "mid_open",
+			max("mid_high") AS "mid_high",
+			min("mid_low") AS "mid_low",
+			(array_agg("mid_close" ORDER BY "part_start" DESC, "id" DESC))[1] AS "mid_close",
+			(array_agg("ask_open" ORDER BY "part_start", "id"))[1] AS "ask_open",
+			max("ask_high") AS "ask_high",
+			min("ask_low") AS "ask_low",
+			(array_agg("ask_close" ORDER BY "part_start" DESC, "id" DESC))[1] AS "ask_close",
+			(array_agg("bid_open" ORDER BY "part_start", "id"))[1] AS "bid_open",
+			max("bid_high") AS "bid_high",
+			min("bid_low") AS "bid_low",
+			(array_agg("bid_close" ORDER BY "part_start" DESC, "id" DESC))[1] AS "bid_close",
+			sum("count")::BIGINT AS "count"
+		FROM (` + candleSourcesSQL + `) AS "parts"
+		GROUP BY "market", "start"
+		ORDER BY "start"
+	`
+
+	var rows []candleRow
+
+	err := r.reader().SelectContext(ctx, &rows, query, q.Interval.Seconds(), q.Market, q.Start().UTC(), q.To.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error while executing GetCandles sql request: %w", err)
+	}
+
+	candles := make([]domain.Candle, len(rows))
+	for i, row := range rows {
+		candles[i] = domain.Candle{
+			Start: row.Start,
+			Mid:   domain.OHLC{Open: row.MidOpen, High: row.MidHigh, Low: row.MidLow, Close: row.MidClose},
+			Ask:   domain.OHLC{Open: row.AskOpen, High: row.AskHigh, Low: row.AskLow, Close: row.AskClose},
+			Bid:   domain.OHLC{Open: row.BidOpen, High: row.BidHigh, Low: row.BidLow, Close: row.BidClose},
+			Count: row.Count,
+		}
 	}
 
-	return nil
+	return candles, nil
 }
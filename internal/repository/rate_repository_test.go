@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
 	"final/internal/domain"
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
 	"reflect"
 	"testing"
 	"time"
@@ -70,6 +73,7 @@ func TestRateRepository_SaveRate(t *testing.T) {
 		fields  fields
 		args    args
 		mock    func(mock sqlmock.Sqlmock)
+		want    bool
 		wantErr bool
 	}{
 		{
@@ -80,21 +84,60 @@ func TestRateRepository_SaveRate(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				rate: &domain.Rate{
-					Ask:       "100.5",
-					Bid:       "99.5",
-					Timestamp: time.Now(),
+					Market:     "usdtrub",
+					Provider:   "garantex",
+					Ask:        domain.MustParseDecimal("100.5"),
+					Bid:        domain.MustParseDecimal("99.5"),
+					AskVolume:  domain.MustParseDecimal("12.5"),
+					BidVolume:  domain.MustParseDecimal("3.0"),
+					Timestamp:  time.Now(),
+					ReceivedAt: time.Now(),
+					Latency:    250 * time.Millisecond,
 				},
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				query := `INSERT INTO "Rate" \("ask", "bid", "timestamp"\) VALUES \(\$1, \$2, \$3\)`
+				query := `INSERT INTO "Rate" \("market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\) ON CONFLICT \("market", "provider", "timestamp"\) DO NOTHING`
 				mock.ExpectExec(query).
 					WithArgs(
+						"usdtrub",
+						"garantex",
 						"100.5",
 						"99.5",
+						"12.5",
+						"3.0",
 						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						int64(250),
 					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "Duplicate rate is skipped",
+			fields: fields{
+				db: sqlxDB,
+			},
+			args: args{
+				ctx: context.Background(),
+				rate: &domain.Rate{
+					Market:     "usdtrub",
+					Provider:   "garantex",
+					Ask:        domain.MustParseDecimal("100.5"),
+					Bid:        domain.MustParseDecimal("99.5"),
+					AskVolume:  domain.MustParseDecimal("12.5"),
+					BidVolume:  domain.MustParseDecimal("3.0"),
+					Timestamp:  time.Now(),
+					ReceivedAt: time.Now(),
+					Latency:    250 * time.Millisecond,
+				},
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO "Rate" .+ ON CONFLICT`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want:    false,
 			wantErr: false,
 		},
 		{
@@ -105,18 +148,30 @@ func TestRateRepository_SaveRate(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				rate: &domain.Rate{
-					Ask:       "100.5",
-					Bid:       "99.5",
-					Timestamp: time.Now(),
+					Market:     "usdtrub",
+					Provider:   "garantex",
+					Ask:        domain.MustParseDecimal("100.5"),
+					Bid:        domain.MustParseDecimal("99.5"),
+					AskVolume:  domain.MustParseDecimal("12.5"),
+					BidVolume:  domain.MustParseDecimal("3.0"),
+					Timestamp:  time.Now(),
+					ReceivedAt: time.Now(),
+					Latency:    250 * time.Millisecond,
 				},
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				query := `INSERT INTO "Rate" \("ask", "bid", "timestamp"\) VALUES \(\$1, \$2, \$3\)`
+				query := `INSERT INTO "Rate" \("market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\)`
 				mock.ExpectExec(query).
 					WithArgs(
+						"usdtrub",
+						"garantex",
 						"100.5",
 						"99.5",
+						"12.5",
+						"3.0",
 						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						int64(250),
 					).
 					WillReturnError(fmt.Errorf("db error"))
 			},
@@ -130,9 +185,15 @@ func TestRateRepository_SaveRate(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				rate: &domain.Rate{
-					Ask:       "100.5",
-					Bid:       "99.5",
-					Timestamp: time.Now(),
+					Market:     "usdtrub",
+					Provider:   "garantex",
+					Ask:        domain.MustParseDecimal("100.5"),
+					Bid:        domain.MustParseDecimal("99.5"),
+					AskVolume:  domain.MustParseDecimal("12.5"),
+					BidVolume:  domain.MustParseDecimal("3.0"),
+					Timestamp:  time.Now(),
+					ReceivedAt: time.Now(),
+					Latency:    250 * time.Millisecond,
 				},
 			},
 			mock:    func(mock sqlmock.Sqlmock) {},
@@ -146,13 +207,19 @@ func TestRateRepository_SaveRate(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				rate: &domain.Rate{
-					Ask:       "100.5",
-					Bid:       "99.5",
-					Timestamp: time.Now(),
+					Market:     "usdtrub",
+					Provider:   "garantex",
+					Ask:        domain.MustParseDecimal("100.5"),
+					Bid:        domain.MustParseDecimal("99.5"),
+					AskVolume:  domain.MustParseDecimal("12.5"),
+					BidVolume:  domain.MustParseDecimal("3.0"),
+					Timestamp:  time.Now(),
+					ReceivedAt: time.Now(),
+					Latency:    250 * time.Millisecond,
 				},
 			},
 			mock: func(mock sqlmock.Sqlmock) {
-				query := `INSERT INTO "Rate" \("ask", "bid", "timestamp"\) VALUES \(\$1, \$2, \$3\)`
+				query := `INSERT INTO "Rate" \("market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\)`
 				mock.ExpectExec(query).
 					WillReturnError(fmt.Errorf("prepare error"))
 			},
@@ -168,9 +235,395 @@ func TestRateRepository_SaveRate(t *testing.T) {
 			r := &RateRepository{
 				db: tt.fields.db,
 			}
-			if err := r.SaveRate(tt.args.ctx, tt.args.rate); (err != nil) != tt.wantErr {
+			got, err := r.SaveRate(tt.args.ctx, tt.args.rate)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("SaveRate() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if got != tt.want {
+				t.Errorf("SaveRate() = %v, want %v", got, tt.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unmet expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRateRepository_SaveRates(t *testing.T) {
+	fixedTime := time.Unix(1700000000, 0).UTC()
+
+	rate := func(market string) *domain.Rate {
+		return &domain.Rate{
+			Market:     market,
+			Provider:   "garantex",
+			Ask:        domain.MustParseDecimal("100.5"),
+			Bid:        domain.MustParseDecimal("99.5"),
+			AskVolume:  domain.MustParseDecimal("12.5"),
+			BidVolume:  domain.MustParseDecimal("3.0"),
+			Timestamp:  fixedTime,
+			ReceivedAt: fixedTime,
+			Latency:    250 * time.Millisecond,
+		}
+	}
+
+	args := func(market string) []driver.Value {
+		return []driver.Value{market, "garantex", "100.5", "99.5", "12.5", "3.0", fixedTime, fixedTime, int64(250)}
+	}
+
+	tests := []struct {
+		name      string
+		rates     []*domain.Rate
+		mock      func(mock sqlmock.Sqlmock)
+		want      int
+		errorText string
+	}{
+		{
+			name:  "Rates are inserted with a single statement",
+			rates: []*domain.Rate{rate("usdtrub"), rate("btcusdt")},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO "Rate" \(.*\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\), \(\$10, \$11, \$12, \$13, \$14, \$15, \$16, \$17, \$18\) ON CONFLICT \("market", "provider", "timestamp"\) DO NOTHING$`).
+					WithArgs(append(args("usdtrub"), args("btcusdt")...)...).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			want: 2,
+		},
+		{
+			name:  "Duplicate rates are skipped",
+			rates: []*domain.Rate{rate("usdtrub"), rate("usdtrub")},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO "Rate" .+ ON CONFLICT`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: 1,
+		},
+		{
+			name:  "No rates",
+			mock:  func(mock sqlmock.Sqlmock) {},
+			rates: nil,
+		},
+		{
+			name:      "Too many rates",
+			rates:     make([]*domain.Rate, MaxSaveBatch+1),
+			mock:      func(mock sqlmock.Sqlmock) {},
+			errorText: fmt.Sprintf("too many rates to save at once: %d, max %d", MaxSaveBatch+1, MaxSaveBatch),
+		},
+		{
+			name:  "Database error",
+			rates: []*domain.Rate{rate("usdtrub")},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO "Rate"`).WillReturnError(errors.New("db error"))
+			},
+			errorText: "error while executing SaveRates sql request: db error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer mockDB.Close()
+
+			tt.mock(mock)
+
+			got, err := NewRateRepository(sqlx.NewDb(mockDB, "sqlmock")).SaveRates(context.Background(), tt.rates)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRateRepository_GetLatestRate(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	fixedTime := time.Unix(1700000000, 0).UTC()
+	columns := []string{"market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"}
+	query := `SELECT "market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms" FROM "Rate" WHERE "market" = \$1 ORDER BY "timestamp" DESC LIMIT 1`
+
+	tests := []struct {
+		name    string
+		market  string
+		mock    func(mock sqlmock.Sqlmock)
+		want    *domain.Rate
+		wantErr bool
+		errIs   error
+	}{
+		{
+			name:   "Latest rate is returned",
+			market: "usdtrub",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs("usdtrub").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow("usdtrub", "garantex", "100.5", "99.5", "12.5", "3.0", fixedTime, fixedTime.Add(time.Second), int64(250)))
+			},
+			want: &domain.Rate{
+				Market:     "usdtrub",
+				Provider:   "garantex",
+				Ask:        domain.MustParseDecimal("100.5"),
+				Bid:        domain.MustParseDecimal("99.5"),
+				AskVolume:  domain.MustParseDecimal("12.5"),
+				BidVolume:  domain.MustParseDecimal("3.0"),
+				Timestamp:  fixedTime,
+				ReceivedAt: fixedTime.Add(time.Second),
+				Latency:    250 * time.Millisecond,
+			},
+		},
+		{
+			name:   "No saved rate",
+			market: "btcrub",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs("btcrub").
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			wantErr: true,
+			errIs:   domain.ErrRateNotFound,
+		},
+		{
+			name:   "Error during query execution",
+			market: "usdtrub",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs("usdtrub").
+					WillReturnError(fmt.Errorf("db error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(mock)
+
+			r := NewRateRepository(sqlxDB)
+
+			got, err := r.GetLatestRate(context.Background(), tt.market)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetLatestRate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+				t.Errorf("GetLatestRate() error = %v, want %v", err, tt.errIs)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetLatestRate() = %v, want %v", got, tt.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unmet expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRateRepository_GetRateHistory(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	from := time.Unix(1700000000, 0).UTC()
+	to := from.Add(time.Hour)
+	query := `SELECT "id", "market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms" ` +
+		`FROM "Rate" WHERE "market" = \$1 AND \("timestamp", "id"\) > \(\$2, \$3\) AND "timestamp" >= \$4 AND "timestamp" < \$5 ORDER BY "timestamp", "id" LIMIT \$6`
+	columns := []string{"id", "market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"}
+
+	row := func(id int64, ts time.Time) []driver.Value {
+		return []driver.Value{id, "usdtrub", "garantex", "100.5", "99.5", "12.5", "3.0", ts, ts, int64(250)}
+	}
+	rate := func(ts time.Time) *domain.Rate {
+		return &domain.Rate{
+			Market:     "usdtrub",
+			Provider:   "garantex",
+			Ask:        domain.MustParseDecimal("100.5"),
+			Bid:        domain.MustParseDecimal("99.5"),
+			AskVolume:  domain.MustParseDecimal("12.5"),
+			BidVolume:  domain.MustParseDecimal("3.0"),
+			Timestamp:  ts,
+			ReceivedAt: ts,
+			Latency:    250 * time.Millisecond,
+		}
+	}
+
+	cursor := domain.HistoryCursor{Timestamp: from.Add(time.Minute), ID: 7}
+
+	tests := []struct {
+		name    string
+		q       domain.RateHistoryQuery
+		mock    func(mock sqlmock.Sqlmock)
+		want    *domain.RateHistoryPage
+		wantErr bool
+		errIs   error
+	}{
+		{
+			name: "First page has a next cursor",
+			q:    domain.RateHistoryQuery{Market: "usdtrub", From: from, To: to, Limit: 2},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs("usdtrub", from, int64(0), from, to, 3).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(row(3, from)...).
+						AddRow(row(7, from.Add(time.Minute))...).
+						AddRow(row(8, from.Add(time.Minute))...))
+			},
+			want: &domain.RateHistoryPage{
+				Rates:      []*domain.Rate{rate(from), rate(from.Add(time.Minute))},
+				NextCursor: cursor.String(),
+			},
+		},
+		{
+			name: "Last page starts after the cursor",
+			q:    domain.RateHistoryQuery{Market: "usdtrub", From: from, To: to, Limit: 2, Cursor: cursor.String()},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs("usdtrub", cursor.Timestamp, int64(7), from, to, 3).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(row(8, from.Add(time.Minute))...))
+			},
+			want: &domain.RateHistoryPage{
+				Rates: []*domain.Rate{rate(from.Add(time.Minute))},
+			},
+		},
+		{
+			name:    "Malformed cursor",
+			q:       domain.RateHistoryQuery{Market: "usdtrub", From: from, To: to, Limit: 2, Cursor: "!"},
+			mock:    func(mock sqlmock.Sqlmock) {},
+			wantErr: true,
+			errIs:   domain.ErrInvalidArgument,
+		},
+		{
+			name:    "Cursor out of the range",
+			q:       domain.RateHistoryQuery{Market: "usdtrub", From: from.Add(2 * time.Minute), To: to, Limit: 2, Cursor: cursor.String()},
+			mock:    func(mock sqlmock.Sqlmock) {},
+			wantErr: true,
+			errIs:   domain.ErrInvalidArgument,
+		},
+		{
+			name: "Error during query execution",
+			q:    domain.RateHistoryQuery{Market: "usdtrub", From: from, To: to, Limit: 2},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WillReturnError(fmt.Errorf("db error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(mock)
+
+			got, err := NewRateRepository(sqlxDB).GetRateHistory(context.Background(), tt.q)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetRateHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+				t.Errorf("GetRateHistory() error = %v, want %v", err, tt.errIs)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRateHistory() = %v, want %v", got, tt.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unmet expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRateRepository_GetCandles(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	// from is queried aligned down to the interval
+	from := time.Unix(1699999800, 0).UTC()
+	to := from.Add(time.Hour)
+	q := domain.CandleQuery{Market: "usdtrub", Interval: 5 * time.Minute, From: from.Add(200 * time.Second), To: to}
+
+	query := `date_bin\(make_interval\(secs => \$1\), "part_start", TIMESTAMPTZ 'epoch'\) .*` +
+		`FROM "Rate", "bounds" WHERE "market" = \$2 .* FROM "Rate_1m" .* FROM "Rate_1h", "bounds" .* GROUP BY "market", "start" ORDER BY "start"`
+	columns := []string{
+		"market", "start",
+		"mid_open", "mid_high", "mid_low", "mid_close",
+		"ask_open", "ask_high", "ask_low", "ask_close",
+		"bid_open", "bid_high", "bid_low", "bid_close",
+		"count",
+	}
+
+	tests := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		want    []domain.Candle
+		wantErr bool
+	}{
+		{
+			name: "Candles are aggregated",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs(float64(300), "usdtrub", from, to).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow("usdtrub", from, "100.00", "101.50", "99.00", "100.50", "100.5", "102", "99.5", "101", "99.5", "101", "98.5", "100", int64(4)))
+			},
+			want: []domain.Candle{
+				{
+					Start: from,
+					Mid:   domain.OHLC{Open: domain.MustParseDecimal("100.00"), High: domain.MustParseDecimal("101.50"), Low: domain.MustParseDecimal("99.00"), Close: domain.MustParseDecimal("100.50")},
+					Ask:   domain.OHLC{Open: domain.MustParseDecimal("100.5"), High: domain.MustParseDecimal("102"), Low: domain.MustParseDecimal("99.5"), Close: domain.MustParseDecimal("101")},
+					Bid:   domain.OHLC{Open: domain.MustParseDecimal("99.5"), High: domain.MustParseDecimal("101"), Low: domain.MustParseDecimal("98.5"), Close: domain.MustParseDecimal("100")},
+					Count: 4,
+				},
+			},
+		},
+		{
+			name: "No rates",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns))
+			},
+			want: []domain.Candle{},
+		},
+		{
+			name: "Error during query execution",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WillReturnError(fmt.Errorf("db error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(mock)
+
+			got, err := NewRateRepository(sqlxDB).GetCandles(context.Background(), q)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCandles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCandles() = %v, want %v", got, tt.want)
+			}
 
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("there were unmet expectations: %s", err)
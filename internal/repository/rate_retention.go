@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"final/internal/domain"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// retentionLockID is a key of the postgres advisory lock letting a single app instance apply the retention at a time.
+const retentionLockID = 7_236_418_502
+
+// ApplyRetention rolls raw rates before cutoffs.Raw up into minute and hour candles, then drops partitions of the raw
+// rates ending before cutoffs.Raw and deletes the rest of the raw rates and the candles before their cutoffs. Candles are only built for intervals kept by their cutoffs.
+// Cutoffs should be aligned to hours, so that every rolled up interval is complete.
+// Everything runs in a single transaction, which is skipped if another instance holds the retention lock.
+func (r *RateRepository) ApplyRetention(ctx context.Context, cutoffs domain.RetentionCutoffs) (*domain.RetentionResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin retention transaction: %w", err)
+	}
+	// rollback is a no-op after commit
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.GetContext(ctx, &locked, `SELECT pg_try_advisory_xact_lock($1)`, retentionLockID); err != nil {
+		return nil, fmt.Errorf("failed to acquire retention lock: %w", err)
+	}
+
+	if !locked {
+		return &domain.RetentionResult{Skipped: true}, nil
+	}
+
+	res := &domain.RetentionResult{}
+
+	rollups := []struct {
+		table    string
+		interval time.Duration
+		after    time.Time
+		count    *int64
+	}{
+		{table: "Rate_1m", interval: time.Minute, after: cutoffs.Minute, count: &res.RolledUpMinutes},
+		{table: "Rate_1h", interval: time.Hour, after: cutoffs.Hour, count: &res.RolledUpHours},
+	}
+
+	for _, rollup := range rollups {
+		// rates saved late for an already rolled up interval do not change its candle
+		query := `INSERT INTO "` + rollup.table + `" (` + candleColumns + `)` +
+			candlesSQL(`"timestamp" >= $2 AND "timestamp" < $3`) +
+			`ON CONFLICT ("market", "start") DO NOTHING`
+
+		if *rollup.count, err = execCount(ctx, tx, query, rollup.interval.Seconds(), rollup.after.UTC(), cutoffs.Raw.UTC()); err != nil {
+			return nil, fmt.Errorf("failed to roll up rates into %s: %w", rollup.table, err)
+		}
+	}
+
+	partitions, err := listRatePartitions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range partitions {
+		if p.end.After(cutoffs.Raw) {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `DROP TABLE "`+p.name+`"`); err != nil {
+			return nil, fmt.Errorf("failed to drop partition %s: %w", p.name, err)
+		}
+		res.DroppedPartitions++
+	}
+
+	deletes := []struct {
+		query  string
+		before time.Time
+		count  *int64
+	}{
+		{query: `DELETE FROM "Rate" WHERE "timestamp" < $1`, before: cutoffs.Raw, count: &res.DeletedRaw},
+		{query: `DELETE FROM "Rate_1m" WHERE "start" < $1`, before: cutoffs.Minute, count: &res.DeletedMinutes},
+		{query: `DELETE FROM "Rate_1h" WHERE "start" < $1`, before: cutoffs.Hour, count: &res.DeletedHours},
+	}
+
+	for _, d := range deletes {
+		if *d.count, err = execCount(ctx, tx, d.query, d.before.UTC()); err != nil {
+			return nil, fmt.Errorf("failed to delete expired rates: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit retention transaction: %w", err)
+	}
+
+	return res, nil
+}
+
+// execCount executes the query and returns the number of affected rows.
+func execCount(ctx context.Context, tx *sqlx.Tx, query string, args ...any) (int64, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateRepository_ApplyRetention(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC().Truncate(time.Hour)
+	cutoffs := domain.RetentionCutoffs{
+		Raw:    now.Add(-7 * 24 * time.Hour),
+		Minute: now.Add(-30 * 24 * time.Hour),
+		Hour:   now.Add(-365 * 24 * time.Hour),
+	}
+
+	lock := `SELECT pg_try_advisory_xact_lock\(\$1\)`
+
+	expectRollups := func(mock sqlmock.Sqlmock) {
+		mock.ExpectExec(`INSERT INTO "Rate_1m" .* ON CONFLICT \("market", "start"\) DO NOTHING`).
+			WithArgs(float64(60), cutoffs.Minute, cutoffs.Raw).
+			WillReturnResult(sqlmock.NewResult(0, 120))
+		mock.ExpectExec(`INSERT INTO "Rate_1h" .* ON CONFLICT \("market", "start"\) DO NOTHING`).
+			WithArgs(float64(3600), cutoffs.Hour, cutoffs.Raw).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+
+	expectPartitions := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT c."relname" FROM "pg_inherits"`).
+			WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+				// an expired month partition and the last expired day partition
+				AddRow("Rate_p202310").
+				AddRow("Rate_p20231106").
+				// partially expired and kept day partitions
+				AddRow("Rate_p20231107").
+				AddRow("Rate_p20231114"))
+		mock.ExpectExec(`DROP TABLE "Rate_p202310"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DROP TABLE "Rate_p20231106"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	tests := []struct {
+		name      string
+		mock      func(mock sqlmock.Sqlmock)
+		want      *domain.RetentionResult
+		errorText string
+	}{
+		{
+			name: "Rates are rolled up and deleted",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(retentionLockID).
+					WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(true))
+				expectRollups(mock)
+				expectPartitions(mock)
+				mock.ExpectExec(`DELETE FROM "Rate" WHERE "timestamp" < \$1`).
+					WithArgs(cutoffs.Raw).
+					WillReturnResult(sqlmock.NewResult(0, 7200))
+				mock.ExpectExec(`DELETE FROM "Rate_1m" WHERE "start" < \$1`).
+					WithArgs(cutoffs.Minute).
+					WillReturnResult(sqlmock.NewResult(0, 60))
+				mock.ExpectExec(`DELETE FROM "Rate_1h" WHERE "start" < \$1`).
+					WithArgs(cutoffs.Hour).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: &domain.RetentionResult{
+				RolledUpMinutes:   120,
+				RolledUpHours:     2,
+				DroppedPartitions: 2,
+				DeletedRaw:        7200,
+				DeletedMinutes:    60,
+				DeletedHours:      1,
+			},
+		},
+		{
+			name: "Another instance holds the lock",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(retentionLockID).
+					WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			want: &domain.RetentionResult{Skipped: true},
+		},
+		{
+			name: "Failed delete rolls back the rollups",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(retentionLockID).
+					WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(true))
+				expectRollups(mock)
+				expectPartitions(mock)
+				mock.ExpectExec(`DELETE FROM "Rate" WHERE "timestamp" < \$1`).
+					WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			errorText: "failed to delete expired rates: db error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer mockDB.Close()
+
+			tt.mock(mock)
+
+			got, err := NewRateRepository(sqlx.NewDb(mockDB, "sqlmock")).ApplyRetention(context.Background(), cutoffs)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
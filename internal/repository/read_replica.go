@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// replicationLagSQL returns the replication lag of a replica in seconds, zero if it has replayed everything it received
+// or is not a replica. It is NULL while a replica has not replayed any transaction yet.
+// A replica that lost its connection to the primary has replayed everything it received, so it also returns
+// whether the replica is streaming from the primary. Columns of pg_stat_wal_receiver but pid are NULL
+// for roles without pg_read_all_stats, a running receiver is taken as streaming for them.
+const replicationLagSQL = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+	END AS "lag",
+	NOT pg_is_in_recovery() OR EXISTS (
+		SELECT 1 FROM pg_stat_wal_receiver WHERE "pid" IS NOT NULL AND coalesce("status", 'streaming') = 'streaming'
+	) AS "streaming"
+`
+
+type replicationLag struct {
+	Seconds   sql.NullFloat64 `db:"lag"`
+	Streaming bool            `db:"streaming"`
+}
+
+// ReadReplica is a read replica of the primary database, RateRepository reads from it
+// while its replication lag checked by CheckLag is within maxLag and from the primary otherwise.
+type ReadReplica struct {
+	db     *sqlx.DB
+	maxLag time.Duration
+	// healthy is set by CheckLag, reads go to the primary until the first check
+	healthy atomic.Bool
+}
+
+func NewReadReplica(db *sqlx.DB, maxLag time.Duration) *ReadReplica {
+	return &ReadReplica{db: db, maxLag: maxLag}
+}
+
+// CheckLag queries the replication lag of the replica and routes reads to it if the lag is within maxLag.
+// Returns whether reads go to the replica, they go to the primary if the lag can not be queried
+// or the replica is not streaming from the primary.
+func (r *ReadReplica) CheckLag(ctx context.Context) (time.Duration, bool, error) {
+	var row replicationLag
+
+	if err := r.db.GetContext(ctx, &row, replicationLagSQL); err != nil {
+		r.healthy.Store(false)
+		return 0, false, fmt.Errorf("error while executing replication lag sql request: %w", err)
+	}
+
+	if !row.Seconds.Valid {
+		r.healthy.Store(false)
+		return 0, false, fmt.Errorf("replication lag is unknown, replica has not replayed any transaction yet")
+	}
+
+	if !row.Streaming {
+		r.healthy.Store(false)
+		return 0, false, fmt.Errorf("replication lag is unknown, replica is not streaming from the primary")
+	}
+
+	lag := time.Duration(row.Seconds.Float64 * float64(time.Second))
+	healthy := lag <= r.maxLag
+
+	r.healthy.Store(healthy)
+
+	return lag, healthy, nil
+}
+
+// Close closes the replica pool.
+func (r *ReadReplica) Close() error {
+	return r.db.Close()
+}
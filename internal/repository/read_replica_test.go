@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadReplica_CheckLag(t *testing.T) {
+	tests := []struct {
+		name        string
+		mock        func(mock sqlmock.Sqlmock)
+		wantLag     time.Duration
+		wantHealthy bool
+		wantErr     bool
+	}{
+		{
+			name: "Lag within the limit",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag", "streaming"}).AddRow(1.5, true))
+			},
+			wantLag:     1500 * time.Millisecond,
+			wantHealthy: true,
+		},
+		{
+			name: "Lag exceeds the limit",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag", "streaming"}).AddRow(7.0, true))
+			},
+			wantLag: 7 * time.Second,
+		},
+		{
+			name: "Unknown lag",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag", "streaming"}).AddRow(nil, true))
+			},
+			wantErr: true,
+		},
+		{
+			name: "Replica not streaming from the primary",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag", "streaming"}).AddRow(0.0, false))
+			},
+			wantErr: true,
+		},
+		{
+			name: "Replica is unavailable",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT CASE`).WillReturnError(errors.New("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer mockDB.Close()
+
+			r := NewReadReplica(sqlx.NewDb(mockDB, "sqlmock"), 5*time.Second)
+			// the previous check routed reads to the replica
+			r.healthy.Store(true)
+
+			tt.mock(mock)
+
+			lag, healthy, err := r.CheckLag(context.Background())
+
+			assert.Equal(t, tt.wantErr, err != nil, "CheckLag() error = %v", err)
+			assert.Equal(t, tt.wantLag, lag)
+			assert.Equal(t, tt.wantHealthy, healthy)
+			assert.Equal(t, tt.wantHealthy, r.healthy.Load())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// replicaSchemaSQL shadows the replication functions and pg_stat_wal_receiver of pg_catalog with those of a replica
+// streaming from the primary, the receiver hides its columns but pid from roles without pg_read_all_stats as postgres does.
+const replicaSchemaSQL = `
+	DROP SCHEMA IF EXISTS "replica_test" CASCADE;
+	CREATE SCHEMA "replica_test";
+
+	CREATE FUNCTION "replica_test".pg_is_in_recovery() RETURNS boolean LANGUAGE sql AS 'SELECT true';
+	CREATE FUNCTION "replica_test".pg_last_wal_receive_lsn() RETURNS pg_lsn LANGUAGE sql AS 'SELECT ''0/2''::pg_lsn';
+	CREATE FUNCTION "replica_test".pg_last_wal_replay_lsn() RETURNS pg_lsn LANGUAGE sql AS 'SELECT ''0/1''::pg_lsn';
+	CREATE FUNCTION "replica_test".pg_last_xact_replay_timestamp() RETURNS timestamptz LANGUAGE sql
+		AS 'SELECT now() - interval ''1 second''';
+
+	CREATE VIEW "replica_test".pg_stat_wal_receiver AS
+		SELECT 42 AS "pid", CASE WHEN pg_has_role('pg_read_all_stats', 'MEMBER') THEN 'streaming' END AS "status";
+
+	DO $$ BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'replica_test_reader') THEN
+			CREATE ROLE "replica_test_reader" NOLOGIN;
+		END IF;
+	END $$;
+	GRANT USAGE ON SCHEMA "replica_test" TO "replica_test_reader";
+	GRANT SELECT ON "replica_test".pg_stat_wal_receiver TO "replica_test_reader";
+`
+
+func TestReadReplica_CheckLagUnprivilegedRole(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	ctx := context.Background()
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to postgres: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, replicaSchemaSQL); err != nil {
+		t.Fatalf("failed to create replica schema: %v", err)
+	}
+
+	// the session of the single connection reads as the unprivileged role
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, `SET ROLE "replica_test_reader"; SET search_path = "replica_test", pg_catalog`); err != nil {
+		t.Fatalf("failed to set unprivileged role: %v", err)
+	}
+	defer db.ExecContext(ctx, `RESET ROLE; RESET search_path; DROP SCHEMA "replica_test" CASCADE; DROP ROLE "replica_test_reader"`)
+
+	var status sql.NullString
+	assert.NoError(t, db.GetContext(ctx, &status, `SELECT "status" FROM pg_stat_wal_receiver`))
+	assert.False(t, status.Valid, "receiver status is visible to the unprivileged role")
+
+	lag, healthy, err := NewReadReplica(db, 5*time.Second).CheckLag(ctx)
+
+	assert.NoError(t, err)
+	assert.True(t, healthy)
+	assert.InDelta(t, float64(time.Second), float64(lag), float64(time.Second))
+}
+
+func TestRateRepository_ReadsFromReplica(t *testing.T) {
+	primaryDB, primary, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer primaryDB.Close()
+
+	replicaDB, replica, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer replicaDB.Close()
+
+	rr := NewReadReplica(sqlx.NewDb(replicaDB, "sqlmock"), 5*time.Second)
+	repo := NewReplicatedRateRepository(sqlx.NewDb(primaryDB, "sqlmock"), rr)
+
+	columns := []string{"market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"}
+	fixedTime := time.Unix(1700000000, 0).UTC()
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow("usdtrub", "garantex", "100.5", "99.5", "12.5", "3.0", fixedTime, fixedTime, int64(250))
+	}
+
+	// reads go to the primary until the first check
+	primary.ExpectQuery(`SELECT .+ FROM "Rate"`).WithArgs("usdtrub").WillReturnRows(row())
+	_, err = repo.GetLatestRate(context.Background(), "usdtrub")
+	assert.NoError(t, err)
+
+	replica.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag", "streaming"}).AddRow(0.0, true))
+	replica.ExpectQuery(`SELECT .+ FROM "Rate"`).WithArgs("usdtrub").WillReturnRows(row())
+
+	_, _, err = rr.CheckLag(context.Background())
+	assert.NoError(t, err)
+	_, err = repo.GetLatestRate(context.Background(), "usdtrub")
+	assert.NoError(t, err)
+
+	// reads fall back to the primary while the replica lags
+	replica.ExpectQuery(`SELECT CASE`).WillReturnRows(sqlmock.NewRows([]string{"lag", "streaming"}).AddRow(30.0, true))
+	primary.ExpectQuery(`SELECT .+ FROM "Rate"`).WithArgs("usdtrub").WillReturnRows(row())
+
+	_, _, err = rr.CheckLag(context.Background())
+	assert.NoError(t, err)
+	_, err = repo.GetLatestRate(context.Background(), "usdtrub")
+	assert.NoError(t, err)
+
+	// writes always go to the primary
+	primary.ExpectExec(`INSERT INTO "Rate"`).WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err = repo.SaveRate(context.Background(), contractRate("usdtrub", fixedTime, "100.5", "99.5"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replica.ExpectationsWereMet())
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteDriver is the name of the embedded sqlite driver.
+const SQLiteDriver = "sqlite"
+
+// sqliteSchema is the schema of the sqlite backend, decimals are stored as exact text
+// and times as unix nanoseconds in UTC.
+const sqliteSchema = `
+	CREATE TABLE IF NOT EXISTS "Rate" (
+		"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+		"market" TEXT NOT NULL,
+		"provider" TEXT NOT NULL,
+		"ask" TEXT NOT NULL,
+		"bid" TEXT NOT NULL,
+		"ask_volume" TEXT NOT NULL,
+		"bid_volume" TEXT NOT NULL,
+		"timestamp" INTEGER NOT NULL,
+		"received_at" INTEGER NOT NULL,
+		"latency_ms" INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS "Rate_market_timestamp_id_idx" ON "Rate" ("market", "timestamp", "id");
+
+	CREATE UNIQUE INDEX IF NOT EXISTS "Rate_market_provider_timestamp_key" ON "Rate" ("market", "provider", "timestamp");
+`
+
+// OpenSQLite opens the sqlite database file at path creating it and its schema if needed.
+func OpenSQLite(ctx context.Context, path string) (*sqlx.DB, error) {
+	db, err := sqlx.Open(SQLiteDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	// sqlite has a single writer, a single connection avoids busy errors
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(ctx, `PRAGMA journal_mode = WAL`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to enable sqlite write-ahead log: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+
+	return db, nil
+}
+
+func NewSQLiteRepository(db *sqlx.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+// SQLiteRepository stores rates in an embedded sqlite database, see OpenSQLite.
+type SQLiteRepository struct {
+	db *sqlx.DB
+}
+
+type sqliteRow struct {
+	ID         int64          `db:"id"`
+	Market     string         `db:"market"`
+	Provider   string         `db:"provider"`
+	Ask        domain.Decimal `db:"ask"`
+	Bid        domain.Decimal `db:"bid"`
+	AskVolume  domain.Decimal `db:"ask_volume"`
+	BidVolume  domain.Decimal `db:"bid_volume"`
+	Timestamp  int64          `db:"timestamp"`
+	ReceivedAt int64          `db:"received_at"`
+	LatencyMs  int64          `db:"latency_ms"`
+}
+
+func (row *sqliteRow) toRate() *domain.Rate {
+	return &domain.Rate{
+		Market:     row.Market,
+		Provider:   row.Provider,
+		Ask:        row.Ask,
+		Bid:        row.Bid,
+		AskVolume:  row.AskVolume,
+		BidVolume:  row.BidVolume,
+		Timestamp:  time.Unix(0, row.Timestamp).UTC(),
+		ReceivedAt: time.Unix(0, row.ReceivedAt).UTC(),
+		Latency:    time.Duration(row.LatencyMs) * time.Millisecond,
+	}
+}
+
+const sqliteColumns = `"id", "market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms"`
+
+func (r *SQLiteRepository) SaveRate(ctx context.Context, rate *domain.Rate) (bool, error) {
+	inserted, err := r.SaveRates(ctx, []*domain.Rate{rate})
+	return inserted > 0, err
+}
+
+// SaveRates inserts the rates in a single transaction, so either all of them are saved or none.
+// Rates with the market, provider and timestamp of a saved rate are skipped. Returns the number of new rates.
+func (r *SQLiteRepository) SaveRates(ctx context.Context, rates []*domain.Rate) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin sqlite transaction: %w", err)
+	}
+	// rollback is a no-op after commit
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO "Rate" ("market", "provider", "ask", "bid", "ask_volume", "bid_volume", "timestamp", "received_at", "latency_ms")
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT ("market", "provider", "timestamp") DO NOTHING
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("error while preparing SaveRates sqlite request: %w", err)
+	}
+	defer stmt.Close()
+
+	inserted := 0
+
+	for _, rate := range rates {
+		res, err := stmt.ExecContext(ctx,
+			rate.Market,
+			rate.Provider,
+			rate.Ask,
+			rate.Bid,
+			rate.AskVolume,
+			rate.BidVolume,
+			rate.Timestamp.UnixNano(),
+			rate.ReceivedAt.UnixNano(),
+			rate.Latency.Milliseconds(),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("error while executing SaveRates sqlite request: %w", err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get number of saved rates: %w", err)
+		}
+
+		inserted += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit sqlite transaction: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// GetLatestRate returns the most recent saved rate of the market.
+// Returns domain.ErrRateNotFound if no rate of the market has been saved.
+func (r *SQLiteRepository) GetLatestRate(ctx context.Context, market string) (*domain.Rate, error) {
+	query := `SELECT ` + sqliteColumns + ` FROM "Rate" WHERE "market" = ? ORDER BY "timestamp" DESC, "id" DESC LIMIT 1`
+
+	var row sqliteRow
+
+	err := r.db.GetContext(ctx, &row, query, market)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %q", domain.ErrRateNotFound, market)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error while executing GetLatestRate sqlite request: %w", err)
+	}
+
+	return row.toRate(), nil
+}
+
+// Bounds of times representable as unix nanoseconds, years 1677 to 2262.
+var (
+	sqliteMinTime = time.Unix(0, math.MinInt64)
+	sqliteMaxTime = time.Unix(0, math.MaxInt64)
+)
+
+// sqliteTime returns the time as stored by the sqlite backend, a query bound beyond the representable range is clamped to it,
+// as UnixNano of such times overflows.
+func sqliteTime(t time.Time) int64 {
+	switch {
+	case t.Before(sqliteMinTime):
+		return math.MinInt64
+	case t.After(sqliteMaxTime):
+		return math.MaxInt64
+	default:
+		return t.UnixNano()
+	}
+}
+
+// GetRateHistory returns a page of saved rates of the market in order of timestamp and id.
+// Returns domain.ErrInvalidArgument if the cursor is malformed or out of the requested range.
+func (r *SQLiteRepository) GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error) {
+	after, err := q.After()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + sqliteColumns + `
+		FROM "Rate"
+		WHERE "market" = ? AND ("timestamp", "id") > (?, ?) AND "timestamp" >= ? AND "timestamp" < ?
+		ORDER BY "timestamp", "id"
+		LIMIT ?
+	`
+
+	var rows []sqliteRow
+
+	// one more row tells whether there is a next page
+	err = r.db.SelectContext(ctx, &rows, query, q.Market, sqliteTime(after.Timestamp), after.ID, sqliteTime(q.From), sqliteTime(q.To), q.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error while executing GetRateHistory sqlite request: %w", err)
+	}
+
+	page := &domain.RateHistoryPage{}
+
+	if len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = domain.HistoryCursor{Timestamp: time.Unix(0, last.Timestamp).UTC(), ID: last.ID}.String()
+	}
+
+	page.Rates = make([]*domain.Rate, len(rows))
+	for i := range rows {
+		page.Rates[i] = rows[i].toRate()
+	}
+
+	return page, nil
+}
+
+// GetCandles aggregates saved rates of the market into candles of intervals with rates, in order of start time.
+// Rates are aggregated by the app, as sqlite can not compare decimals stored as text.
+func (r *SQLiteRepository) GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error) {
+	query := `
+		SELECT ` + sqliteColumns + `
+		FROM "Rate"
+		WHERE "market" = ? AND "timestamp" >= ? AND "timestamp" < ?
+		ORDER BY "timestamp", "id"
+	`
+
+	var rows []sqliteRow
+
+	err := r.db.SelectContext(ctx, &rows, query, q.Market, sqliteTime(q.Start()), sqliteTime(q.To))
+	if err != nil {
+		return nil, fmt.Errorf("error while executing GetCandles sqlite request: %w", err)
+	}
+
+	rates := make([]*domain.Rate, len(rows))
+	for i := range rows {
+		rates[i] = rows[i].toRate()
+	}
+
+	return aggregateCandles(rates, q.Interval), nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"final/internal/domain"
+	"io"
+	"net"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Storage backends.
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendMemory   = "memory"
+)
+
+// Storage saves rates and reads them back, it is implemented by every storage backend.
+// A rate is identified by its market, provider and timestamp, saving it again saves nothing.
+// Retention, rollups and partitions are maintained by the postgres backend only.
+type Storage interface {
+	// SaveRate returns whether the rate was new.
+	SaveRate(ctx context.Context, rate *domain.Rate) (bool, error)
+	// SaveRates saves the rates at once and returns the number of new ones.
+	SaveRates(ctx context.Context, rates []*domain.Rate) (int, error)
+	// GetLatestRate returns domain.ErrRateNotFound if no rate of the market has been saved.
+	GetLatestRate(ctx context.Context, market string) (*domain.Rate, error)
+	// GetRateHistory returns a page of saved rates in order of timestamp and storage id.
+	// Returns domain.ErrInvalidArgument if the query cursor is malformed or out of the query range.
+	GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error)
+	// GetCandles returns candles of intervals with saved rates in order of start time.
+	GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error)
+}
+
+var (
+	_ Storage = (*RateRepository)(nil)
+	_ Storage = (*SQLiteRepository)(nil)
+	_ Storage = (*MemoryRepository)(nil)
+)
+
+// IsTransient reports whether the error is caused by an unavailable database rather than by the request,
+// so that the same request may succeed later.
+func IsTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		// connection exception, transaction rollback, insufficient resources, operator intervention, system error
+		case "08", "40", "53", "57", "58":
+			return true
+		}
+		return false
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// extended result codes keep the primary code in the low byte
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED, sqlite3.SQLITE_IOERR, sqlite3.SQLITE_FULL:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"final/migrations"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// storageBackends opens every storage backend for the contract tests.
+// The postgres backend runs only if TEST_POSTGRES_DSN is set, its "Rate" table is truncated.
+var storageBackends = map[string]func(t *testing.T) Storage{
+	BackendMemory: func(t *testing.T) Storage {
+		return NewMemoryRepository()
+	},
+	BackendSQLite: func(t *testing.T) Storage {
+		db, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "rates.db"))
+		if err != nil {
+			t.Fatalf("failed to open sqlite database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		return NewSQLiteRepository(db)
+	},
+	BackendPostgres: func(t *testing.T) Storage {
+		dsn := os.Getenv("TEST_POSTGRES_DSN")
+		if dsn == "" {
+			t.Skip("TEST_POSTGRES_DSN is not set")
+		}
+
+		ctx := context.Background()
+
+		db, err := sqlx.Connect("postgres", dsn)
+		if err != nil {
+			t.Fatalf("failed to connect to postgres: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		ms, err := LoadMigrations(migrations.FS)
+		if err != nil {
+			t.Fatalf("failed to load migrations: %v", err)
+		}
+
+		if _, err := NewMigrator(db, ms).Up(ctx); err != nil {
+			t.Fatalf("failed to apply migrations: %v", err)
+		}
+
+		if _, err := db.ExecContext(ctx, `TRUNCATE "Rate"`); err != nil {
+			t.Fatalf("failed to truncate rates: %v", err)
+		}
+
+		repo := NewRateRepository(db)
+
+		// rates of the tests are saved around now
+		now := time.Now()
+		if _, err := repo.CreatePartitions(ctx, domain.PartitionDay, now.Add(-48*time.Hour), now.Add(48*time.Hour)); err != nil {
+			t.Fatalf("failed to create partitions: %v", err)
+		}
+
+		return repo
+	},
+}
+
+// forEachBackend runs the test against every storage backend.
+func forEachBackend(t *testing.T, test func(t *testing.T, s Storage)) {
+	for name, open := range storageBackends {
+		t.Run(name, func(t *testing.T) {
+			test(t, open(t))
+		})
+	}
+}
+
+// contractRate returns a rate of the market quoted at ts, postgres keeps timestamps with microsecond precision.
+func contractRate(market string, ts time.Time, ask, bid string) *domain.Rate {
+	return &domain.Rate{
+		Market:     market,
+		Provider:   "garantex",
+		Ask:        domain.MustParseDecimal(ask),
+		Bid:        domain.MustParseDecimal(bid),
+		AskVolume:  domain.MustParseDecimal("10"),
+		BidVolume:  domain.MustParseDecimal("20"),
+		Timestamp:  ts,
+		ReceivedAt: ts.Add(time.Second),
+		Latency:    15 * time.Millisecond,
+	}
+}
+
+// saveContractRates saves new rates.
+func saveContractRates(t *testing.T, s Storage, rates []*domain.Rate) {
+	t.Helper()
+
+	inserted, err := s.SaveRates(context.Background(), rates)
+
+	assert.NoError(t, err)
+	assert.Equal(t, len(rates), inserted)
+}
+
+// assertRate compares the stored fields of rates, decimals are compared by value as backends keep different scales.
+func assertRate(t *testing.T, want, got *domain.Rate) {
+	t.Helper()
+
+	if !assert.NotNil(t, got) {
+		return
+	}
+
+	assert.Equal(t, want.Market, got.Market)
+	assert.Equal(t, want.Provider, got.Provider)
+	assert.Zero(t, want.Ask.Cmp(got.Ask), "ask %s, got %s", want.Ask, got.Ask)
+	assert.Zero(t, want.Bid.Cmp(got.Bid), "bid %s, got %s", want.Bid, got.Bid)
+	assert.Zero(t, want.AskVolume.Cmp(got.AskVolume), "ask volume %s, got %s", want.AskVolume, got.AskVolume)
+	assert.Zero(t, want.BidVolume.Cmp(got.BidVolume), "bid volume %s, got %s", want.BidVolume, got.BidVolume)
+	assert.True(t, want.Timestamp.Equal(got.Timestamp), "timestamp %s, got %s", want.Timestamp, got.Timestamp)
+	assert.Equal(t, want.Latency, got.Latency)
+}
+
+func assertOHLC(t *testing.T, want, got domain.OHLC) {
+	t.Helper()
+
+	for _, p := range []struct {
+		name      string
+		want, got domain.Decimal
+	}{
+		{"open", want.Open, got.Open},
+		{"high", want.High, got.High},
+		{"low", want.Low, got.Low},
+		{"close", want.Close, got.Close},
+	} {
+		assert.Zero(t, p.want.Cmp(p.got), "%s %s, got %s", p.name, p.want, p.got)
+	}
+}
+
+// contractStart is an hour start of the test rates, whole hours keep candles of the tests aligned.
+func contractStart() time.Time {
+	return time.Now().UTC().Truncate(time.Hour).Add(-time.Hour)
+}
+
+func TestStorage_SaveAndGetLatestRate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		start := contractStart()
+
+		latest := contractRate("usdtrub", start.Add(2*time.Minute), "95.5", "95.1")
+
+		saveContractRates(t, s, []*domain.Rate{
+			contractRate("usdtrub", start, "95.2", "95.0"),
+			latest,
+			contractRate("btcrub", start.Add(3*time.Minute), "6000000", "5990000"),
+		})
+		// a rate saved later with an earlier timestamp is not the latest one
+		saveContractRates(t, s, []*domain.Rate{contractRate("usdtrub", start.Add(time.Minute), "95.3", "95.1")})
+
+		got, err := s.GetLatestRate(ctx, "usdtrub")
+
+		assert.NoError(t, err)
+		assertRate(t, latest, got)
+	})
+}
+
+func TestStorage_SaveDuplicateRates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		start := contractStart()
+
+		first := contractRate("usdtrub", start, "95.2", "95.0")
+		saveContractRates(t, s, []*domain.Rate{first})
+
+		// the same snapshot polled again
+		saved, err := s.SaveRate(ctx, contractRate("usdtrub", start, "95.3", "95.1"))
+		assert.NoError(t, err)
+		assert.False(t, saved)
+
+		other := contractRate("usdtrub", start, "95.4", "95.2")
+		other.Provider = "binance"
+
+		inserted, err := s.SaveRates(ctx, []*domain.Rate{
+			contractRate("usdtrub", start, "95.3", "95.1"),
+			// a rate of another provider at the same time is new
+			other,
+			contractRate("usdtrub", start.Add(time.Minute), "95.5", "95.3"),
+			contractRate("usdtrub", start.Add(time.Minute), "95.6", "95.4"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, inserted)
+
+		page, err := s.GetRateHistory(ctx, domain.RateHistoryQuery{Market: "usdtrub", From: start, To: start.Add(time.Hour), Limit: 10})
+		if !assert.NoError(t, err) || !assert.Len(t, page.Rates, 3) {
+			return
+		}
+
+		assertRate(t, first, page.Rates[0])
+		assertRate(t, other, page.Rates[1])
+		assertRate(t, contractRate("usdtrub", start.Add(time.Minute), "95.5", "95.3"), page.Rates[2])
+	})
+}
+
+func TestStorage_SaveSubSecondRates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		start := contractStart()
+
+		// quotes within the same second are distinct rates
+		rates := []*domain.Rate{
+			contractRate("usdtrub", start.Add(250*time.Millisecond), "95.2", "95.0"),
+			contractRate("usdtrub", start.Add(250*time.Millisecond+time.Microsecond), "95.3", "95.1"),
+			contractRate("usdtrub", start.Add(750*time.Millisecond), "95.4", "95.2"),
+		}
+		saveContractRates(t, s, rates)
+
+		page, err := s.GetRateHistory(ctx, domain.RateHistoryQuery{Market: "usdtrub", From: start, To: start.Add(time.Second), Limit: 10})
+		if !assert.NoError(t, err) || !assert.Len(t, page.Rates, len(rates)) {
+			return
+		}
+
+		for i, rate := range rates {
+			assertRate(t, rate, page.Rates[i])
+		}
+	})
+}
+
+func TestStorage_GetLatestRateNotFound(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+
+		saveContractRates(t, s, []*domain.Rate{contractRate("btcrub", contractStart(), "6000000", "5990000")})
+
+		_, err := s.GetLatestRate(ctx, "usdtrub")
+
+		assert.True(t, errors.Is(err, domain.ErrRateNotFound), "got %v", err)
+	})
+}
+
+func TestStorage_GetRateHistory(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		start := contractStart()
+
+		// a rate of another provider at the same timestamp, ordered by save order
+		sameTime := contractRate("usdtrub", start.Add(time.Minute), "95.3", "95.0")
+		sameTime.Provider = "binance"
+
+		rates := []*domain.Rate{
+			contractRate("usdtrub", start, "95.1", "95.0"),
+			contractRate("usdtrub", start.Add(time.Minute), "95.2", "95.0"),
+			sameTime,
+			contractRate("usdtrub", start.Add(2*time.Minute), "95.4", "95.0"),
+			contractRate("usdtrub", start.Add(3*time.Minute), "95.5", "95.0"),
+		}
+
+		saveContractRates(t, s, rates)
+		saveContractRates(t, s, []*domain.Rate{contractRate("btcrub", start.Add(time.Minute), "6000000", "5990000")})
+
+		q := domain.RateHistoryQuery{
+			Market: "usdtrub",
+			From:   start,
+			// the last rate is out of the range
+			To:    start.Add(3 * time.Minute),
+			Limit: 2,
+		}
+
+		var got []*domain.Rate
+		pages := 0
+
+		for {
+			page, err := s.GetRateHistory(ctx, q)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			pages++
+			got = append(got, page.Rates...)
+
+			if page.NextCursor == "" {
+				break
+			}
+
+			if pages > len(rates) {
+				t.Fatalf("history does not end")
+			}
+
+			q.Cursor = page.NextCursor
+		}
+
+		assert.Equal(t, 2, pages)
+
+		if assert.Len(t, got, 4) {
+			for i := range got {
+				assertRate(t, rates[i], got[i])
+			}
+		}
+	})
+}
+
+func TestStorage_GetRateHistoryMalformedCursor(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		start := contractStart()
+
+		_, err := s.GetRateHistory(context.Background(), domain.RateHistoryQuery{
+			Market: "usdtrub",
+			From:   start,
+			To:     start.Add(time.Hour),
+			Limit:  10,
+			Cursor: "not a cursor",
+		})
+
+		assert.True(t, errors.Is(err, domain.ErrInvalidArgument), "got %v", err)
+	})
+}
+
+func TestStorage_GetRateHistoryCursorOutOfRange(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		start := contractStart()
+
+		saveContractRates(t, s, []*domain.Rate{
+			contractRate("usdtrub", start, "95.1", "95.0"),
+			contractRate("usdtrub", start.Add(time.Minute), "95.2", "95.0"),
+		})
+
+		page, err := s.GetRateHistory(ctx, domain.RateHistoryQuery{Market: "usdtrub", From: start, To: start.Add(time.Hour), Limit: 1})
+		if !assert.NoError(t, err) || !assert.NotEmpty(t, page.NextCursor) {
+			return
+		}
+
+		// the cursor of one range reused with a later range would skip its start
+		_, err = s.GetRateHistory(ctx, domain.RateHistoryQuery{
+			Market: "usdtrub",
+			From:   start.Add(30 * time.Second),
+			To:     start.Add(time.Hour),
+			Limit:  1,
+			Cursor: page.NextCursor,
+		})
+
+		assert.True(t, errors.Is(err, domain.ErrInvalidArgument), "got %v", err)
+	})
+}
+
+func TestStorage_UnboundedTimeRange(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		start := contractStart()
+
+		rate := contractRate("usdtrub", start, "95.1", "95.0")
+		saveContractRates(t, s, []*domain.Rate{rate})
+
+		// bounds beyond the years representable as unix nanoseconds
+		from := time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+		page, err := s.GetRateHistory(ctx, domain.RateHistoryQuery{Market: "usdtrub", From: from, To: to, Limit: 10})
+		if assert.NoError(t, err) && assert.Len(t, page.Rates, 1) {
+			assertRate(t, rate, page.Rates[0])
+		}
+
+		candles, err := s.GetCandles(ctx, domain.CandleQuery{Market: "usdtrub", From: from, To: to, Interval: time.Hour})
+		if assert.NoError(t, err) && assert.Len(t, candles, 1) {
+			assert.Equal(t, int64(1), candles[0].Count)
+		}
+	})
+}
+
+func TestStorage_GetCandles(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		start := contractStart()
+
+		saveContractRates(t, s, []*domain.Rate{
+			contractRate("usdtrub", start.Add(10*time.Second), "10", "8"),
+			contractRate("usdtrub", start.Add(20*time.Second), "14", "10"),
+			contractRate("usdtrub", start.Add(30*time.Second), "8", "6"),
+			// the second minute has no rates
+			contractRate("usdtrub", start.Add(2*time.Minute+5*time.Second), "12", "10"),
+			contractRate("btcrub", start.Add(10*time.Second), "6000000", "5990000"),
+		})
+
+		got, err := s.GetCandles(ctx, domain.CandleQuery{
+			Market:   "usdtrub",
+			From:     start,
+			To:       start.Add(time.Hour),
+			Interval: time.Minute,
+		})
+
+		if !assert.NoError(t, err) || !assert.Len(t, got, 2) {
+			return
+		}
+
+		d := domain.MustParseDecimal
+
+		assert.True(t, start.Equal(got[0].Start), "start %s, got %s", start, got[0].Start)
+		assert.Equal(t, int64(3), got[0].Count)
+		assertOHLC(t, domain.OHLC{Open: d("9"), High: d("12"), Low: d("7"), Close: d("7")}, got[0].Mid)
+		assertOHLC(t, domain.OHLC{Open: d("10"), High: d("14"), Low: d("8"), Close: d("8")}, got[0].Ask)
+		assertOHLC(t, domain.OHLC{Open: d("8"), High: d("10"), Low: d("6"), Close: d("6")}, got[0].Bid)
+
+		assert.True(t, start.Add(2*time.Minute).Equal(got[1].Start), "start %s, got %s", start.Add(2*time.Minute), got[1].Start)
+		assert.Equal(t, int64(1), got[1].Count)
+		assertOHLC(t, domain.OHLC{Open: d("11"), High: d("11"), Low: d("11"), Close: d("11")}, got[1].Mid)
+	})
+}
+
+func TestStorage_GetCandlesUnalignedFrom(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		start := contractStart()
+
+		saveContractRates(t, s, []*domain.Rate{
+			contractRate("usdtrub", start.Add(5*time.Minute), "10", "8"),
+			contractRate("usdtrub", start.Add(40*time.Minute), "14", "10"),
+			contractRate("usdtrub", start.Add(70*time.Minute), "12", "10"),
+		})
+
+		// the first candle covers its whole interval rather than the rates from From onward
+		got, err := s.GetCandles(ctx, domain.CandleQuery{
+			Market:   "usdtrub",
+			From:     start.Add(30 * time.Minute),
+			To:       start.Add(2 * time.Hour),
+			Interval: time.Hour,
+		})
+
+		if !assert.NoError(t, err) || !assert.Len(t, got, 2) {
+			return
+		}
+
+		d := domain.MustParseDecimal
+
+		assert.True(t, start.Equal(got[0].Start), "start %s, got %s", start, got[0].Start)
+		assert.Equal(t, int64(2), got[0].Count)
+		assertOHLC(t, domain.OHLC{Open: d("10"), High: d("14"), Low: d("10"), Close: d("14")}, got[0].Ask)
+
+		assert.True(t, start.Add(time.Hour).Equal(got[1].Start), "start %s, got %s", start.Add(time.Hour), got[1].Start)
+		assert.Equal(t, int64(1), got[1].Count)
+	})
+}
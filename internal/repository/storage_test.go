@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Bad connection",
+			err:  fmt.Errorf("error while executing SaveRates sql request: %w", driver.ErrBadConn),
+			want: true,
+		},
+		{
+			name: "Connection refused",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			want: true,
+		},
+		{
+			name: "Deadline exceeded",
+			err:  context.DeadlineExceeded,
+			want: true,
+		},
+		{
+			name: "Postgres is shutting down",
+			err:  &pq.Error{Code: "57P01"},
+			want: true,
+		},
+		{
+			name: "Postgres connection failure",
+			err:  fmt.Errorf("wrapped: %w", &pq.Error{Code: "08006"}),
+			want: true,
+		},
+		{
+			name: "Numeric value out of range",
+			err:  &pq.Error{Code: "22003"},
+		},
+		{
+			name: "Not null violation",
+			err:  &pq.Error{Code: "23502"},
+		},
+		{
+			name: "Unknown error",
+			err:  errors.New("too many rates to save at once"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
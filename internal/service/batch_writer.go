@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// batchFlushTimeout bounds saving of a batch, it does not depend on callers which queued the rates.
+const batchFlushTimeout = 30 * time.Second
+
+// ErrWriterClosed is returned by BatchWriter.SaveRate after Close.
+var ErrWriterClosed = errors.New("batch writer is closed")
+
+var (
+	batchQueueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_batch_queue_length",
+			Help: "Number of rates waiting in the queue of the batch writer",
+		},
+	)
+	batchQueueFull = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_batch_queue_full_total",
+			Help: "Total number of saved rates which had to wait for space in the full queue",
+		},
+	)
+	batchDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_batch_dropped_total",
+			Help: "Total number of rates which were not saved because the queue was full or saving of their batch failed",
+		},
+	)
+	batchFlushSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "rate_batch_flush_size",
+			Help:    "Number of rates in flushed batches",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		},
+	)
+	batchFlushDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "rate_batch_flush_duration_seconds",
+			Help: "Duration of saving batches of rates",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(batchQueueLength, batchQueueFull, batchDropped, batchFlushSize, batchFlushDuration)
+}
+
+// RateBatchStore is a RateStore which can save rates in batches.
+type RateBatchStore interface {
+	RateStore
+	RateBatchSaver
+}
+
+// BatchWriter is a RateStore which saves rates in the background in batches, reads are passed to the store.
+// A batch is saved when it has batchSize rates or flushInterval after the previous flush.
+// Queued rates are not yet visible to reads.
+type BatchWriter struct {
+	store         RateBatchStore
+	batchSize     int
+	flushInterval time.Duration
+	l             *zap.SugaredLogger
+
+	// mu guards closing of the queue against concurrent sends
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *domain.Rate
+	// closing is closed by Close before closing the queue, so that sends waiting for space in the full queue
+	// give up instead of blocking Close
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	// ctx is cancelled when Close gives up waiting for the final flush
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewBatchWriter creates a writer queueing up to queueSize rates and starts saving them, Close must be called to save the queued rates.
+func NewBatchWriter(store RateBatchStore, queueSize, batchSize int, flushInterval time.Duration, logger *zap.SugaredLogger) *BatchWriter {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &BatchWriter{
+		store:         store,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		l:             logger,
+		queue:         make(chan *domain.Rate, queueSize),
+		closing:       make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+
+	go w.loop()
+
+	return w
+}
+
+// SaveRate queues the rate. If the queue is full, it waits for space until ctx is done or the writer is closed
+// and then drops the rate.
+// A queued rate is reported as new, duplicates are counted when its batch is saved.
+func (w *BatchWriter) SaveRate(ctx context.Context, rate *domain.Rate) (bool, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		batchDropped.Inc()
+		return false, ErrWriterClosed
+	}
+
+	select {
+	case w.queue <- rate:
+	default:
+		batchQueueFull.Inc()
+
+		select {
+		case w.queue <- rate:
+		case <-w.closing:
+			batchDropped.Inc()
+			return false, ErrWriterClosed
+		case <-ctx.Done():
+			batchDropped.Inc()
+			return false, ctx.Err()
+		}
+	}
+
+	batchQueueLength.Set(float64(len(w.queue)))
+
+	return true, nil
+}
+
+func (w *BatchWriter) GetLatestRate(ctx context.Context, market string) (*domain.Rate, error) {
+	return w.store.GetLatestRate(ctx, market)
+}
+
+func (w *BatchWriter) GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error) {
+	return w.store.GetRateHistory(ctx, q)
+}
+
+func (w *BatchWriter) GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error) {
+	return w.store.GetCandles(ctx, q)
+}
+
+// Close stops queueing rates and waits until the queued ones are saved, rates waiting for space in the full queue are dropped.
+// If ctx is done first, saving is cancelled and the rates which are not saved yet are dropped.
+func (w *BatchWriter) Close(ctx context.Context) error {
+	// waiting senders hold the read lock, they release it once closing is closed
+	w.closeOnce.Do(func() { close(w.closing) })
+
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-w.done
+		return ctx.Err()
+	}
+}
+
+func (w *BatchWriter) loop() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.Rate, 0, w.batchSize)
+
+	for {
+		select {
+		case rate, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+
+			batch = append(batch, rate)
+			batchQueueLength.Set(float64(len(w.queue)))
+
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = make([]*domain.Rate, 0, w.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = make([]*domain.Rate, 0, w.batchSize)
+			}
+		}
+	}
+}
+
+// flush saves the batch, rates of a batch which failed to save are dropped.
+func (w *BatchWriter) flush(batch []*domain.Rate) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.ctx, batchFlushTimeout)
+	defer cancel()
+
+	start := time.Now()
+	inserted, err := w.store.SaveRates(ctx, batch)
+	batchFlushDuration.Observe(time.Since(start).Seconds())
+	batchFlushSize.Observe(float64(len(batch)))
+
+	if err != nil {
+		batchDropped.Add(float64(len(batch)))
+		w.l.Errorf("failed to save batch of %d rates: %v", len(batch), err)
+		return
+	}
+
+	rateDuplicates.Add(float64(len(batch) - inserted))
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"final/internal/domain"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeBatchStore records saved batches, saving waits for release if it is set.
+// The first duplicates rates of every batch are reported as already saved.
+type fakeBatchStore struct {
+	MockRateStore
+
+	release    chan struct{}
+	duplicates int
+
+	mu      sync.Mutex
+	batches [][]*domain.Rate
+}
+
+func (s *fakeBatchStore) SaveRates(ctx context.Context, rates []*domain.Rate) (int, error) {
+	if s.release != nil {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = append(s.batches, rates)
+
+	return len(rates) - min(s.duplicates, len(rates)), nil
+}
+
+func (s *fakeBatchStore) batchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sizes := make([]int, 0, len(s.batches))
+	for _, b := range s.batches {
+		sizes = append(sizes, len(b))
+	}
+
+	return sizes
+}
+
+func saveRates(t *testing.T, w *BatchWriter, n int) {
+	for range n {
+		saved, err := w.SaveRate(context.Background(), &domain.Rate{Market: "usdtrub"})
+		assert.NoError(t, err)
+		assert.True(t, saved, "queued rate is reported as new")
+	}
+}
+
+func TestBatchWriter_Flush(t *testing.T) {
+	tests := []struct {
+		name          string
+		batchSize     int
+		flushInterval time.Duration
+		saved         int
+		want          []int
+	}{
+		{
+			name:          "Full batches are flushed",
+			batchSize:     2,
+			flushInterval: time.Hour,
+			saved:         5,
+			want:          []int{2, 2},
+		},
+		{
+			name:          "Incomplete batch is flushed after the interval",
+			batchSize:     100,
+			flushInterval: 10 * time.Millisecond,
+			saved:         3,
+			want:          []int{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeBatchStore{}
+			w := NewBatchWriter(store, 10, tt.batchSize, tt.flushInterval, zap.NewNop().Sugar())
+			defer w.Close(context.Background())
+
+			saveRates(t, w, tt.saved)
+
+			assert.Eventually(t, func() bool { return assert.ObjectsAreEqual(tt.want, store.batchSizes()) },
+				time.Second, 5*time.Millisecond)
+		})
+	}
+}
+
+func TestBatchWriter_Close(t *testing.T) {
+	store := &fakeBatchStore{}
+	w := NewBatchWriter(store, 10, 100, time.Hour, zap.NewNop().Sugar())
+
+	saveRates(t, w, 3)
+
+	assert.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, []int{3}, store.batchSizes(), "queued rates are flushed on close")
+
+	dropped := testutil.ToFloat64(batchDropped)
+
+	_, err := w.SaveRate(context.Background(), &domain.Rate{})
+	assert.ErrorIs(t, err, ErrWriterClosed)
+	assert.Equal(t, dropped+1, testutil.ToFloat64(batchDropped))
+	assert.NoError(t, w.Close(context.Background()), "close is idempotent")
+}
+
+func TestBatchWriter_Duplicates(t *testing.T) {
+	store := &fakeBatchStore{duplicates: 2}
+	w := NewBatchWriter(store, 10, 100, time.Hour, zap.NewNop().Sugar())
+
+	duplicates := testutil.ToFloat64(rateDuplicates)
+
+	saveRates(t, w, 3)
+
+	assert.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, duplicates+2, testutil.ToFloat64(rateDuplicates), "duplicates are counted when the batch is saved")
+}
+
+func TestBatchWriter_FullQueue(t *testing.T) {
+	store := &fakeBatchStore{release: make(chan struct{})}
+	w := NewBatchWriter(store, 1, 1, time.Hour, zap.NewNop().Sugar())
+
+	// the first rate is being saved and the second one fills the queue
+	saveRates(t, w, 1)
+	assert.Eventually(t, func() bool { return len(w.queue) == 0 }, time.Second, time.Millisecond)
+	saveRates(t, w, 1)
+
+	full := testutil.ToFloat64(batchQueueFull)
+	dropped := testutil.ToFloat64(batchDropped)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := w.SaveRate(ctx, &domain.Rate{})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, full+1, testutil.ToFloat64(batchQueueFull))
+	assert.Equal(t, dropped+1, testutil.ToFloat64(batchDropped))
+
+	close(store.release)
+
+	assert.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, []int{1, 1}, store.batchSizes())
+}
+
+func TestBatchWriter_CloseTimeout(t *testing.T) {
+	store := &fakeBatchStore{release: make(chan struct{})}
+	w := NewBatchWriter(store, 10, 100, time.Hour, zap.NewNop().Sugar())
+
+	saveRates(t, w, 3)
+
+	dropped := testutil.ToFloat64(batchDropped)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := w.Close(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, store.batchSizes())
+	assert.Equal(t, dropped+3, testutil.ToFloat64(batchDropped), "rates of the cancelled flush are dropped")
+}
+
+func TestBatchWriter_CloseWhileQueueFull(t *testing.T) {
+	store := &fakeBatchStore{release: make(chan struct{})}
+	w := NewBatchWriter(store, 1, 1, time.Hour, zap.NewNop().Sugar())
+
+	// the first rate is being saved and the second one fills the queue
+	saveRates(t, w, 1)
+	assert.Eventually(t, func() bool { return len(w.queue) == 0 }, time.Second, time.Millisecond)
+	saveRates(t, w, 1)
+
+	full := testutil.ToFloat64(batchQueueFull)
+
+	blocked := make(chan error)
+	go func() {
+		_, err := w.SaveRate(context.Background(), &domain.Rate{})
+		blocked <- err
+	}()
+	assert.Eventually(t, func() bool { return testutil.ToFloat64(batchQueueFull) > full }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the save is stuck, Close gives up at its deadline rather than waiting for the blocked sender
+	assert.ErrorIs(t, w.Close(ctx), context.DeadlineExceeded)
+
+	select {
+	case err := <-blocked:
+		assert.ErrorIs(t, err, ErrWriterClosed)
+	case <-time.After(time.Second):
+		t.Fatal("sender waiting for space in the full queue was not released by Close")
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	BinanceApiUrl = "https://api.binance.com/api/v3/depth"
+)
+
+// binanceMarkets maps markets to binance symbols.
+var binanceMarkets = map[string]string{
+	"btcusdt":  "BTCUSDT",
+	"ethusdt":  "ETHUSDT",
+	"usdcusdt": "USDCUSDT",
+	"ethbtc":   "ETHBTC",
+}
+
+// BinanceAPIResponse is a response of the binance depth endpoint.
+// Every level is a [price, quantity] pair.
+type BinanceAPIResponse struct {
+	LastUpdateID int64      `json:"lastUpdateId"`
+	Asks         [][]string `json:"asks"`
+	Bids         [][]string `json:"bids"`
+}
+
+type BinanceFetcher struct {
+	client *http.Client
+	url    string
+	retry  RetryPolicy
+}
+
+func NewBinanceFetcher(retry RetryPolicy) *BinanceFetcher {
+	return &BinanceFetcher{
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		url:   BinanceApiUrl,
+		retry: retry,
+	}
+}
+
+// FetchRate requests the top of the order book for the given market.
+// Binance depth has no timestamp, so the time of the response is used in full, as polls within a second are distinct rates.
+func (r BinanceFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	rate, _, err := r.FetchQuote(ctx, market)
+	return rate, err
+}
+
+// FetchQuote requests the top of the order book for the given market and returns it as received along with the rate.
+func (r BinanceFetcher) FetchQuote(ctx context.Context, market string) (*domain.Rate, *RawQuote, error) {
+	symbol, ok := binanceMarkets[market]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, market)
+	}
+
+	var apiResponse BinanceAPIResponse
+
+	start := time.Now()
+
+	if err := getJSON(ctx, r.client, r.retry, r.url+"?limit=5&symbol="+url.QueryEscape(symbol), &apiResponse); err != nil {
+		return nil, nil, err
+	}
+
+	receivedAt := time.Now()
+
+	if len(apiResponse.Asks) == 0 || len(apiResponse.Bids) == 0 ||
+		len(apiResponse.Asks[0]) < 2 || len(apiResponse.Bids[0]) < 2 {
+		return nil, nil, errors.New("not enough data in binance API response")
+	}
+
+	rate := &domain.Rate{
+		Market:     market,
+		Provider:   ProviderBinance,
+		Timestamp:  receivedAt,
+		ReceivedAt: receivedAt,
+		Latency:    receivedAt.Sub(start),
+	}
+
+	ask, bid := apiResponse.Asks[0], apiResponse.Bids[0]
+	raw := &RawQuote{AskPrice: ask[0], AskVolume: ask[1], BidPrice: bid[0], BidVolume: bid[1]}
+
+	if err := parseTopOfBook(rate, ask[0], ask[1], bid[0], bid[1]); err != nil {
+		return nil, raw, err
+	}
+
+	return rate, raw, nil
+}
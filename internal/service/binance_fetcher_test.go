@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"final/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBinanceFetcher(t *testing.T) {
+	retry := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	got := NewBinanceFetcher(retry)
+
+	assert.NotNil(t, got.client)
+	assert.Equal(t, 5*time.Second, got.client.Timeout)
+	assert.Equal(t, BinanceApiUrl, got.url)
+	assert.Equal(t, retry, got.retry)
+}
+
+func TestBinanceFetcher_FetchRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		market     string
+		status     int
+		body       string
+		wantSymbol string
+		want       *domain.Rate
+		errorText  string
+	}{
+		{
+			name:       "Successful fetch and parse",
+			market:     "btcusdt",
+			status:     http.StatusOK,
+			body:       `{"lastUpdateId":1027024,"bids":[["64999.99","0.5"],["64999.98","1.2"]],"asks":[["65000.01","0.3"]]}`,
+			wantSymbol: "BTCUSDT",
+			want: &domain.Rate{
+				Market:    "btcusdt",
+				Provider:  ProviderBinance,
+				Ask:       domain.MustParseDecimal("65000.01"),
+				Bid:       domain.MustParseDecimal("64999.99"),
+				AskVolume: domain.MustParseDecimal("0.3"),
+				BidVolume: domain.MustParseDecimal("0.5"),
+			},
+		},
+		{
+			name:      "Unknown market",
+			market:    "usdtrub",
+			status:    http.StatusOK,
+			errorText: "unknown market",
+		},
+		{
+			name:       "Invalid symbol",
+			market:     "ethusdt",
+			status:     http.StatusBadRequest,
+			body:       `{"code":-1121,"msg":"Invalid symbol."}`,
+			wantSymbol: "ETHUSDT",
+			errorText:  "unexpected status code: 400",
+		},
+		{
+			name:       "Empty asks in response",
+			market:     "btcusdt",
+			status:     http.StatusOK,
+			body:       `{"lastUpdateId":1027024,"bids":[["64999.99","0.5"]],"asks":[]}`,
+			wantSymbol: "BTCUSDT",
+			errorText:  "not enough data in binance API response",
+		},
+		{
+			name:       "Non-numeric price in response",
+			market:     "btcusdt",
+			status:     http.StatusOK,
+			body:       `{"lastUpdateId":1027024,"bids":[["64999.99","0.5"]],"asks":[["-","0.3"]]}`,
+			wantSymbol: "BTCUSDT",
+			errorText:  `invalid quote: non-numeric ask "-"`,
+		},
+		{
+			name:       "Non-numeric volume in response",
+			market:     "btcusdt",
+			status:     http.StatusOK,
+			body:       `{"lastUpdateId":1027024,"bids":[["64999.99","lots"]],"asks":[["65000.01","0.3"]]}`,
+			wantSymbol: "BTCUSDT",
+			errorText:  `invalid bid volume "lots"`,
+		},
+		{
+			name:       "Invalid JSON response",
+			market:     "btcusdt",
+			status:     http.StatusOK,
+			body:       `{"bids":`,
+			wantSymbol: "BTCUSDT",
+			errorText:  "failed to decode response",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.wantSymbol, r.URL.Query().Get("symbol"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			fetcher := BinanceFetcher{client: server.Client(), url: server.URL}
+
+			got, err := fetcher.FetchRate(context.Background(), tt.market)
+
+			if tt.errorText != "" {
+				assert.ErrorContains(t, err, tt.errorText)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.WithinDuration(t, time.Now(), got.ReceivedAt, 2*time.Second)
+			assert.GreaterOrEqual(t, got.Latency, time.Duration(0))
+			// the receive time is kept in full so that polls within a second are distinct rates
+			assert.Equal(t, got.ReceivedAt, got.Timestamp)
+			got.ReceivedAt, got.Latency, got.Timestamp = time.Time{}, 0, time.Time{}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	BybitApiUrl = "https://api.bybit.com/v5/market/orderbook"
+)
+
+// bybitMarkets maps markets to bybit spot symbols.
+var bybitMarkets = map[string]string{
+	"btcusdt":  "BTCUSDT",
+	"ethusdt":  "ETHUSDT",
+	"usdcusdt": "USDCUSDT",
+}
+
+// BybitAPIResponse is a response of the bybit v5 orderbook endpoint.
+type BybitAPIResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		Symbol    string     `json:"s"`
+		Asks      [][]string `json:"a"`
+		Bids      [][]string `json:"b"`
+		Timestamp int64      `json:"ts"`
+	} `json:"result"`
+}
+
+type BybitFetcher struct {
+	client *http.Client
+	url    string
+	retry  RetryPolicy
+}
+
+func NewBybitFetcher(retry RetryPolicy) *BybitFetcher {
+	return &BybitFetcher{
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		url:   BybitApiUrl,
+		retry: retry,
+	}
+}
+
+// FetchRate requests the top of the spot order book for the given market.
+func (r BybitFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	rate, _, err := r.FetchQuote(ctx, market)
+	return rate, err
+}
+
+// FetchQuote requests the top of the spot order book for the given market and returns it as received along with the rate.
+func (r BybitFetcher) FetchQuote(ctx context.Context, market string) (*domain.Rate, *RawQuote, error) {
+	symbol, ok := bybitMarkets[market]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, market)
+	}
+
+	var apiResponse BybitAPIResponse
+
+	start := time.Now()
+
+	if err := getJSON(ctx, r.client, r.retry, r.url+"?category=spot&limit=1&symbol="+url.QueryEscape(symbol), &apiResponse); err != nil {
+		return nil, nil, err
+	}
+
+	receivedAt := time.Now()
+
+	if apiResponse.RetCode != 0 {
+		return nil, nil, fmt.Errorf("bybit API error %d: %s", apiResponse.RetCode, apiResponse.RetMsg)
+	}
+
+	result := apiResponse.Result
+
+	if len(result.Asks) == 0 || len(result.Bids) == 0 ||
+		len(result.Asks[0]) < 2 || len(result.Bids[0]) < 2 {
+		return nil, nil, errors.New("not enough data in bybit API response")
+	}
+
+	rate := &domain.Rate{
+		Market:     market,
+		Provider:   ProviderBybit,
+		Timestamp:  time.UnixMilli(result.Timestamp),
+		ReceivedAt: receivedAt,
+		Latency:    receivedAt.Sub(start),
+	}
+
+	ask, bid := result.Asks[0], result.Bids[0]
+	raw := &RawQuote{
+		AskPrice:  ask[0],
+		AskVolume: ask[1],
+		BidPrice:  bid[0],
+		BidVolume: bid[1],
+		Timestamp: strconv.FormatInt(result.Timestamp, 10),
+	}
+
+	if err := parseTopOfBook(rate, ask[0], ask[1], bid[0], bid[1]); err != nil {
+		return nil, raw, err
+	}
+
+	return rate, raw, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"final/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBybitFetcher(t *testing.T) {
+	retry := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	got := NewBybitFetcher(retry)
+
+	assert.NotNil(t, got.client)
+	assert.Equal(t, 5*time.Second, got.client.Timeout)
+	assert.Equal(t, BybitApiUrl, got.url)
+	assert.Equal(t, retry, got.retry)
+}
+
+func TestBybitFetcher_FetchRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		market     string
+		status     int
+		body       string
+		wantSymbol string
+		want       *domain.Rate
+		errorText  string
+	}{
+		{
+			name:   "Successful fetch and parse",
+			market: "ethusdt",
+			status: http.StatusOK,
+			body: `{"retCode":0,"retMsg":"OK","result":{"s":"ETHUSDT","a":[["3500.11","16.6"]],` +
+				`"b":[["3500.01","47.08"]],"ts":1716863719031,"u":230704},"retExtInfo":{},"time":1716863719382}`,
+			wantSymbol: "ETHUSDT",
+			want: &domain.Rate{
+				Market:    "ethusdt",
+				Provider:  ProviderBybit,
+				Ask:       domain.MustParseDecimal("3500.11"),
+				Bid:       domain.MustParseDecimal("3500.01"),
+				AskVolume: domain.MustParseDecimal("16.6"),
+				BidVolume: domain.MustParseDecimal("47.08"),
+				Timestamp: time.UnixMilli(1716863719031),
+			},
+		},
+		{
+			name:      "Unknown market",
+			market:    "btcrub",
+			status:    http.StatusOK,
+			errorText: "unknown market",
+		},
+		{
+			name:       "Non-zero retCode",
+			market:     "btcusdt",
+			status:     http.StatusOK,
+			body:       `{"retCode":10001,"retMsg":"params error: symbol invalid","result":{}}`,
+			wantSymbol: "BTCUSDT",
+			errorText:  "bybit API error 10001: params error: symbol invalid",
+		},
+		{
+			name:       "Empty bids in response",
+			market:     "btcusdt",
+			status:     http.StatusOK,
+			body:       `{"retCode":0,"retMsg":"OK","result":{"s":"BTCUSDT","a":[["65000.1","1"]],"b":[],"ts":1716863719031}}`,
+			wantSymbol: "BTCUSDT",
+			errorText:  "not enough data in bybit API response",
+		},
+		{
+			name:       "Non-200 HTTP response",
+			market:     "btcusdt",
+			status:     http.StatusServiceUnavailable,
+			wantSymbol: "BTCUSDT",
+			errorText:  "unexpected status code: 503",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "spot", r.URL.Query().Get("category"))
+				assert.Equal(t, tt.wantSymbol, r.URL.Query().Get("symbol"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			fetcher := BybitFetcher{client: server.Client(), url: server.URL}
+
+			got, err := fetcher.FetchRate(context.Background(), tt.market)
+
+			if tt.errorText != "" {
+				assert.ErrorContains(t, err, tt.errorText)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.WithinDuration(t, time.Now(), got.ReceivedAt, 2*time.Second)
+			assert.GreaterOrEqual(t, got.Latency, time.Duration(0))
+			got.ReceivedAt, got.Latency = time.Time{}, 0
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
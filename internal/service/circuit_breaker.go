@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CircuitState is a state of the circuit breaker, values are exported as rate_provider_circuit_state.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitHalfOpen
+	CircuitOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// ErrCircuitOpen is returned without calling the provider while its circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+var (
+	circuitState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rate_provider_circuit_state",
+			Help: "Circuit breaker state of a rate provider (0 - closed, 1 - half-open, 2 - open)",
+		},
+		[]string{"provider"},
+	)
+	circuitRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_provider_circuit_rejections_total",
+			Help: "Total number of requests rejected by an open circuit breaker",
+		},
+		[]string{"provider"},
+	)
+	circuitTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_provider_circuit_transitions_total",
+			Help: "Total number of circuit breaker state transitions",
+		},
+		[]string{"provider", "state"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(circuitState, circuitRejections, circuitTransitions)
+}
+
+// CircuitBreakerFetcher opens the circuit of a provider after failureThreshold consecutive failures.
+// While the circuit is open requests fail fast with ErrCircuitOpen.
+// After cooldown a single trial request is let through in the half-open state,
+// its success closes the circuit and its failure opens it again.
+type CircuitBreakerFetcher struct {
+	provider         string
+	fetcher          RateFetcher
+	failureThreshold int
+	cooldown         time.Duration
+	now              func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// probing is true while the half-open trial request is in flight
+	probing bool
+}
+
+func NewCircuitBreakerFetcher(provider string, fetcher RateFetcher, failureThreshold int, cooldown time.Duration) *CircuitBreakerFetcher {
+	circuitState.WithLabelValues(provider).Set(float64(CircuitClosed))
+
+	return &CircuitBreakerFetcher{
+		provider:         provider,
+		fetcher:          fetcher,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		now:              time.Now,
+	}
+}
+
+// FetchRate calls the provider if the circuit allows it.
+// Unknown markets and requests cancelled by the caller are counted neither as failures nor as successes.
+func (c *CircuitBreakerFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	if err := c.acquire(); err != nil {
+		return nil, err
+	}
+
+	rate, err := c.fetcher.FetchRate(ctx, market)
+	c.record(ctx, err)
+
+	return rate, err
+}
+
+// OrderBooks returns the order book fetcher of the provider guarded by the same circuit,
+// so failures of rates and order books open it together.
+func (c *CircuitBreakerFetcher) OrderBooks(books OrderBookFetcher) OrderBookFetcher {
+	return &circuitBreakerBooks{breaker: c, books: books}
+}
+
+type circuitBreakerBooks struct {
+	breaker *CircuitBreakerFetcher
+	books   OrderBookFetcher
+}
+
+// FetchOrderBook calls the provider if the circuit allows it.
+func (b *circuitBreakerBooks) FetchOrderBook(ctx context.Context, market string) (*domain.OrderBook, error) {
+	if err := b.breaker.acquire(); err != nil {
+		return nil, err
+	}
+
+	book, err := b.books.FetchOrderBook(ctx, market)
+	b.breaker.record(ctx, err)
+
+	return book, err
+}
+
+// acquire returns ErrCircuitOpen if the circuit does not let the request through.
+func (c *CircuitBreakerFetcher) acquire() error {
+	if !c.allow() {
+		circuitRejections.WithLabelValues(c.provider).Inc()
+		return fmt.Errorf("%s: %w", c.provider, ErrCircuitOpen)
+	}
+
+	return nil
+}
+
+// record updates the circuit with the result of the request.
+func (c *CircuitBreakerFetcher) record(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		c.onSuccess()
+	case ctx.Err() != nil, errors.Is(err, domain.ErrUnknownMarket):
+		c.onNeutral()
+	default:
+		c.onFailure()
+	}
+}
+
+// State returns the current state of the circuit.
+func (c *CircuitBreakerFetcher) State() CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state
+}
+
+func (c *CircuitBreakerFetcher) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if c.now().Sub(c.openedAt) < c.cooldown {
+			return false
+		}
+		c.setState(CircuitHalfOpen)
+		c.probing = true
+		return true
+	case CircuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (c *CircuitBreakerFetcher) onSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = 0
+	c.probing = false
+	if c.state != CircuitClosed {
+		c.setState(CircuitClosed)
+	}
+}
+
+func (c *CircuitBreakerFetcher) onFailure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures++
+	c.probing = false
+	if c.state == CircuitHalfOpen || c.failures >= c.failureThreshold {
+		c.openedAt = c.now()
+		c.setState(CircuitOpen)
+	}
+}
+
+// onNeutral releases the half-open trial without changing the state or the failure count.
+func (c *CircuitBreakerFetcher) onNeutral() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probing = false
+}
+
+func (c *CircuitBreakerFetcher) setState(state CircuitState) {
+	if c.state == state {
+		return
+	}
+
+	c.state = state
+	circuitState.WithLabelValues(c.provider).Set(float64(state))
+	circuitTransitions.WithLabelValues(c.provider, state.String()).Inc()
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCircuitBreakerFetcher_FetchRate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rate := &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5")}
+	fetchErr := errors.New("timeout")
+
+	fetcher := new(MockRateFetcher)
+	breaker := NewCircuitBreakerFetcher("test-breaker", fetcher, 2, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	fetch := func() error {
+		_, err := breaker.FetchRate(context.Background(), "usdtrub")
+		return err
+	}
+
+	// failures below the threshold keep the circuit closed
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, fetchErr).Once()
+	assert.ErrorIs(t, fetch(), fetchErr)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	// unknown market is neither a provider failure nor a success resetting the failures
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "usdtrub")).Once()
+	assert.ErrorIs(t, fetch(), domain.ErrUnknownMarket)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, fetchErr).Once()
+	assert.ErrorIs(t, fetch(), fetchErr)
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.Equal(t, float64(CircuitOpen), testutil.ToFloat64(circuitState.WithLabelValues("test-breaker")))
+
+	// open circuit fails fast
+	assert.ErrorIs(t, fetch(), ErrCircuitOpen)
+	assert.Equal(t, float64(1), testutil.ToFloat64(circuitRejections.WithLabelValues("test-breaker")))
+
+	// failed trial after cooldown opens the circuit again
+	now = now.Add(30 * time.Second)
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, fetchErr).Once()
+	assert.ErrorIs(t, fetch(), fetchErr)
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.ErrorIs(t, fetch(), ErrCircuitOpen)
+
+	// successful trial closes the circuit
+	now = now.Add(30 * time.Second)
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(rate, nil).Once()
+	assert.NoError(t, fetch())
+	assert.Equal(t, CircuitClosed, breaker.State())
+	assert.Equal(t, float64(CircuitClosed), testutil.ToFloat64(circuitState.WithLabelValues("test-breaker")))
+
+	fetcher.AssertExpectations(t)
+}
+
+func TestCircuitBreakerFetcher_HalfOpenAllowsSingleTrial(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	release := make(chan time.Time)
+
+	fetcher := new(MockRateFetcher)
+	breaker := NewCircuitBreakerFetcher("test-half-open", fetcher, 1, time.Second)
+	breaker.now = func() time.Time { return now }
+
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, errors.New("timeout")).Once()
+	_, _ = breaker.FetchRate(context.Background(), "usdtrub")
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	now = now.Add(time.Second)
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").WaitUntil(release).Return(&domain.Rate{}, nil).Once()
+
+	done := make(chan error)
+	go func() {
+		_, err := breaker.FetchRate(context.Background(), "usdtrub")
+		done <- err
+	}()
+
+	assert.Eventually(t, func() bool { return breaker.State() == CircuitHalfOpen }, time.Second, time.Millisecond)
+
+	_, err := breaker.FetchRate(context.Background(), "usdtrub")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreakerFetcher_UnknownMarketTrialKeepsHalfOpen(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	fetcher := new(MockRateFetcher)
+	breaker := NewCircuitBreakerFetcher("test-unknown-trial", fetcher, 1, time.Second)
+	breaker.now = func() time.Time { return now }
+
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, errors.New("timeout")).Once()
+	_, _ = breaker.FetchRate(context.Background(), "usdtrub")
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	// a trial of an unknown market says nothing about the provider, the next request is a trial again
+	now = now.Add(time.Second)
+	fetcher.On("FetchRate", mock.Anything, "xrprub").Return(nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "xrprub")).Once()
+	_, err := breaker.FetchRate(context.Background(), "xrprub")
+	assert.ErrorIs(t, err, domain.ErrUnknownMarket)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, errors.New("timeout")).Once()
+	_, err = breaker.FetchRate(context.Background(), "usdtrub")
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	fetcher.AssertExpectations(t)
+}
+
+func TestCircuitState_String(t *testing.T) {
+	assert.Equal(t, "closed", CircuitClosed.String())
+	assert.Equal(t, "half-open", CircuitHalfOpen.String())
+	assert.Equal(t, "open", CircuitOpen.String())
+	assert.Equal(t, "CircuitState(7)", CircuitState(7).String())
+}
+
+func TestCircuitBreakerFetcher_OrderBooksShareCircuit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	fetchErr := errors.New("timeout")
+
+	fetcher := new(MockRateFetcher)
+	books := new(MockOrderBookFetcher)
+
+	breaker := NewCircuitBreakerFetcher("test-books", fetcher, 2, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+	guarded := breaker.OrderBooks(books)
+
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, fetchErr).Once()
+	_, err := breaker.FetchRate(context.Background(), "usdtrub")
+	assert.ErrorIs(t, err, fetchErr)
+
+	// an order book failure of the same provider opens the circuit
+	books.On("FetchOrderBook", mock.Anything, "usdtrub").Return(nil, fetchErr).Once()
+	_, err = guarded.FetchOrderBook(context.Background(), "usdtrub")
+	assert.ErrorIs(t, err, fetchErr)
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	_, err = guarded.FetchOrderBook(context.Background(), "usdtrub")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// a successful order book trial closes the circuit for rates too
+	now = now.Add(30 * time.Second)
+	books.On("FetchOrderBook", mock.Anything, "usdtrub").Return(&domain.OrderBook{Market: "usdtrub"}, nil).Once()
+	_, err = guarded.FetchOrderBook(context.Background(), "usdtrub")
+	assert.NoError(t, err)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	fetcher.AssertExpectations(t)
+	books.AssertExpectations(t)
+}
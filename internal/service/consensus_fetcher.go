@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Consensus methods.
+const (
+	ConsensusMedian       = "median"
+	ConsensusWeightedMean = "weighted"
+)
+
+// ProviderConsensus is the provider name of aggregated rates.
+const ProviderConsensus = "consensus"
+
+// ConsensusFetcher fetches rates from all providers concurrently and aggregates them into a single rate.
+// Quotes which mid price deviates from the median mid price by more than band are excluded.
+type ConsensusFetcher struct {
+	providers []Provider
+	method    string
+	band      float64
+	weights   map[string]float64
+	l         *zap.SugaredLogger
+}
+
+// NewConsensusFetcher creates a consensus fetcher.
+// Band is a relative deviation, e.g. 0.02 for 2%. Providers missing in weights have weight 1.
+// Returns an error if method is unknown or band or weights are not positive.
+func NewConsensusFetcher(providers []Provider, method string, band float64, weights map[string]float64, logger *zap.SugaredLogger) (*ConsensusFetcher, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("no providers for consensus")
+	}
+
+	if method != ConsensusMedian && method != ConsensusWeightedMean {
+		return nil, fmt.Errorf("unknown consensus method %q", method)
+	}
+
+	if band <= 0 {
+		return nil, fmt.Errorf("consensus band must be positive, got %v", band)
+	}
+
+	for name, w := range weights {
+		if w <= 0 {
+			return nil, fmt.Errorf("weight of provider %q must be positive, got %v", name, w)
+		}
+	}
+
+	return &ConsensusFetcher{
+		providers: providers,
+		method:    method,
+		band:      band,
+		weights:   weights,
+		l:         logger,
+	}, nil
+}
+
+// quote is a validated provider rate.
+type quote struct {
+	source   *domain.RateSource
+	ask, bid domain.Decimal
+	rate     *domain.Rate
+	weight   domain.Decimal
+}
+
+// FetchRate returns an aggregated rate with sources explaining its calculation.
+// Returns domain.ErrUnknownMarket if none of the providers supports the market.
+func (c *ConsensusFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	rates := make([]*domain.Rate, len(c.providers))
+	errs := make([]error, len(c.providers))
+
+	var wg sync.WaitGroup
+	for i, p := range c.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rates[i], errs[i] = p.Fetcher.FetchRate(ctx, market)
+		}()
+	}
+	wg.Wait()
+
+	var quotes []*quote
+	sources := make([]*domain.RateSource, 0, len(c.providers))
+	unsupported := 0
+
+	for i, p := range c.providers {
+		if errs[i] != nil {
+			if errors.Is(errs[i], domain.ErrUnknownMarket) {
+				unsupported++
+				continue
+			}
+
+			c.l.Warnf("consensus: provider %s failed: %v", p.Name, errs[i])
+			sources = append(sources, &domain.RateSource{Provider: p.Name, Excluded: true, Reason: "fetch failed"})
+			continue
+		}
+
+		q, err := c.parseQuote(p.Name, rates[i])
+		if err != nil {
+			sources = append(sources, &domain.RateSource{
+				Provider: p.Name, Ask: rates[i].Ask, Bid: rates[i].Bid, Excluded: true, Reason: err.Error(),
+			})
+			continue
+		}
+
+		sources = append(sources, q.source)
+		quotes = append(quotes, q)
+	}
+
+	if unsupported == len(c.providers) {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, market)
+	}
+
+	if len(quotes) == 0 {
+		return nil, errors.New("no provider returned a valid quote")
+	}
+
+	mids := make([]domain.Decimal, len(quotes))
+	for i, q := range quotes {
+		mids[i] = q.ask.Add(q.bid).Half()
+	}
+	medianMid := median(mids)
+
+	var included []*quote
+	for i, q := range quotes {
+		deviation, _ := mids[i].Sub(medianMid).Abs().Div(medianMid, 10, domain.RoundHalfEven)
+		if deviation.Float64() > c.band {
+			q.source.Excluded = true
+			q.source.Reason = fmt.Sprintf("deviation %.4f%% exceeds band %.4f%%", deviation.Float64()*100, c.band*100)
+			continue
+		}
+
+		included = append(included, q)
+	}
+
+	if len(included) == 0 {
+		return nil, errors.New("no quotes within consensus band")
+	}
+
+	rate := &domain.Rate{
+		Market:   market,
+		Provider: ProviderConsensus,
+	}
+
+	// aggregated prices are rounded to the precision of the included quotes,
+	// volumes are summed as the included books are available together,
+	// the timestamp is when the consensus was taken, the latest time a quote was received, so that a change
+	// of an older quote is not saved as a duplicate of the previous consensus, latency is of the slowest provider
+	precision := int32(0)
+	for _, q := range included {
+		precision = max(precision, q.ask.Scale(), q.bid.Scale())
+		rate.AskVolume = rate.AskVolume.Add(q.rate.AskVolume)
+		rate.BidVolume = rate.BidVolume.Add(q.rate.BidVolume)
+		if q.rate.ReceivedAt.After(rate.ReceivedAt) {
+			rate.ReceivedAt = q.rate.ReceivedAt
+		}
+		rate.Timestamp = latest(rate.Timestamp, q.rate.Timestamp, q.rate.ReceivedAt)
+		rate.Latency = max(rate.Latency, q.rate.Latency)
+	}
+
+	rate.Ask, rate.Bid = c.aggregate(included, precision)
+
+	rate.Sources = make([]domain.RateSource, len(sources))
+	for i, source := range sources {
+		rate.Sources[i] = *source
+	}
+
+	return rate, nil
+}
+
+// aggregate calculates ask and bid of included quotes rounded to precision and sets their normalized weights.
+func (c *ConsensusFetcher) aggregate(included []*quote, precision int32) (domain.Decimal, domain.Decimal) {
+	if c.method == ConsensusMedian {
+		asks := make([]domain.Decimal, len(included))
+		bids := make([]domain.Decimal, len(included))
+		for i, q := range included {
+			asks[i], bids[i] = q.ask, q.bid
+			q.source.Weight = 1 / float64(len(included))
+		}
+
+		return median(asks).Round(precision, domain.RoundHalfEven), median(bids).Round(precision, domain.RoundHalfEven)
+	}
+
+	var ask, bid, total domain.Decimal
+	for _, q := range included {
+		ask = ask.Add(q.ask.Mul(q.weight))
+		bid = bid.Add(q.bid.Mul(q.weight))
+		total = total.Add(q.weight)
+	}
+
+	for _, q := range included {
+		q.source.Weight = q.weight.Float64() / total.Float64()
+	}
+
+	// total is positive as weights are validated by NewConsensusFetcher
+	ask, _ = ask.Div(total, precision, domain.RoundHalfEven)
+	bid, _ = bid.Div(total, precision, domain.RoundHalfEven)
+
+	return ask, bid
+}
+
+func (c *ConsensusFetcher) parseQuote(provider string, rate *domain.Rate) (*quote, error) {
+	if rate.Ask.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid ask %q", rate.Ask)
+	}
+
+	if rate.Bid.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid bid %q", rate.Bid)
+	}
+
+	weight := domain.NewDecimal(1, 0)
+	if w, ok := c.weights[provider]; ok {
+		var err error
+		if weight, err = domain.DecimalFromFloat(w); err != nil {
+			return nil, fmt.Errorf("invalid weight %v: %w", w, err)
+		}
+	}
+
+	return &quote{
+		source: &domain.RateSource{Provider: provider, Ask: rate.Ask, Bid: rate.Bid},
+		ask:    rate.Ask,
+		bid:    rate.Bid,
+		rate:   rate,
+		weight: weight,
+	}, nil
+}
+
+// median returns the exact median of values.
+func median(values []domain.Decimal) domain.Decimal {
+	sorted := slices.Clone(values)
+	slices.SortFunc(sorted, domain.Decimal.Cmp)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+
+	return sorted[n/2-1].Add(sorted[n/2]).Half()
+}
+
+// latest returns the latest of the times.
+func latest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, tt := range times {
+		if tt.After(t) {
+			t = tt
+		}
+	}
+	return t
+}
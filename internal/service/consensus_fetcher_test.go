@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestNewConsensusFetcher(t *testing.T) {
+	providers := []Provider{{Name: "a", Fetcher: new(MockRateFetcher)}}
+
+	tests := []struct {
+		name      string
+		providers []Provider
+		method    string
+		band      float64
+		weights   map[string]float64
+		errorText string
+	}{
+		{
+			name:      "Valid median consensus",
+			providers: providers,
+			method:    ConsensusMedian,
+			band:      0.02,
+		},
+		{
+			name:      "No providers",
+			method:    ConsensusMedian,
+			band:      0.02,
+			errorText: "no providers for consensus",
+		},
+		{
+			name:      "Unknown method",
+			providers: providers,
+			method:    "mode",
+			band:      0.02,
+			errorText: `unknown consensus method "mode"`,
+		},
+		{
+			name:      "Zero band",
+			providers: providers,
+			method:    ConsensusMedian,
+			errorText: "consensus band must be positive, got 0",
+		},
+		{
+			name:      "Negative weight",
+			providers: providers,
+			method:    ConsensusWeightedMean,
+			band:      0.02,
+			weights:   map[string]float64{"a": -1},
+			errorText: `weight of provider "a" must be positive, got -1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConsensusFetcher(tt.providers, tt.method, tt.band, tt.weights, zap.NewNop().Sugar())
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestConsensusFetcher_FetchRate(t *testing.T) {
+	older := time.Unix(1700000000, 0)
+	newer := time.Unix(1700000010, 0)
+
+	quote := func(ask, bid string, ts time.Time) *domain.Rate {
+		return &domain.Rate{Market: "btcusdt", Ask: domain.MustParseDecimal(ask), Bid: domain.MustParseDecimal(bid), Timestamp: ts}
+	}
+
+	book := func(rate *domain.Rate, askVolume, bidVolume string, received time.Time, latency time.Duration) *domain.Rate {
+		rate.AskVolume, rate.BidVolume = domain.MustParseDecimal(askVolume), domain.MustParseDecimal(bidVolume)
+		rate.ReceivedAt, rate.Latency = received, latency
+		return rate
+	}
+
+	type result struct {
+		rate *domain.Rate
+		err  error
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		weights   map[string]float64
+		results   []result
+		want      *domain.Rate
+		errorText string
+		errorIs   error
+	}{
+		{
+			name:   "Median with an outlier excluded",
+			method: ConsensusMedian,
+			results: []result{
+				{rate: book(quote("100.5", "99.5", older), "1.5", "2", newer.Add(time.Second), 300*time.Millisecond)},
+				{rate: book(quote("101.0", "100.0", newer), "0.25", "1", newer.Add(2*time.Second), 100*time.Millisecond)},
+				{rate: book(quote("120.0", "119.0", newer), "10", "10", newer.Add(3*time.Second), time.Second)},
+			},
+			want: &domain.Rate{
+				Market:     "btcusdt",
+				Provider:   ProviderConsensus,
+				Ask:        domain.MustParseDecimal("100.8"),
+				Bid:        domain.MustParseDecimal("99.8"),
+				AskVolume:  domain.MustParseDecimal("1.75"),
+				BidVolume:  domain.MustParseDecimal("3"),
+				Timestamp:  newer.Add(2 * time.Second),
+				ReceivedAt: newer.Add(2 * time.Second),
+				Latency:    300 * time.Millisecond,
+				Sources: []domain.RateSource{
+					{Provider: "p0", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Weight: 0.5},
+					{Provider: "p1", Ask: domain.MustParseDecimal("101.0"), Bid: domain.MustParseDecimal("100.0"), Weight: 0.5},
+					{Provider: "p2", Ask: domain.MustParseDecimal("120.0"), Bid: domain.MustParseDecimal("119.0"), Excluded: true, Reason: "deviation 18.9055% exceeds band 2.0000%"},
+				},
+			},
+		},
+		{
+			name:    "Weighted mean with failed and unsupported providers",
+			method:  ConsensusWeightedMean,
+			weights: map[string]float64{"p0": 3},
+			results: []result{
+				{rate: quote("100", "98", older)},
+				{rate: quote("104", "102", older)},
+				{err: errors.New("timeout")},
+				{err: fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "btcusdt")},
+			},
+			want: &domain.Rate{
+				Market:    "btcusdt",
+				Provider:  ProviderConsensus,
+				Ask:       domain.MustParseDecimal("101"),
+				Bid:       domain.MustParseDecimal("99"),
+				Timestamp: older,
+				Sources: []domain.RateSource{
+					{Provider: "p0", Ask: domain.MustParseDecimal("100"), Bid: domain.MustParseDecimal("98"), Weight: 0.75},
+					{Provider: "p1", Ask: domain.MustParseDecimal("104"), Bid: domain.MustParseDecimal("102"), Weight: 0.25},
+					{Provider: "p2", Excluded: true, Reason: "fetch failed"},
+				},
+			},
+		},
+		{
+			name:   "Invalid quote is excluded",
+			method: ConsensusMedian,
+			results: []result{
+				{rate: quote("100", "99", older)},
+				{rate: quote("0", "99", older)},
+			},
+			want: &domain.Rate{
+				Market:    "btcusdt",
+				Provider:  ProviderConsensus,
+				Ask:       domain.MustParseDecimal("100"),
+				Bid:       domain.MustParseDecimal("99"),
+				Timestamp: older,
+				Sources: []domain.RateSource{
+					{Provider: "p0", Ask: domain.MustParseDecimal("100"), Bid: domain.MustParseDecimal("99"), Weight: 1},
+					{Provider: "p1", Ask: domain.MustParseDecimal("0"), Bid: domain.MustParseDecimal("99"), Excluded: true, Reason: `invalid ask "0"`},
+				},
+			},
+		},
+		{
+			name:   "Market unsupported by every provider",
+			method: ConsensusMedian,
+			results: []result{
+				{err: fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "btcusdt")},
+				{err: fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "btcusdt")},
+			},
+			errorIs: domain.ErrUnknownMarket,
+		},
+		{
+			name:   "Every provider failed",
+			method: ConsensusMedian,
+			results: []result{
+				{err: errors.New("timeout")},
+				{err: fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "btcusdt")},
+			},
+			errorText: "no provider returned a valid quote",
+		},
+		{
+			name:   "Quotes disagree",
+			method: ConsensusMedian,
+			results: []result{
+				{rate: quote("100", "99", older)},
+				{rate: quote("110", "109", older)},
+			},
+			errorText: "no quotes within consensus band",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]Provider, len(tt.results))
+			for i, res := range tt.results {
+				fetcher := new(MockRateFetcher)
+				fetcher.On("FetchRate", mock.Anything, "btcusdt").Return(res.rate, res.err)
+				providers[i] = Provider{Name: fmt.Sprintf("p%d", i), Fetcher: fetcher}
+			}
+
+			c, err := NewConsensusFetcher(providers, tt.method, 0.02, tt.weights, zap.NewNop().Sugar())
+			assert.NoError(t, err)
+
+			got, err := c.FetchRate(context.Background(), "btcusdt")
+
+			switch {
+			case tt.errorIs != nil:
+				assert.ErrorIs(t, err, tt.errorIs)
+				assert.Nil(t, got)
+			case tt.errorText != "":
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConsensusFetcher_FetchRate_OlderQuoteChanged(t *testing.T) {
+	older := time.Unix(1700000000, 0)
+	newer := time.Unix(1700000010, 0)
+
+	olderQuote := new(MockRateFetcher)
+	olderQuote.On("FetchRate", mock.Anything, "btcusdt").Return(
+		&domain.Rate{Ask: domain.MustParseDecimal("100"), Bid: domain.MustParseDecimal("99"), Timestamp: older, ReceivedAt: newer.Add(time.Second)}, nil).Once()
+	olderQuote.On("FetchRate", mock.Anything, "btcusdt").Return(
+		&domain.Rate{Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Timestamp: older.Add(time.Second), ReceivedAt: newer.Add(2 * time.Second)}, nil).Once()
+
+	newerQuote := new(MockRateFetcher)
+	newerQuote.On("FetchRate", mock.Anything, "btcusdt").Return(
+		&domain.Rate{Ask: domain.MustParseDecimal("100"), Bid: domain.MustParseDecimal("99"), Timestamp: newer, ReceivedAt: newer.Add(time.Second)}, nil).Once()
+	newerQuote.On("FetchRate", mock.Anything, "btcusdt").Return(
+		&domain.Rate{Ask: domain.MustParseDecimal("100"), Bid: domain.MustParseDecimal("99"), Timestamp: newer, ReceivedAt: newer.Add(2 * time.Second)}, nil).Once()
+
+	c, err := NewConsensusFetcher([]Provider{{Name: "p0", Fetcher: olderQuote}, {Name: "p1", Fetcher: newerQuote}}, ConsensusMedian, 0.02, nil, zap.NewNop().Sugar())
+	assert.NoError(t, err)
+
+	first, err := c.FetchRate(context.Background(), "btcusdt")
+	assert.NoError(t, err)
+
+	second, err := c.FetchRate(context.Background(), "btcusdt")
+	assert.NoError(t, err)
+
+	// the latest quote is the same, the new consensus must not have the key of the previous one
+	assert.NotEqual(t, first.Ask, second.Ask)
+	assert.True(t, second.Timestamp.After(first.Timestamp), "consensus timestamps %v and %v", first.Timestamp, second.Timestamp)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var failoverProvider = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "rate_failover_provider",
+		Help: "Provider which served the last rate of a market, 1 for the serving provider",
+	},
+	[]string{"market", "provider"},
+)
+
+func init() {
+	prometheus.MustRegister(failoverProvider)
+}
+
+// FailoverFetcher tries providers in the priority order and returns the first successful rate.
+// Providers which do not support the market are skipped.
+type FailoverFetcher struct {
+	providers []Provider
+	l         *zap.SugaredLogger
+
+	mu sync.Mutex
+	// last is the provider which served the last rate of every market
+	last map[string]string
+}
+
+func NewFailoverFetcher(providers []Provider, logger *zap.SugaredLogger) (*FailoverFetcher, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("no providers for failover")
+	}
+
+	return &FailoverFetcher{
+		providers: providers,
+		l:         logger,
+		last:      make(map[string]string),
+	}, nil
+}
+
+// FetchRate returns the rate of the first provider which served it, rate.Provider is set to its name.
+// Returns domain.ErrUnknownMarket if none of the providers supports the market.
+func (f *FailoverFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	var errs []error
+
+	for _, p := range f.providers {
+		rate, err := p.Fetcher.FetchRate(ctx, market)
+		if err == nil {
+			rate.Provider = p.Name
+			f.setLast(p.Name, market)
+			return rate, nil
+		}
+
+		if errors.Is(err, domain.ErrUnknownMarket) {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failover interrupted on provider %s: %w", p.Name, err)
+		}
+
+		f.l.Warnf("failover: provider %s failed to serve %s: %v", p.Name, market, err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, market)
+	}
+
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// FetchOrderBook returns the order book of the first provider which served it, book.Provider is set to its name.
+// Providers which do not serve order books are skipped.
+// Returns domain.ErrUnknownMarket if none of the providers supports the market.
+func (f *FailoverFetcher) FetchOrderBook(ctx context.Context, market string) (*domain.OrderBook, error) {
+	var errs []error
+
+	for _, p := range f.providers {
+		if p.Books == nil {
+			continue
+		}
+
+		book, err := p.Books.FetchOrderBook(ctx, market)
+		if err == nil {
+			book.Provider = p.Name
+			return book, nil
+		}
+
+		if errors.Is(err, domain.ErrUnknownMarket) {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failover interrupted on provider %s: %w", p.Name, err)
+		}
+
+		f.l.Warnf("failover: provider %s failed to serve %s order book: %v", p.Name, market, err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, market)
+	}
+
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// setLast records the provider which served the rate of the market, exports it as rate_failover_provider
+// and logs a switch of its provider.
+func (f *FailoverFetcher) setLast(provider, market string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	last := f.last[market]
+	if last != provider {
+		if last != "" {
+			f.l.Infof("failover: %s rate is served by %s instead of %s", market, provider, last)
+			failoverProvider.DeleteLabelValues(market, last)
+		}
+		f.last[market] = provider
+		failoverProvider.WithLabelValues(market, provider).Set(1)
+	}
+}
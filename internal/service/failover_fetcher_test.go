@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestNewFailoverFetcher(t *testing.T) {
+	got, err := NewFailoverFetcher(nil, zap.NewNop().Sugar())
+
+	assert.EqualError(t, err, "no providers for failover")
+	assert.Nil(t, got)
+}
+
+func TestFailoverFetcher_FetchRate(t *testing.T) {
+	fixedTime := time.Unix(1700000000, 0)
+	unknownMarket := fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "usdtrub")
+
+	type result struct {
+		rate *domain.Rate
+		err  error
+	}
+
+	tests := []struct {
+		name         string
+		results      []result
+		want         *domain.Rate
+		wantLast     string
+		wantNotCalls []int
+		errorText    string
+		errorIs      error
+	}{
+		{
+			name: "Primary serves the rate",
+			results: []result{
+				{rate: &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Timestamp: fixedTime}},
+				{rate: &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("101"), Bid: domain.MustParseDecimal("100"), Timestamp: fixedTime}},
+			},
+			want:         &domain.Rate{Market: "usdtrub", Provider: "p0", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Timestamp: fixedTime},
+			wantLast:     "p0",
+			wantNotCalls: []int{1},
+		},
+		{
+			name: "Backup serves the rate when primary fails",
+			results: []result{
+				{err: errors.New("timeout")},
+				{err: unknownMarket},
+				{rate: &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("101"), Bid: domain.MustParseDecimal("100"), Timestamp: fixedTime}},
+			},
+			want:     &domain.Rate{Market: "usdtrub", Provider: "p2", Ask: domain.MustParseDecimal("101"), Bid: domain.MustParseDecimal("100"), Timestamp: fixedTime},
+			wantLast: "p2",
+		},
+		{
+			name: "Every provider failed",
+			results: []result{
+				{err: errors.New("timeout")},
+				{err: errors.New("unexpected status code: 500")},
+			},
+			errorText: "all providers failed: p0: timeout\np1: unexpected status code: 500",
+		},
+		{
+			name: "Market unsupported by every provider",
+			results: []result{
+				{err: unknownMarket},
+				{err: unknownMarket},
+			},
+			errorIs: domain.ErrUnknownMarket,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]Provider, len(tt.results))
+			fetchers := make([]*MockRateFetcher, len(tt.results))
+			for i, res := range tt.results {
+				fetchers[i] = new(MockRateFetcher)
+				fetchers[i].On("FetchRate", mock.Anything, "usdtrub").Return(res.rate, res.err)
+				providers[i] = Provider{Name: fmt.Sprintf("p%d", i), Fetcher: fetchers[i]}
+			}
+
+			f, err := NewFailoverFetcher(providers, zap.NewNop().Sugar())
+			assert.NoError(t, err)
+
+			got, err := f.FetchRate(context.Background(), "usdtrub")
+
+			switch {
+			case tt.errorIs != nil:
+				assert.ErrorIs(t, err, tt.errorIs)
+				assert.Nil(t, got)
+			case tt.errorText != "":
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			assert.Equal(t, tt.wantLast, f.last["usdtrub"])
+
+			for _, i := range tt.wantNotCalls {
+				fetchers[i].AssertNotCalled(t, "FetchRate", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestFailoverFetcher_LastProviderByMarket(t *testing.T) {
+	primary := new(MockRateFetcher)
+	primary.On("FetchRate", mock.Anything, "usdtrub").Return(&domain.Rate{Market: "usdtrub"}, nil)
+	primary.On("FetchRate", mock.Anything, "btcrub").Return(nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "btcrub"))
+
+	backup := new(MockRateFetcher)
+	backup.On("FetchRate", mock.Anything, "btcrub").Return(&domain.Rate{Market: "btcrub"}, nil)
+
+	f, err := NewFailoverFetcher([]Provider{{Name: "p0", Fetcher: primary}, {Name: "p1", Fetcher: backup}}, zap.NewNop().Sugar())
+	assert.NoError(t, err)
+
+	// alternating markets served by different providers are not a failover
+	for _, market := range []string{"usdtrub", "btcrub", "usdtrub", "btcrub"} {
+		_, err := f.FetchRate(context.Background(), market)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]string{"usdtrub": "p0", "btcrub": "p1"}, f.last)
+	assert.Equal(t, float64(1), testutil.ToFloat64(failoverProvider.WithLabelValues("usdtrub", "p0")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(failoverProvider.WithLabelValues("btcrub", "p1")))
+}
+
+func TestFailoverFetcher_ProviderSwitchMetric(t *testing.T) {
+	primary := new(MockRateFetcher)
+	primary.On("FetchRate", mock.Anything, "ethusdt").Return(&domain.Rate{Market: "ethusdt"}, nil).Once()
+	primary.On("FetchRate", mock.Anything, "ethusdt").Return(nil, errors.New("timeout"))
+
+	backup := new(MockRateFetcher)
+	backup.On("FetchRate", mock.Anything, "ethusdt").Return(&domain.Rate{Market: "ethusdt"}, nil)
+
+	f, err := NewFailoverFetcher([]Provider{{Name: "primary", Fetcher: primary}, {Name: "backup", Fetcher: backup}}, zap.NewNop().Sugar())
+	assert.NoError(t, err)
+
+	_, err = f.FetchRate(context.Background(), "ethusdt")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(failoverProvider.WithLabelValues("ethusdt", "primary")))
+
+	_, err = f.FetchRate(context.Background(), "ethusdt")
+	assert.NoError(t, err)
+
+	// the series of the previous provider is removed
+	assert.False(t, failoverProvider.DeleteLabelValues("ethusdt", "primary"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(failoverProvider.WithLabelValues("ethusdt", "backup")))
+}
+
+func TestFailoverFetcher_FetchOrderBook(t *testing.T) {
+	unknownMarket := fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "usdtrub")
+
+	failing := new(MockOrderBookFetcher)
+	failing.On("FetchOrderBook", mock.Anything, "usdtrub").Return(nil, errors.New("timeout"))
+
+	unsupported := new(MockOrderBookFetcher)
+	unsupported.On("FetchOrderBook", mock.Anything, "usdtrub").Return(nil, unknownMarket)
+
+	serving := new(MockOrderBookFetcher)
+	serving.On("FetchOrderBook", mock.Anything, "usdtrub").Return(&domain.OrderBook{Market: "usdtrub"}, nil)
+
+	tests := []struct {
+		name      string
+		providers []Provider
+		want      *domain.OrderBook
+		errorText string
+		errorIs   error
+	}{
+		{
+			name: "Providers without order books are skipped",
+			providers: []Provider{
+				{Name: "p0", Fetcher: new(MockRateFetcher)},
+				{Name: "p1", Fetcher: new(MockRateFetcher), Books: failing},
+				{Name: "p2", Fetcher: new(MockRateFetcher), Books: unsupported},
+				{Name: "p3", Fetcher: new(MockRateFetcher), Books: serving},
+			},
+			want: &domain.OrderBook{Market: "usdtrub", Provider: "p3"},
+		},
+		{
+			name: "Every provider failed",
+			providers: []Provider{
+				{Name: "p0", Fetcher: new(MockRateFetcher), Books: failing},
+			},
+			errorText: "all providers failed: p0: timeout",
+		},
+		{
+			name: "No provider serves order books",
+			providers: []Provider{
+				{Name: "p0", Fetcher: new(MockRateFetcher)},
+				{Name: "p1", Fetcher: new(MockRateFetcher), Books: unsupported},
+			},
+			errorIs: domain.ErrUnknownMarket,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFailoverFetcher(tt.providers, zap.NewNop().Sugar())
+			assert.NoError(t, err)
+
+			got, err := f.FetchOrderBook(context.Background(), "usdtrub")
+
+			switch {
+			case tt.errorIs != nil:
+				assert.ErrorIs(t, err, tt.errorIs)
+				assert.Nil(t, got)
+			case tt.errorText != "":
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// RetryPolicy configures retries of upstream requests.
+// Connection errors, 429, 5xx responses and truncated bodies are retried with exponential backoff and jitter.
+type RetryPolicy struct {
+	// MaxAttempts is a total number of attempts, values below 2 disable retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// backoff returns a delay before the next attempt after the given number of failed attempts.
+// Half of the delay is fixed and half is random.
+func (p RetryPolicy) backoff(failed int) time.Duration {
+	delay := p.BaseDelay << (failed - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryableError is a failure of an attempt which may succeed if repeated.
+type retryableError struct {
+	err error
+	// retryAfter is a delay requested by the server, zero if not requested
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// getJSON sends GET request to the url and decodes json response body into v.
+// Failed attempts are retried according to the policy while ctx deadline allows waiting for the next one
+// and the server does not ask to retry after more than the max delay.
+// Every attempt is recorded as a span.
+// Returns an error if request failed or response status is not 200.
+func getJSON(ctx context.Context, client *http.Client, retry RetryPolicy, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	tracer := otel.Tracer("final-service/fetcher")
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx, span := tracer.Start(ctx, "GET "+req.URL.Host+req.URL.Path)
+		span.SetAttributes(attribute.Int("attempt", attempt), attribute.String("url", url))
+
+		err := doGetJSON(client, req.Clone(attemptCtx), v)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		var retryable *retryableError
+		if err == nil || !errors.As(err, &retryable) || attempt >= retry.MaxAttempts || ctx.Err() != nil {
+			if err != nil && attempt > 1 {
+				return fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return err
+		}
+
+		// a server asking to wait longer than the policy allows is not waited for, it would stall the poll
+		if retryable.retryAfter > retry.MaxDelay {
+			return fmt.Errorf("%w (after %d attempts, retry after %s exceeds max delay %s)", err, attempt, retryable.retryAfter, retry.MaxDelay)
+		}
+
+		delay := max(retry.backoff(attempt), retryable.retryAfter)
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return fmt.Errorf("%w (after %d attempts, no time left to retry)", err, attempt)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (after %d attempts)", err, attempt)
+		case <-timer.C:
+		}
+	}
+}
+
+func doGetJSON(client *http.Client, req *http.Request, v any) error {
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return &retryableError{err: fmt.Errorf("failed to do request: %w", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			return &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+		}
+
+		return err
+	}
+
+	// drop fields decoded by a previous truncated attempt
+	reflect.ValueOf(v).Elem().SetZero()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		err = fmt.Errorf("failed to decode response: %w", err)
+
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return &retryableError{err: err}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// parseRetryAfter parses Retry-After header given in seconds or as HTTP date.
+// Returns zero if the header is empty or malformed.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type response struct {
+	status     int
+	body       string
+	retryAfter string
+}
+
+func TestGetJSON_Retries(t *testing.T) {
+	retry := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		responses []response
+		timeout   time.Duration
+		// maxDelay overrides the max delay of the policy
+		maxDelay  time.Duration
+		want      int
+		wantCalls int32
+		errorText string
+	}{
+		{
+			name: "Server error is retried",
+			responses: []response{
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusOK, body: `{"value": 1}`},
+			},
+			want:      1,
+			wantCalls: 2,
+		},
+		{
+			name: "Too many requests honours Retry-After",
+			responses: []response{
+				{status: http.StatusTooManyRequests, retryAfter: "1"},
+				{status: http.StatusOK, body: `{"value": 2}`},
+			},
+			maxDelay:  2 * time.Second,
+			want:      2,
+			wantCalls: 2,
+		},
+		{
+			name: "Truncated body is retried",
+			responses: []response{
+				{status: http.StatusOK, body: `{"value": 3`},
+				{status: http.StatusOK, body: `{"value": 3}`},
+			},
+			want:      3,
+			wantCalls: 2,
+		},
+		{
+			name: "Client error is not retried",
+			responses: []response{
+				{status: http.StatusBadRequest},
+			},
+			wantCalls: 1,
+			errorText: "unexpected status code: 400",
+		},
+		{
+			name: "Malformed body is not retried",
+			responses: []response{
+				{status: http.StatusOK, body: `[]`},
+			},
+			wantCalls: 1,
+			errorText: "failed to decode response",
+		},
+		{
+			name: "Gives up after max attempts",
+			responses: []response{
+				{status: http.StatusBadGateway},
+				{status: http.StatusBadGateway},
+				{status: http.StatusBadGateway},
+			},
+			wantCalls: 3,
+			errorText: "unexpected status code: 502 (after 3 attempts)",
+		},
+		{
+			name: "Retry-After beyond the deadline is not waited",
+			responses: []response{
+				{status: http.StatusServiceUnavailable, retryAfter: "10"},
+			},
+			timeout:   time.Second,
+			maxDelay:  time.Minute,
+			wantCalls: 1,
+			errorText: "unexpected status code: 503 (after 1 attempts, no time left to retry)",
+		},
+		{
+			name: "Retry-After beyond the max delay is not waited",
+			responses: []response{
+				{status: http.StatusTooManyRequests, retryAfter: "3600"},
+			},
+			wantCalls: 1,
+			errorText: "unexpected status code: 429 (after 1 attempts, retry after 1h0m0s exceeds max delay 5ms)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := tt.responses[calls.Add(1)-1]
+				if resp.retryAfter != "" {
+					w.Header().Set("Retry-After", resp.retryAfter)
+				}
+				w.WriteHeader(resp.status)
+				_, _ = w.Write([]byte(resp.body))
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			var got struct {
+				Value int `json:"value"`
+			}
+
+			policy := retry
+			if tt.maxDelay > 0 {
+				policy.MaxDelay = tt.maxDelay
+			}
+
+			err := getJSON(ctx, server.Client(), policy, server.URL, &got)
+
+			if tt.errorText != "" {
+				assert.ErrorContains(t, err, tt.errorText)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got.Value)
+			}
+
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestGetJSON_AttemptSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "GetRate")
+
+	var got struct{}
+	err := getJSON(ctx, server.Client(), RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, server.URL, &got)
+	parent.End()
+
+	assert.NoError(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	for _, span := range spans[:2] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+	assert.Len(t, spans[0].Events(), 1, "error of the first attempt is recorded")
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	for i := 0; i < 100; i++ {
+		d := p.backoff(1)
+		assert.True(t, d >= 50*time.Millisecond && d <= 100*time.Millisecond, "first delay %s", d)
+
+		d = p.backoff(2)
+		assert.True(t, d >= 100*time.Millisecond && d <= 200*time.Millisecond, "second delay %s", d)
+
+		d = p.backoff(10)
+		assert.True(t, d >= 150*time.Millisecond && d <= 300*time.Millisecond, "capped delay %s", d)
+	}
+
+	assert.Zero(t, RetryPolicy{}.backoff(1))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Zero(t, parseRetryAfter(""))
+	assert.Zero(t, parseRetryAfter("soon"))
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	assert.InDelta(t, time.Hour, parseRetryAfter(date), float64(2*time.Second))
+}
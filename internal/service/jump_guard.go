@@ -0,0 +1,111 @@
+package service
+
+import (
+	"final/internal/domain"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	jumpsHeld = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_jumps_held_total",
+			Help: "Total number of rates held as unconfirmed because of a suspicious price move",
+		},
+		[]string{"market"},
+	)
+	jumpsReleased = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_jumps_released_total",
+			Help: "Total number of held price moves confirmed by a later observation",
+		},
+		[]string{"market"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(jumpsHeld, jumpsReleased)
+}
+
+// JumpGuard holds rates which move more than threshold from the previous accepted rate of the market
+// until a later observation confirms the move.
+// An observation confirms the held rate if it is within threshold of it
+// and comes from a different provider or has a different timestamp, so a repeated bad tick does not confirm itself.
+type JumpGuard struct {
+	threshold float64
+	l         *zap.SugaredLogger
+
+	mu      sync.Mutex
+	markets map[string]*guardState
+}
+
+type guardState struct {
+	accepted *domain.Rate
+	// held is an unconfirmed rate, nil if there is none
+	held *domain.Rate
+}
+
+// NewJumpGuard creates a guard, threshold is a max relative move of the mid price, e.g. 0.1 for 10%.
+func NewJumpGuard(threshold float64, logger *zap.SugaredLogger) *JumpGuard {
+	return &JumpGuard{
+		threshold: threshold,
+		l:         logger,
+		markets:   make(map[string]*guardState),
+	}
+}
+
+// Observe returns the rate to be served and whether it is the observed one.
+// If the observed rate is held, the previous accepted rate is returned instead.
+// The first rate of a market is accepted as there is nothing to compare it with.
+func (g *JumpGuard) Observe(rate *domain.Rate) (*domain.Rate, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	state, ok := g.markets[rate.Market]
+	if !ok {
+		g.markets[rate.Market] = &guardState{accepted: rate}
+		return rate, true
+	}
+
+	move, ok := relativeMove(state.accepted, rate)
+	if !ok || move <= g.threshold {
+		if state.held != nil {
+			g.l.Infof("discarded unconfirmed %s rate %s/%s of %s", rate.Market, state.held.Ask, state.held.Bid, state.held.Provider)
+		}
+		state.accepted, state.held = rate, nil
+		return rate, true
+	}
+
+	if state.held != nil && confirms(state.held, rate) {
+		if heldMove, ok := relativeMove(state.held, rate); ok && heldMove <= g.threshold {
+			jumpsReleased.WithLabelValues(rate.Market).Inc()
+			g.l.Warnf("released %s rate move of %.4f%% confirmed by %s", rate.Market, move*100, rate.Provider)
+
+			state.accepted, state.held = rate, nil
+			return rate, true
+		}
+	}
+
+	jumpsHeld.WithLabelValues(rate.Market).Inc()
+	g.l.Warnf("held unconfirmed %s rate %s/%s of %s, mid moved %.4f%% from %s/%s", rate.Market, rate.Ask, rate.Bid, rate.Provider, move*100, state.accepted.Ask, state.accepted.Bid)
+
+	state.held = rate
+	return state.accepted, false
+}
+
+// confirms reports whether the rate is an observation independent of the held one.
+func confirms(held, rate *domain.Rate) bool {
+	return rate.Provider != held.Provider || !rate.Timestamp.Equal(held.Timestamp)
+}
+
+// relativeMove returns the relative change of the mid price, false if the previous mid price is zero.
+func relativeMove(from, to *domain.Rate) (float64, bool) {
+	move, err := to.Mid().Sub(from.Mid()).Abs().Div(from.Mid(), 10, domain.RoundHalfEven)
+	if err != nil {
+		return 0, false
+	}
+
+	return move.Float64(), true
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"final/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestJumpGuard_Observe(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+
+	quote := func(provider, ask, bid string, seconds int) *domain.Rate {
+		return &domain.Rate{Market: "usdcrub", Provider: provider, Ask: domain.MustParseDecimal(ask), Bid: domain.MustParseDecimal(bid), Timestamp: ts.Add(time.Duration(seconds) * time.Second)}
+	}
+
+	first := quote("garantex", "100", "98", 0)
+	small := quote("garantex", "104", "102", 10)
+	jump := quote("garantex", "130", "128", 20)
+	repeated := quote("garantex", "130", "128", 20)
+	confirmed := quote("binance", "131", "129", 20)
+	back := quote("garantex", "104", "102", 30)
+
+	type step struct {
+		rate     *domain.Rate
+		want     *domain.Rate
+		accepted bool
+	}
+
+	tests := []struct {
+		name         string
+		steps        []step
+		wantHeld     float64
+		wantReleased float64
+	}{
+		{
+			name: "Moves within threshold are accepted",
+			steps: []step{
+				{rate: first, want: first, accepted: true},
+				{rate: small, want: small, accepted: true},
+			},
+		},
+		{
+			name: "Jump is held until another provider confirms it",
+			steps: []step{
+				{rate: small, want: small, accepted: true},
+				{rate: jump, want: small},
+				{rate: repeated, want: small},
+				{rate: confirmed, want: confirmed, accepted: true},
+			},
+			wantHeld:     2,
+			wantReleased: 1,
+		},
+		{
+			name: "Jump is confirmed by a later tick of the same provider",
+			steps: []step{
+				{rate: small, want: small, accepted: true},
+				{rate: quote("garantex", "130", "128", 20), want: small},
+				{rate: quote("garantex", "129", "127", 25), want: quote("garantex", "129", "127", 25), accepted: true},
+			},
+			wantHeld:     1,
+			wantReleased: 1,
+		},
+		{
+			name: "Held rate is discarded when the price returns",
+			steps: []step{
+				{rate: small, want: small, accepted: true},
+				{rate: jump, want: small},
+				{rate: back, want: back, accepted: true},
+				{rate: confirmed, want: back},
+			},
+			wantHeld: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			held := testutil.ToFloat64(jumpsHeld.WithLabelValues("usdcrub"))
+			released := testutil.ToFloat64(jumpsReleased.WithLabelValues("usdcrub"))
+
+			g := NewJumpGuard(0.1, zap.NewNop().Sugar())
+
+			for i, s := range tt.steps {
+				got, accepted := g.Observe(s.rate)
+				assert.Equal(t, s.want, got, "step %d", i)
+				assert.Equal(t, s.accepted, accepted, "step %d", i)
+			}
+
+			assert.Equal(t, tt.wantHeld, testutil.ToFloat64(jumpsHeld.WithLabelValues("usdcrub"))-held)
+			assert.Equal(t, tt.wantReleased, testutil.ToFloat64(jumpsReleased.WithLabelValues("usdcrub"))-released)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KrakenApiUrl = "https://api.kraken.com/0/public/Depth"
+)
+
+// krakenMarkets maps markets to kraken pairs.
+var krakenMarkets = map[string]string{
+	"btcusdt":  "XBTUSDT",
+	"ethusdt":  "ETHUSDT",
+	"usdcusdt": "USDCUSDT",
+	"usdtusd":  "USDTZUSD",
+	"btcusd":   "XXBTZUSD",
+	"ethbtc":   "XETHXXBT",
+}
+
+// KrakenAPIResponse is a response of the kraken depth endpoint.
+// Result is keyed by the kraken pair name, which may differ from the requested one.
+type KrakenAPIResponse struct {
+	Error  []string                   `json:"error"`
+	Result map[string]KrakenOrderBook `json:"result"`
+}
+
+// KrakenOrderBook holds levels as [price, volume, timestamp] triples.
+type KrakenOrderBook struct {
+	Asks [][3]json.RawMessage `json:"asks"`
+	Bids [][3]json.RawMessage `json:"bids"`
+}
+
+type KrakenFetcher struct {
+	client *http.Client
+	url    string
+	retry  RetryPolicy
+}
+
+func NewKrakenFetcher(retry RetryPolicy) *KrakenFetcher {
+	return &KrakenFetcher{
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		url:   KrakenApiUrl,
+		retry: retry,
+	}
+}
+
+// FetchRate requests the top of the order book for the given market.
+// The most recent of top ask and bid timestamps is used as rate timestamp.
+func (r KrakenFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	rate, _, err := r.FetchQuote(ctx, market)
+	return rate, err
+}
+
+// FetchQuote requests the top of the order book for the given market and returns it as received along with the rate.
+func (r KrakenFetcher) FetchQuote(ctx context.Context, market string) (*domain.Rate, *RawQuote, error) {
+	pair, ok := krakenMarkets[market]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, market)
+	}
+
+	var apiResponse KrakenAPIResponse
+
+	start := time.Now()
+
+	if err := getJSON(ctx, r.client, r.retry, r.url+"?count=1&pair="+url.QueryEscape(pair), &apiResponse); err != nil {
+		return nil, nil, err
+	}
+
+	receivedAt := time.Now()
+
+	if len(apiResponse.Error) > 0 {
+		return nil, nil, fmt.Errorf("kraken API error: %s", strings.Join(apiResponse.Error, "; "))
+	}
+
+	if len(apiResponse.Result) != 1 {
+		return nil, nil, errors.New("not enough data in kraken API response")
+	}
+
+	var book KrakenOrderBook
+	for _, b := range apiResponse.Result {
+		book = b
+	}
+
+	if len(book.Asks) == 0 || len(book.Bids) == 0 {
+		return nil, nil, errors.New("not enough data in kraken API response")
+	}
+
+	ask, err := parseKrakenLevel(book.Asks[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse ask: %w", err)
+	}
+
+	bid, err := parseKrakenLevel(book.Bids[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse bid: %w", err)
+	}
+
+	rate := &domain.Rate{
+		Market:     market,
+		Provider:   ProviderKraken,
+		Timestamp:  time.Unix(max(ask.timestamp, bid.timestamp), 0),
+		ReceivedAt: receivedAt,
+		Latency:    receivedAt.Sub(start),
+	}
+
+	raw := &RawQuote{
+		AskPrice:  ask.price,
+		AskVolume: ask.volume,
+		BidPrice:  bid.price,
+		BidVolume: bid.volume,
+		Timestamp: strconv.FormatInt(max(ask.timestamp, bid.timestamp), 10),
+	}
+
+	if err := parseTopOfBook(rate, ask.price, ask.volume, bid.price, bid.volume); err != nil {
+		return nil, raw, err
+	}
+
+	return rate, raw, nil
+}
+
+// krakenLevel is a decoded order book level.
+type krakenLevel struct {
+	price, volume string
+	timestamp     int64
+}
+
+// parseKrakenLevel decodes price, volume and unix timestamp of the level.
+func parseKrakenLevel(level [3]json.RawMessage) (krakenLevel, error) {
+	var res krakenLevel
+
+	if err := json.Unmarshal(level[0], &res.price); err != nil {
+		return res, fmt.Errorf("invalid price: %w", err)
+	}
+
+	if err := json.Unmarshal(level[1], &res.volume); err != nil {
+		return res, fmt.Errorf("invalid volume: %w", err)
+	}
+
+	if err := json.Unmarshal(level[2], &res.timestamp); err != nil {
+		return res, fmt.Errorf("invalid timestamp: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"final/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKrakenFetcher(t *testing.T) {
+	retry := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	got := NewKrakenFetcher(retry)
+
+	assert.NotNil(t, got.client)
+	assert.Equal(t, 5*time.Second, got.client.Timeout)
+	assert.Equal(t, KrakenApiUrl, got.url)
+	assert.Equal(t, retry, got.retry)
+}
+
+func TestKrakenFetcher_FetchRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		market    string
+		status    int
+		body      string
+		wantPair  string
+		want      *domain.Rate
+		errorText string
+	}{
+		{
+			name:   "Successful fetch and parse",
+			market: "btcusd",
+			status: http.StatusOK,
+			body: `{"error":[],"result":{"XXBTZUSD":{"asks":[["69000.10000","0.500",1700000005]],` +
+				`"bids":[["68999.90000","1.250",1700000003]]}}}`,
+			wantPair: "XXBTZUSD",
+			want: &domain.Rate{
+				Market:    "btcusd",
+				Provider:  ProviderKraken,
+				Ask:       domain.MustParseDecimal("69000.10000"),
+				Bid:       domain.MustParseDecimal("68999.90000"),
+				AskVolume: domain.MustParseDecimal("0.500"),
+				BidVolume: domain.MustParseDecimal("1.250"),
+				Timestamp: time.Unix(1700000005, 0),
+			},
+		},
+		{
+			name:   "Result keyed by a different pair name",
+			market: "btcusdt",
+			status: http.StatusOK,
+			body: `{"error":[],"result":{"XBTUSDT":{"asks":[["69010.1","0.1",1700000000]],` +
+				`"bids":[["69009.9","0.2",1700000001]]}}}`,
+			wantPair: "XBTUSDT",
+			want: &domain.Rate{
+				Market:    "btcusdt",
+				Provider:  ProviderKraken,
+				Ask:       domain.MustParseDecimal("69010.1"),
+				Bid:       domain.MustParseDecimal("69009.9"),
+				AskVolume: domain.MustParseDecimal("0.1"),
+				BidVolume: domain.MustParseDecimal("0.2"),
+				Timestamp: time.Unix(1700000001, 0),
+			},
+		},
+		{
+			name:      "Unknown market",
+			market:    "usdtrub",
+			status:    http.StatusOK,
+			errorText: "unknown market",
+		},
+		{
+			name:      "API error",
+			market:    "ethbtc",
+			status:    http.StatusOK,
+			body:      `{"error":["EQuery:Unknown asset pair"]}`,
+			wantPair:  "XETHXXBT",
+			errorText: "kraken API error: EQuery:Unknown asset pair",
+		},
+		{
+			name:      "Empty asks in response",
+			market:    "btcusd",
+			status:    http.StatusOK,
+			body:      `{"error":[],"result":{"XXBTZUSD":{"asks":[],"bids":[["68999.9","1.25",1700000003]]}}}`,
+			wantPair:  "XXBTZUSD",
+			errorText: "not enough data in kraken API response",
+		},
+		{
+			name:      "Malformed level",
+			market:    "btcusd",
+			status:    http.StatusOK,
+			body:      `{"error":[],"result":{"XXBTZUSD":{"asks":[[69000.1,"0.5",1700000005]],"bids":[["68999.9","1.25",1700000003]]}}}`,
+			wantPair:  "XXBTZUSD",
+			errorText: "failed to parse ask",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.wantPair, r.URL.Query().Get("pair"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			fetcher := KrakenFetcher{client: server.Client(), url: server.URL}
+
+			got, err := fetcher.FetchRate(context.Background(), tt.market)
+
+			if tt.errorText != "" {
+				assert.ErrorContains(t, err, tt.errorText)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.WithinDuration(t, time.Now(), got.ReceivedAt, 2*time.Second)
+			assert.GreaterOrEqual(t, got.Latency, time.Duration(0))
+			got.ReceivedAt, got.Latency = time.Time{}, 0
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"final/internal/domain"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	DefaultOrderBookDepth = 20
+	MaxOrderBookDepth     = 100
+)
+
+func NewOrderBookService(fetcher OrderBookFetcher) *OrderBookService {
+	return &OrderBookService{
+		fetcher: fetcher,
+	}
+}
+
+type OrderBookService struct {
+	fetcher OrderBookFetcher
+}
+
+type OrderBookFetcher interface {
+	FetchOrderBook(ctx context.Context, market string) (*domain.OrderBook, error)
+}
+
+// GetOrderBook fetches the order book for the market and keeps depth levels on each side.
+// Empty market is treated as domain.DefaultMarket, non-positive depth as DefaultOrderBookDepth.
+// Depth is limited by MaxOrderBookDepth.
+func (s *OrderBookService) GetOrderBook(ctx context.Context, market string, depth int) (*domain.OrderBook, error) {
+	if market == "" {
+		market = domain.DefaultMarket
+	}
+
+	if depth <= 0 {
+		depth = DefaultOrderBookDepth
+	}
+	depth = min(depth, MaxOrderBookDepth)
+
+	book, err := s.fetcher.FetchOrderBook(ctx, market)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order book: %w", err)
+	}
+
+	book.Asks = book.Asks[:min(depth, len(book.Asks))]
+	book.Bids = book.Bids[:min(depth, len(book.Bids))]
+
+	return book, nil
+}
+
+// GetExecutionPrice walks the full order book of the market and returns the volume-weighted price
+// of buying and selling amount of the base currency.
+// Returns domain.ErrInvalidArgument if amount is not a positive number and
+// domain.ErrInsufficientLiquidity if either side of the book is too thin.
+func (s *OrderBookService) GetExecutionPrice(ctx context.Context, market string, amount string) (*domain.ExecutionPrice, error) {
+	if market == "" {
+		market = domain.DefaultMarket
+	}
+
+	size, ok := new(big.Rat).SetString(amount)
+	if !ok || size.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: amount must be a positive number, got %q", domain.ErrInvalidArgument, amount)
+	}
+
+	book, err := s.fetcher.FetchOrderBook(ctx, market)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order book: %w", err)
+	}
+
+	buy, err := walkBook(book.Asks, size, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to buy %s on %s: %w", amount, market, err)
+	}
+
+	sell, err := walkBook(book.Bids, size, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sell %s on %s: %w", amount, market, err)
+	}
+
+	return &domain.ExecutionPrice{
+		Market:    book.Market,
+		Provider:  book.Provider,
+		Amount:    amount,
+		Buy:       *buy,
+		Sell:      *sell,
+		Timestamp: book.Timestamp,
+	}, nil
+}
+
+// executionPricePrecision is a number of fractional digits of calculated prices.
+const executionPricePrecision = 8
+
+// walkBook fills size by consuming levels from the best one.
+// For the bid side slippage is measured downwards from the best price.
+// Levels with a non-positive price or volume make the book invalid.
+func walkBook(levels []domain.PriceLevel, size *big.Rat, bids bool) (*domain.ExecutionQuote, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("%w: order book side is empty", domain.ErrInsufficientLiquidity)
+	}
+
+	remaining := new(big.Rat).Set(size)
+	cost := new(big.Rat)
+	var best, worst *big.Rat
+
+	for _, level := range levels {
+		price, ok := new(big.Rat).SetString(level.Price)
+		if !ok || price.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid price %q in order book", level.Price)
+		}
+
+		volume, ok := new(big.Rat).SetString(level.Volume)
+		if !ok || volume.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid volume %q in order book", level.Volume)
+		}
+
+		if best == nil {
+			best = price
+		}
+		worst = price
+
+		take := volume
+		if remaining.Cmp(volume) < 0 {
+			take = remaining
+		}
+
+		cost.Add(cost, new(big.Rat).Mul(take, price))
+		remaining.Sub(remaining, take)
+
+		if remaining.Sign() == 0 {
+			break
+		}
+	}
+
+	if remaining.Sign() > 0 {
+		filled := new(big.Rat).Sub(size, remaining)
+		return nil, fmt.Errorf("%w: only %s of %s can be filled", domain.ErrInsufficientLiquidity,
+			formatRat(filled), formatRat(size))
+	}
+
+	vwap := new(big.Rat).Quo(cost, size)
+
+	slippage := new(big.Rat).Sub(vwap, best)
+	if bids {
+		slippage.Neg(slippage)
+	}
+	slippage.Mul(slippage, big.NewRat(10000, 1))
+	slippage.Quo(slippage, best)
+
+	return &domain.ExecutionQuote{
+		VWAP:        formatRat(vwap),
+		BestPrice:   formatRat(best),
+		WorstPrice:  formatRat(worst),
+		SlippageBps: formatRat(slippage),
+	}, nil
+}
+
+// formatRat formats r with executionPricePrecision digits dropping trailing zeros.
+func formatRat(r *big.Rat) string {
+	s := r.FloatString(executionPricePrecision)
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOrderBookFetcher is a mock implementation of the OrderBookFetcher interface.
+type MockOrderBookFetcher struct {
+	mock.Mock
+}
+
+func (m *MockOrderBookFetcher) FetchOrderBook(ctx context.Context, market string) (*domain.OrderBook, error) {
+	args := m.Called(ctx, market)
+	if book, ok := args.Get(0).(*domain.OrderBook); ok {
+		return book, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestOrderBookService_GetOrderBook(t *testing.T) {
+	fixedTime := time.Unix(1700000000, 0)
+
+	levels := func(prices ...string) []domain.PriceLevel {
+		res := make([]domain.PriceLevel, len(prices))
+		for i, p := range prices {
+			res[i] = domain.PriceLevel{Price: p, Volume: "1"}
+		}
+		return res
+	}
+
+	newBook := func() *domain.OrderBook {
+		return &domain.OrderBook{
+			Market:    "usdtrub",
+			Provider:  ProviderGarantex,
+			Asks:      levels("100", "101", "102"),
+			Bids:      levels("99", "98"),
+			Timestamp: fixedTime,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		market     string
+		depth      int
+		wantMarket string
+		fetchErr   error
+		want       *domain.OrderBook
+		errorText  string
+	}{
+		{
+			name:       "Depth limits both sides",
+			market:     "usdtrub",
+			depth:      2,
+			wantMarket: "usdtrub",
+			want: &domain.OrderBook{
+				Market:    "usdtrub",
+				Provider:  ProviderGarantex,
+				Asks:      levels("100", "101"),
+				Bids:      levels("99", "98"),
+				Timestamp: fixedTime,
+			},
+		},
+		{
+			name:       "Default market and depth",
+			wantMarket: domain.DefaultMarket,
+			want:       newBook(),
+		},
+		{
+			name:       "Fetcher returns error",
+			market:     "btcrub",
+			depth:      5,
+			wantMarket: "btcrub",
+			fetchErr:   errors.New("fetch error"),
+			errorText:  "failed to fetch order book: fetch error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := new(MockOrderBookFetcher)
+			if tt.fetchErr != nil {
+				fetcher.On("FetchOrderBook", mock.Anything, tt.wantMarket).Return(nil, tt.fetchErr)
+			} else {
+				fetcher.On("FetchOrderBook", mock.Anything, tt.wantMarket).Return(newBook(), nil)
+			}
+
+			got, err := NewOrderBookService(fetcher).GetOrderBook(context.Background(), tt.market, tt.depth)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			fetcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderBookService_GetExecutionPrice(t *testing.T) {
+	fixedTime := time.Unix(1700000000, 0)
+
+	book := &domain.OrderBook{
+		Market:   "usdtrub",
+		Provider: ProviderGarantex,
+		Asks: []domain.PriceLevel{
+			{Price: "100", Volume: "100000"},
+			{Price: "100.5", Volume: "100000"},
+			{Price: "101", Volume: "100000"},
+		},
+		Bids: []domain.PriceLevel{
+			{Price: "99", Volume: "200000"},
+			{Price: "98", Volume: "100000"},
+		},
+		Timestamp: fixedTime,
+	}
+
+	tests := []struct {
+		name      string
+		amount    string
+		book      *domain.OrderBook
+		want      *domain.ExecutionPrice
+		errorIs   error
+		errorText string
+	}{
+		{
+			name:   "Walks several levels",
+			amount: "250000",
+			book:   book,
+			want: &domain.ExecutionPrice{
+				Market:   "usdtrub",
+				Provider: ProviderGarantex,
+				Amount:   "250000",
+				Buy: domain.ExecutionQuote{
+					VWAP:        "100.4",
+					BestPrice:   "100",
+					WorstPrice:  "101",
+					SlippageBps: "40",
+				},
+				Sell: domain.ExecutionQuote{
+					VWAP:        "98.8",
+					BestPrice:   "99",
+					WorstPrice:  "98",
+					SlippageBps: "20.2020202",
+				},
+				Timestamp: fixedTime,
+			},
+		},
+		{
+			name:   "Filled by the best level",
+			amount: "1000.5",
+			book:   book,
+			want: &domain.ExecutionPrice{
+				Market:    "usdtrub",
+				Provider:  ProviderGarantex,
+				Amount:    "1000.5",
+				Buy:       domain.ExecutionQuote{VWAP: "100", BestPrice: "100", WorstPrice: "100", SlippageBps: "0"},
+				Sell:      domain.ExecutionQuote{VWAP: "99", BestPrice: "99", WorstPrice: "99", SlippageBps: "0"},
+				Timestamp: fixedTime,
+			},
+		},
+		{
+			name:      "Book is too thin",
+			amount:    "300000.5",
+			book:      book,
+			errorIs:   domain.ErrInsufficientLiquidity,
+			errorText: "failed to buy 300000.5 on usdtrub: insufficient liquidity: only 300000 of 300000.5 can be filled",
+		},
+		{
+			name:      "Non-positive amount",
+			amount:    "-1",
+			errorIs:   domain.ErrInvalidArgument,
+			errorText: `invalid argument: amount must be a positive number, got "-1"`,
+		},
+		{
+			name:      "Non-numeric amount",
+			amount:    "lots",
+			errorIs:   domain.ErrInvalidArgument,
+			errorText: `invalid argument: amount must be a positive number, got "lots"`,
+		},
+		{
+			name:   "Malformed volume",
+			amount: "1",
+			book: &domain.OrderBook{
+				Asks: []domain.PriceLevel{{Price: "100", Volume: ""}},
+				Bids: []domain.PriceLevel{{Price: "99", Volume: "1"}},
+			},
+			errorText: `failed to buy 1 on usdtrub: invalid volume "" in order book`,
+		},
+		{
+			name:   "Zero volume",
+			amount: "1",
+			book: &domain.OrderBook{
+				Asks: []domain.PriceLevel{{Price: "100", Volume: "1"}},
+				Bids: []domain.PriceLevel{{Price: "99", Volume: "0"}, {Price: "98", Volume: "1"}},
+			},
+			errorText: `failed to sell 1 on usdtrub: invalid volume "0" in order book`,
+		},
+		{
+			name:   "Negative volume",
+			amount: "1",
+			book: &domain.OrderBook{
+				Asks: []domain.PriceLevel{{Price: "100", Volume: "-2"}, {Price: "101", Volume: "1"}},
+				Bids: []domain.PriceLevel{{Price: "99", Volume: "1"}},
+			},
+			errorText: `failed to buy 1 on usdtrub: invalid volume "-2" in order book`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := new(MockOrderBookFetcher)
+			if tt.book != nil {
+				fetcher.On("FetchOrderBook", mock.Anything, "usdtrub").Return(tt.book, nil)
+			}
+
+			got, err := NewOrderBookService(fetcher).GetExecutionPrice(context.Background(), "", tt.amount)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				if tt.errorIs != nil {
+					assert.ErrorIs(t, err, tt.errorIs)
+				}
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			fetcher.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"final/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	partitionsCreated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_partitions_created_total",
+			Help: "Total number of partitions of the rates table created in advance",
+		},
+	)
+	partitionFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_partition_maintenance_failures_total",
+			Help: "Total number of failed creations of upcoming partitions",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(partitionsCreated, partitionFailures)
+}
+
+type RatePartitioner interface {
+	CreatePartitions(ctx context.Context, period domain.PartitionPeriod, from, to time.Time) ([]string, error)
+}
+
+// PartitionMaintainer creates the current and upcoming partitions of the rates table at the interval in the background,
+// so that saved rates always have a partition. Expired partitions are dropped by the RetentionJob
+// after their rates are rolled up.
+type PartitionMaintainer struct {
+	partitioner RatePartitioner
+	period      domain.PartitionPeriod
+	ahead       int
+	interval    time.Duration
+	now         func() time.Time
+	l           *zap.SugaredLogger
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewPartitionMaintainer creates a maintainer keeping ahead partitions of the period after the current one.
+func NewPartitionMaintainer(partitioner RatePartitioner, period domain.PartitionPeriod, ahead int, interval time.Duration, logger *zap.SugaredLogger) *PartitionMaintainer {
+	return &PartitionMaintainer{
+		partitioner: partitioner,
+		period:      period,
+		ahead:       ahead,
+		interval:    interval,
+		now:         time.Now,
+		l:           logger,
+	}
+}
+
+// Start creates partitions immediately and then at the interval until Stop is called or ctx is done.
+// Start after Stop does nothing.
+func (m *PartitionMaintainer) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped || m.cancel != nil {
+		return
+	}
+
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.loop(ctx)
+	}()
+
+	m.l.Infof("creating %d upcoming %s partitions of rates every %s", m.ahead, m.period, m.interval)
+}
+
+// Stop stops the maintainer and waits for the running creation to finish.
+func (m *PartitionMaintainer) Stop() {
+	m.mu.Lock()
+	m.stopped = true
+	cancel := m.cancel
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	m.wg.Wait()
+}
+
+func (m *PartitionMaintainer) loop(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run creates missing partitions from the current one up to ahead partitions after it.
+func (m *PartitionMaintainer) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, m.interval)
+	defer cancel()
+
+	now := m.now()
+	to := m.period.Start(now)
+	for i := 0; i <= m.ahead; i++ {
+		to = m.period.Next(to)
+	}
+
+	created, err := m.partitioner.CreatePartitions(ctx, m.period, now, to)
+	if err != nil {
+		partitionFailures.Inc()
+		if ctx.Err() == nil {
+			m.l.Errorf("failed to create partitions of rates: %v", err)
+		}
+		return
+	}
+
+	partitionsCreated.Add(float64(len(created)))
+
+	if len(created) > 0 {
+		m.l.Infof("created partitions of rates %v", created)
+	}
+}
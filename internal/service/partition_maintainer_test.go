@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type partitionerFunc func(ctx context.Context, period domain.PartitionPeriod, from, to time.Time) ([]string, error)
+
+func (f partitionerFunc) CreatePartitions(ctx context.Context, period domain.PartitionPeriod, from, to time.Time) ([]string, error) {
+	return f(ctx, period, from, to)
+}
+
+func TestPartitionMaintainer_Run(t *testing.T) {
+	now := time.Date(2026, 1, 31, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		period       domain.PartitionPeriod
+		created      []string
+		err          error
+		wantTo       time.Time
+		wantCreated  float64
+		wantFailures float64
+	}{
+		{
+			name:        "Upcoming days",
+			period:      domain.PartitionDay,
+			created:     []string{"Rate_p20260203"},
+			wantTo:      time.Date(2026, 2, 4, 0, 0, 0, 0, time.UTC),
+			wantCreated: 1,
+		},
+		{
+			name:   "Upcoming months",
+			period: domain.PartitionMonth,
+			wantTo: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "Failed creation",
+			period:       domain.PartitionDay,
+			err:          errors.New("db error"),
+			wantTo:       time.Date(2026, 2, 4, 0, 0, 0, 0, time.UTC),
+			wantFailures: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotFrom, gotTo time.Time
+
+			partitioner := partitionerFunc(func(ctx context.Context, period domain.PartitionPeriod, from, to time.Time) ([]string, error) {
+				assert.Equal(t, tt.period, period)
+				gotFrom, gotTo = from, to
+				return tt.created, tt.err
+			})
+
+			m := NewPartitionMaintainer(partitioner, tt.period, 3, time.Hour, zap.NewNop().Sugar())
+			m.now = func() time.Time { return now }
+
+			created := testutil.ToFloat64(partitionsCreated)
+			failures := testutil.ToFloat64(partitionFailures)
+
+			m.run(context.Background())
+
+			assert.Equal(t, now, gotFrom)
+			assert.Equal(t, tt.wantTo, gotTo)
+			assert.Equal(t, created+tt.wantCreated, testutil.ToFloat64(partitionsCreated))
+			assert.Equal(t, failures+tt.wantFailures, testutil.ToFloat64(partitionFailures))
+		})
+	}
+}
+
+func TestPartitionMaintainer_StartStop(t *testing.T) {
+	var calls atomic.Int32
+
+	partitioner := partitionerFunc(func(ctx context.Context, period domain.PartitionPeriod, from, to time.Time) ([]string, error) {
+		calls.Add(1)
+		return nil, nil
+	})
+
+	m := NewPartitionMaintainer(partitioner, domain.PartitionDay, 3, 10*time.Millisecond, zap.NewNop().Sugar())
+
+	m.Start(context.Background())
+
+	assert.Eventually(t, func() bool { return calls.Load() >= 2 }, time.Second, 5*time.Millisecond)
+
+	m.Stop()
+	stopped := calls.Load()
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load(), "no runs after stop")
+}
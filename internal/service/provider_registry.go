@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Names of the built-in rate providers.
+const (
+	ProviderGarantex = "garantex"
+	ProviderBinance  = "binance"
+	ProviderBybit    = "bybit"
+	ProviderKraken   = "kraken"
+)
+
+// ErrUnknownProvider is returned when a provider is not registered.
+var ErrUnknownProvider = errors.New("unknown provider")
+
+// Provider is a named rate fetcher.
+type Provider struct {
+	Name    string
+	Fetcher RateFetcher
+	// Books is nil if the provider does not serve order books
+	Books OrderBookFetcher
+}
+
+// ProviderRegistry holds rate fetchers keyed by provider name.
+type ProviderRegistry struct {
+	fetchers map[string]RateFetcher
+}
+
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{
+		fetchers: make(map[string]RateFetcher),
+	}
+}
+
+// NewDefaultProviderRegistry returns a registry with all built-in providers registered.
+// Requests of every provider are retried according to the policy.
+func NewDefaultProviderRegistry(retry RetryPolicy) *ProviderRegistry {
+	r := NewProviderRegistry()
+
+	r.Register(ProviderGarantex, NewGarantexFetcher(retry))
+	r.Register(ProviderBinance, NewBinanceFetcher(retry))
+	r.Register(ProviderBybit, NewBybitFetcher(retry))
+	r.Register(ProviderKraken, NewKrakenFetcher(retry))
+
+	return r
+}
+
+// Register adds the fetcher under the name, replacing the previous one if any.
+func (r *ProviderRegistry) Register(name string, fetcher RateFetcher) {
+	r.fetchers[name] = fetcher
+}
+
+// Get returns the fetcher registered under the name.
+// Returns ErrUnknownProvider if there is no such provider.
+func (r *ProviderRegistry) Get(name string) (RateFetcher, error) {
+	fetcher, ok := r.fetchers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+
+	return fetcher, nil
+}
+
+// Names returns sorted names of all registered providers.
+func (r *ProviderRegistry) Names() []string {
+	names := make([]string, 0, len(r.fetchers))
+	for name := range r.fetchers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Select returns providers with the given names in the same order.
+// Returns an error if names are empty, duplicated or not registered.
+func (r *ProviderRegistry) Select(names []string) ([]Provider, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no providers selected")
+	}
+
+	providers := make([]Provider, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("provider %q selected more than once", name)
+		}
+		seen[name] = struct{}{}
+
+		fetcher, err := r.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		books, _ := fetcher.(OrderBookFetcher)
+
+		providers = append(providers, Provider{Name: name, Fetcher: fetcher, Books: books})
+	}
+
+	return providers, nil
+}
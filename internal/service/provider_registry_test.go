@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDefaultProviderRegistry(t *testing.T) {
+	r := NewDefaultProviderRegistry(RetryPolicy{})
+
+	assert.Equal(t, []string{ProviderBinance, ProviderBybit, ProviderGarantex, ProviderKraken}, r.Names())
+}
+
+func TestProviderRegistry_Get(t *testing.T) {
+	r := NewProviderRegistry()
+	fetcher := new(MockRateFetcher)
+	r.Register("mock", fetcher)
+
+	got, err := r.Get("mock")
+	assert.NoError(t, err)
+	assert.Same(t, fetcher, got)
+
+	got, err = r.Get("missing")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+	assert.Nil(t, got)
+}
+
+func TestProviderRegistry_Select(t *testing.T) {
+	first := new(MockRateFetcher)
+	second := new(MockRateFetcher)
+
+	r := NewProviderRegistry()
+	r.Register("first", first)
+	r.Register("second", second)
+
+	tests := []struct {
+		name      string
+		names     []string
+		want      []Provider
+		errorText string
+	}{
+		{
+			name:  "Keeps the requested order",
+			names: []string{"second", "first"},
+			want: []Provider{
+				{Name: "second", Fetcher: second},
+				{Name: "first", Fetcher: first},
+			},
+		},
+		{
+			name:      "No providers",
+			names:     nil,
+			errorText: "no providers selected",
+		},
+		{
+			name:      "Unknown provider",
+			names:     []string{"first", "third"},
+			errorText: `unknown provider: "third"`,
+		},
+		{
+			name:      "Duplicated provider",
+			names:     []string{"first", "first"},
+			errorText: `provider "first" selected more than once`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Select(tt.names)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProviderRegistry_SelectOrderBooks(t *testing.T) {
+	got, err := NewDefaultProviderRegistry(RetryPolicy{}).Select([]string{ProviderBinance, ProviderGarantex})
+
+	if assert.NoError(t, err) && assert.Len(t, got, 2) {
+		assert.Nil(t, got[0].Books)
+		assert.NotNil(t, got[1].Books)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"final/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheLoadTimeout bounds a load shared by coalesced callers, it does not depend on the caller which started it.
+const cacheLoadTimeout = 30 * time.Second
+
+var (
+	cacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_cache_hits_total",
+			Help: "Total number of rate requests served from the cache",
+		},
+		[]string{"market"},
+	)
+	cacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_cache_misses_total",
+			Help: "Total number of rate requests which had to wait for a fetch",
+		},
+		[]string{"market"},
+	)
+	cacheCoalesced = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_cache_coalesced_total",
+			Help: "Total number of cache misses which shared a fetch started by a concurrent request",
+		},
+		[]string{"market"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(cacheHits, cacheMisses, cacheCoalesced)
+}
+
+// RateLoader fetches the current rate of the market on a cache miss.
+type RateLoader func(ctx context.Context, market string) (*domain.Rate, error)
+
+// RateCache keeps the latest rate of every market in memory for ttl.
+// Concurrent misses of the same market are coalesced into a single load.
+// Rates are put into the cache by loads and by the poller, so a stalled poller does not serve outdated rates.
+type RateCache struct {
+	ttl   time.Duration
+	now   func() time.Time
+	group singleflight.Group
+
+	mu    sync.RWMutex
+	rates map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	rate      *domain.Rate
+	updatedAt time.Time
+}
+
+func NewRateCache(ttl time.Duration) *RateCache {
+	return &RateCache{
+		ttl:   ttl,
+		now:   time.Now,
+		rates: make(map[string]cacheEntry),
+	}
+}
+
+// Get returns the cached rate of the market if it is not older than maxAge, ttl is used if maxAge is zero or exceeds it.
+// Otherwise the rate is loaded and cached, callers which miss while the load is in flight wait for its result.
+// The load is detached from cancellation of ctx, so a cancelled caller does not fail the others.
+func (c *RateCache) Get(ctx context.Context, market string, maxAge time.Duration, load RateLoader) (*domain.Rate, error) {
+	// clients may require fresher rates but not older than the ttl
+	if maxAge <= 0 {
+		maxAge = c.ttl
+	} else {
+		maxAge = min(maxAge, c.ttl)
+	}
+
+	if rate, ok := c.lookup(market, maxAge); ok {
+		cacheHits.WithLabelValues(market).Inc()
+		return rate, nil
+	}
+
+	cacheMisses.WithLabelValues(market).Inc()
+
+	// leader is set only by the caller whose function is run, the others share its result
+	leader := false
+
+	result := c.group.DoChan(market, func() (any, error) {
+		leader = true
+
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheLoadTimeout)
+		defer cancel()
+
+		rate, err := load(loadCtx, market)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Set(rate)
+
+		return rate, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if !leader {
+			cacheCoalesced.WithLabelValues(market).Inc()
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*domain.Rate), nil
+	}
+}
+
+// Set replaces the latest rate of the rate market.
+func (c *RateCache) Set(rate *domain.Rate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rates[rate.Market] = cacheEntry{rate: rate, updatedAt: c.now()}
+}
+
+func (c *RateCache) lookup(market string, maxAge time.Duration) (*domain.Rate, bool) {
+	c.mu.RLock()
+	entry, ok := c.rates[market]
+	c.mu.RUnlock()
+
+	if !ok || c.now().Sub(entry.updatedAt) > maxAge {
+		return nil, false
+	}
+
+	return entry.rate, true
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateCache_TTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	c := NewRateCache(30 * time.Second)
+	c.now = func() time.Time { return now }
+
+	rate := &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5")}
+	loaded := &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("101.5"), Bid: domain.MustParseDecimal("100.5")}
+
+	var loads int
+	load := func(ctx context.Context, market string) (*domain.Rate, error) {
+		loads++
+		return loaded, nil
+	}
+
+	get := func(maxAge time.Duration) *domain.Rate {
+		got, err := c.Get(context.Background(), "usdtrub", maxAge, load)
+		assert.NoError(t, err)
+		return got
+	}
+
+	c.Set(rate)
+
+	now = now.Add(30 * time.Second)
+	assert.Same(t, rate, get(0), "rate of ttl age")
+	assert.Same(t, rate, get(time.Minute), "client accepts older rate within ttl")
+	assert.Equal(t, 0, loads)
+
+	assert.Same(t, loaded, get(10*time.Second), "client requires fresher rate")
+	assert.Equal(t, 1, loads)
+
+	now = now.Add(31 * time.Second)
+	assert.Same(t, loaded, get(0), "outdated rate is loaded again")
+	assert.Equal(t, 2, loads)
+
+	now = now.Add(31 * time.Second)
+	assert.Same(t, loaded, get(time.Hour), "client max age is capped by ttl")
+	assert.Equal(t, 3, loads)
+
+	_, ok := c.lookup("btcrub", time.Minute)
+	assert.False(t, ok, "other market")
+}
+
+func TestRateCache_CoalescesConcurrentMisses(t *testing.T) {
+	c := NewRateCache(time.Minute)
+	rate := &domain.Rate{Market: "ethrub", Ask: domain.MustParseDecimal("200000"), Bid: domain.MustParseDecimal("199000")}
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context, market string) (*domain.Rate, error) {
+		loads.Add(1)
+		<-release
+		return rate, nil
+	}
+
+	hits := testutil.ToFloat64(cacheHits.WithLabelValues("ethrub"))
+	misses := testutil.ToFloat64(cacheMisses.WithLabelValues("ethrub"))
+	coalesced := testutil.ToFloat64(cacheCoalesced.WithLabelValues("ethrub"))
+
+	const callers = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := c.Get(context.Background(), "ethrub", 0, load)
+			assert.NoError(t, err)
+			assert.Same(t, rate, got)
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(cacheMisses.WithLabelValues("ethrub")) == misses+callers
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+	assert.Equal(t, float64(callers-1), testutil.ToFloat64(cacheCoalesced.WithLabelValues("ethrub"))-coalesced)
+
+	_, err := c.Get(context.Background(), "ethrub", 0, load)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(cacheHits.WithLabelValues("ethrub"))-hits)
+}
+
+func TestRateCache_LoadErrorIsNotCached(t *testing.T) {
+	c := NewRateCache(time.Minute)
+	rate := &domain.Rate{Market: "btcrub", Ask: domain.MustParseDecimal("100"), Bid: domain.MustParseDecimal("99")}
+	loadErr := errors.New("fetch error")
+
+	_, err := c.Get(context.Background(), "btcrub", 0, func(ctx context.Context, market string) (*domain.Rate, error) {
+		return nil, loadErr
+	})
+	assert.ErrorIs(t, err, loadErr)
+
+	got, err := c.Get(context.Background(), "btcrub", 0, func(ctx context.Context, market string) (*domain.Rate, error) {
+		return rate, nil
+	})
+	assert.NoError(t, err)
+	assert.Same(t, rate, got)
+}
+
+func TestRateCache_CancelledCallerDoesNotFailOthers(t *testing.T) {
+	c := NewRateCache(time.Minute)
+	rate := &domain.Rate{Market: "usdcrub", Ask: domain.MustParseDecimal("100"), Bid: domain.MustParseDecimal("99")}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	load := func(ctx context.Context, market string) (*domain.Rate, error) {
+		close(started)
+		select {
+		case <-release:
+			return rate, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error)
+	go func() {
+		_, err := c.Get(ctx, "usdcrub", 0, load)
+		leader <- err
+	}()
+	<-started
+
+	follower := make(chan *domain.Rate)
+	go func() {
+		got, _ := c.Get(context.Background(), "usdcrub", 0, load)
+		follower <- got
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-leader, context.Canceled)
+
+	close(release)
+	assert.Same(t, rate, <-follower)
+}
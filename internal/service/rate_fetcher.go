@@ -2,18 +2,31 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"final/internal/domain"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
 const (
-	GarantexApiUrl = "https://garantex.org/api/v2/depth?market=usdtrub"
+	GarantexApiUrl = "https://garantex.org/api/v2/depth"
 )
 
+// garantexMarkets is the set of markets which can be requested from garantex.
+var garantexMarkets = map[string]struct{}{
+	"usdtrub":  {},
+	"btcrub":   {},
+	"ethrub":   {},
+	"usdcrub":  {},
+	"btcusdt":  {},
+	"ethusdt":  {},
+	"usdcusdt": {},
+	"ethbtc":   {},
+}
+
 type GarantexAPIResponse struct {
 	Asks      []PriceInstance `json:"asks"`
 	Bids      []PriceInstance `json:"bids"`
@@ -21,14 +34,18 @@ type GarantexAPIResponse struct {
 }
 
 type PriceInstance struct {
-	Price string `json:"price"`
+	Price  string `json:"price"`
+	Volume string `json:"volume"`
+	Amount string `json:"amount"`
+	Factor string `json:"factor"`
 }
 
 type GarantexFetcher struct {
 	client *http.Client
+	retry  RetryPolicy
 }
 
-func NewGarantexFetcher() *GarantexFetcher {
+func NewGarantexFetcher(retry RetryPolicy) *GarantexFetcher {
 
 	c := &http.Client{
 		Timeout: 5 * time.Second,
@@ -36,44 +53,100 @@ func NewGarantexFetcher() *GarantexFetcher {
 
 	return &GarantexFetcher{
 		client: c,
+		retry:  retry,
 	}
 }
 
-func (r GarantexFetcher) FetchRate(ctx context.Context) (*domain.Rate, error) {
+// FetchRate requests the top of the order book for the given market.
+// Returns domain.ErrUnknownMarket if the market is not traded on garantex.
+func (r GarantexFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	rate, _, err := r.FetchQuote(ctx, market)
+	return rate, err
+}
+
+// FetchQuote requests the top of the order book for the given market and returns it as received along with the rate.
+func (r GarantexFetcher) FetchQuote(ctx context.Context, market string) (*domain.Rate, *RawQuote, error) {
+	start := time.Now()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, GarantexApiUrl, nil)
+	apiResponse, err := r.fetchDepth(ctx, market)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, err
+	}
+
+	receivedAt := time.Now()
+
+	rate := &domain.Rate{
+		Market:     market,
+		Provider:   ProviderGarantex,
+		Timestamp:  time.Unix(int64(apiResponse.Timestamp), 0),
+		ReceivedAt: receivedAt,
+		Latency:    receivedAt.Sub(start),
+	}
+
+	ask, bid := apiResponse.Asks[0], apiResponse.Bids[0]
+	raw := &RawQuote{
+		AskPrice:  ask.Price,
+		AskVolume: ask.Volume,
+		BidPrice:  bid.Price,
+		BidVolume: bid.Volume,
+		Timestamp: strconv.Itoa(apiResponse.Timestamp),
 	}
 
-	resp, err := r.client.Do(req)
+	if err := parseTopOfBook(rate, ask.Price, ask.Volume, bid.Price, bid.Volume); err != nil {
+		return nil, raw, err
+	}
+
+	return rate, raw, nil
+}
+
+// FetchOrderBook requests all levels of the order book for the given market.
+// Returns domain.ErrUnknownMarket if the market is not traded on garantex.
+func (r GarantexFetcher) FetchOrderBook(ctx context.Context, market string) (*domain.OrderBook, error) {
 
+	apiResponse, err := r.fetchDepth(ctx, market)
 	if err != nil {
-		return nil, fmt.Errorf("failed to do request: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	return &domain.OrderBook{
+		Market:    market,
+		Provider:  ProviderGarantex,
+		Asks:      toPriceLevels(apiResponse.Asks),
+		Bids:      toPriceLevels(apiResponse.Bids),
+		Timestamp: time.Unix(int64(apiResponse.Timestamp), 0),
+	}, nil
+}
+
+// fetchDepth requests the depth endpoint and checks that both sides of the book are not empty.
+func (r GarantexFetcher) fetchDepth(ctx context.Context, market string) (*GarantexAPIResponse, error) {
+
+	if _, ok := garantexMarkets[market]; !ok {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, market)
 	}
 
 	var apiResponse GarantexAPIResponse
 
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := getJSON(ctx, r.client, r.retry, GarantexApiUrl+"?market="+url.QueryEscape(market), &apiResponse); err != nil {
+		return nil, err
 	}
 
-	resp.Body.Close()
-
 	if len(apiResponse.Asks) == 0 || len(apiResponse.Bids) == 0 {
 		return nil, errors.New("not enough data in garantex API response")
 	}
 
-	ask := apiResponse.Asks[0].Price
-	bid := apiResponse.Bids[0].Price
+	return &apiResponse, nil
+}
 
-	return &domain.Rate{
-		Ask:       ask,
-		Bid:       bid,
-		Timestamp: time.Unix(int64(apiResponse.Timestamp), 0),
-	}, nil
+func toPriceLevels(instances []PriceInstance) []domain.PriceLevel {
+	levels := make([]domain.PriceLevel, len(instances))
+	for i, p := range instances {
+		levels[i] = domain.PriceLevel{
+			Price:  p.Price,
+			Volume: p.Volume,
+			Amount: p.Amount,
+			Factor: p.Factor,
+		}
+	}
+
+	return levels
 }
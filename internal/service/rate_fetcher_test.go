@@ -33,13 +33,17 @@ func TestNewGarantexFetcher(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NewGarantexFetcher()
+			retry := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+			got := NewGarantexFetcher(retry)
 			if got.client == nil {
 				t.Errorf("NewGarantexFetcher() client = nil, want non-nil *http.Client")
 			}
 			if got.client.Timeout != 5*time.Second {
 				t.Errorf("NewGarantexFetcher() client timeout = %v, want %v", got.client.Timeout, 5*time.Second)
 			}
+			if got.retry != retry {
+				t.Errorf("NewGarantexFetcher() retry = %v, want %v", got.retry, retry)
+			}
 		})
 	}
 }
@@ -52,7 +56,8 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 		client *http.Client
 	}
 	type args struct {
-		ctx context.Context
+		ctx    context.Context
+		market string
 	}
 	tests := []struct {
 		name      string
@@ -72,8 +77,8 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 						mockResponse: &http.Response{
 							StatusCode: http.StatusOK,
 							Body: io.NopCloser(bytes.NewBufferString(`{
-								"asks": [{"price": "100.5"}],
-								"bids": [{"price": "99.5"}],
+								"asks": [{"price": "100.5", "volume": "12.5"}],
+								"bids": [{"price": "99.5", "volume": "3.0"}],
 								"timestamp": 1700000000
 							}`)),
 							Header: make(http.Header),
@@ -83,11 +88,16 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 				},
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx:    context.Background(),
+				market: "usdtrub",
 			},
 			want: &domain.Rate{
-				Ask:       "100.5",
-				Bid:       "99.5",
+				Market:    "usdtrub",
+				Provider:  ProviderGarantex,
+				Ask:       domain.MustParseDecimal("100.5"),
+				Bid:       domain.MustParseDecimal("99.5"),
+				AskVolume: domain.MustParseDecimal("12.5"),
+				BidVolume: domain.MustParseDecimal("3.0"),
 				Timestamp: fixedTime,
 			},
 			wantErr: false,
@@ -103,7 +113,8 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 				},
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx:    context.Background(),
+				market: "usdtrub",
 			},
 			want:      nil,
 			wantErr:   true,
@@ -124,7 +135,8 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 				},
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx:    context.Background(),
+				market: "usdtrub",
 			},
 			want:      nil,
 			wantErr:   true,
@@ -149,7 +161,8 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 				},
 			},
 			args: args{
-				ctx: nil, // error due to invalid context
+				ctx:    nil, // error due to invalid context
+				market: "usdtrub",
 			},
 			want:      nil,
 			wantErr:   true,
@@ -174,7 +187,8 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 				},
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx:    context.Background(),
+				market: "usdtrub",
 			},
 			want:      nil,
 			wantErr:   true,
@@ -199,12 +213,31 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 				},
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx:    context.Background(),
+				market: "usdtrub",
 			},
 			want:      nil,
 			wantErr:   true,
 			errorText: "not enough data in garantex API response",
 		},
+		{
+			name: "Unknown market",
+			fields: fields{
+				client: &http.Client{
+					Transport: &mockRoundTripper{
+						mockResponse: nil,
+						mockError:    errors.New("request must not be sent"),
+					},
+				},
+			},
+			args: args{
+				ctx:    context.Background(),
+				market: "usdtxyz",
+			},
+			want:      nil,
+			wantErr:   true,
+			errorText: "unknown market",
+		},
 		{
 			name: "Non-200 HTTP response",
 			fields: fields{
@@ -220,7 +253,8 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 				},
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx:    context.Background(),
+				market: "usdtrub",
 			},
 			want:    &domain.Rate{},
 			wantErr: true,
@@ -239,7 +273,7 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 				}
 			}
 
-			got, err := fetcher.FetchRate(tt.args.ctx)
+			got, err := fetcher.FetchRate(tt.args.ctx, tt.args.market)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("FetchRate() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -249,6 +283,12 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 					t.Errorf("FetchRate() error = %v, expected to contain %v", err, tt.errorText)
 				}
 			}
+			if !tt.wantErr {
+				if got.ReceivedAt.IsZero() || got.Latency < 0 {
+					t.Errorf("FetchRate() got ReceivedAt = %v, Latency = %v", got.ReceivedAt, got.Latency)
+				}
+				got.ReceivedAt, got.Latency = time.Time{}, 0
+			}
 			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("FetchRate() got = %v, want %v", got, tt.want)
 			}
@@ -256,6 +296,83 @@ func TestGarantexFetcher_FetchRate(t *testing.T) {
 	}
 }
 
+func TestGarantexFetcher_FetchOrderBook(t *testing.T) {
+	tests := []struct {
+		name      string
+		market    string
+		body      string
+		want      *domain.OrderBook
+		errorText string
+	}{
+		{
+			name:   "Successful fetch keeps volumes",
+			market: "usdtrub",
+			body: `{
+				"timestamp": 1700000000,
+				"asks": [
+					{"price": "100.5", "volume": "1500.0", "amount": "150750.0", "factor": "0.01", "type": "limit"},
+					{"price": "100.6", "volume": "20.0", "amount": "2012.0", "factor": "0.011", "type": "limit"}
+				],
+				"bids": [{"price": "99.5", "volume": "300.0", "amount": "29850.0", "factor": "-0.01", "type": "limit"}]
+			}`,
+			want: &domain.OrderBook{
+				Market:   "usdtrub",
+				Provider: ProviderGarantex,
+				Asks: []domain.PriceLevel{
+					{Price: "100.5", Volume: "1500.0", Amount: "150750.0", Factor: "0.01"},
+					{Price: "100.6", Volume: "20.0", Amount: "2012.0", Factor: "0.011"},
+				},
+				Bids: []domain.PriceLevel{
+					{Price: "99.5", Volume: "300.0", Amount: "29850.0", Factor: "-0.01"},
+				},
+				Timestamp: time.Unix(1700000000, 0),
+			},
+		},
+		{
+			name:      "Unknown market",
+			market:    "usdtxyz",
+			errorText: "unknown market",
+		},
+		{
+			name:      "Empty bids in response",
+			market:    "usdtrub",
+			body:      `{"asks": [{"price": "100.5", "volume": "1"}], "bids": [], "timestamp": 1700000000}`,
+			errorText: "not enough data in garantex API response",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := GarantexFetcher{
+				client: &http.Client{
+					Transport: &mockRoundTripper{
+						mockResponse: &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(tt.body)),
+							Header:     make(http.Header),
+						},
+					},
+				},
+			}
+
+			got, err := fetcher.FetchOrderBook(context.Background(), tt.market)
+
+			if tt.errorText != "" {
+				if err == nil || !contains(err.Error(), tt.errorText) {
+					t.Errorf("FetchOrderBook() error = %v, expected to contain %v", err, tt.errorText)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("FetchOrderBook() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FetchOrderBook() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // Helper function to check if a substring exists within a string.
 func contains(s, substr string) bool {
 	return bytes.Contains([]byte(s), []byte(substr))
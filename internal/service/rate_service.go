@@ -2,45 +2,273 @@ package service
 
 import (
 	"context"
+	"errors"
 	"final/internal/domain"
 	"fmt"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
-func NewRateService(repo RateSaver, fetcher RateFetcher, logger *zap.SugaredLogger) *RateService {
+var rateDuplicates = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "rate_duplicates_suppressed_total",
+		Help: "Total number of observed rates not saved because a rate of the same market, provider and timestamp is saved",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(rateDuplicates)
+}
+
+// Page sizes of the rate history.
+const (
+	DefaultHistoryPageSize = 100
+	MaxHistoryPageSize     = 1000
+)
+
+// MaxCandles is a max number of candles in a time range of a candles query.
+const MaxCandles = 5000
+
+// CandleIntervals are the supported candle intervals, each of them divides a day.
+var CandleIntervals = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	4 * time.Hour,
+	24 * time.Hour,
+}
+
+// NewRateService creates a rate service.
+// guard holds suspicious price moves of fetched rates, nil disables it.
+// maxStaleness is a max age of the last saved rate served when fetching fails, zero disables the fallback.
+func NewRateService(repo RateStore, fetcher RateFetcher, cache *RateCache, guard *JumpGuard, maxStaleness time.Duration, logger *zap.SugaredLogger) *RateService {
 	return &RateService{
-		repo:    repo,
-		fetcher: fetcher,
-		l:       logger,
+		repo:         repo,
+		fetcher:      fetcher,
+		cache:        cache,
+		guard:        guard,
+		maxStaleness: maxStaleness,
+		now:          time.Now,
+		l:            logger,
 	}
 }
 
 type RateService struct {
-	repo    RateSaver
-	fetcher RateFetcher
-	l       *zap.SugaredLogger
+	repo         RateStore
+	fetcher      RateFetcher
+	cache        *RateCache
+	guard        *JumpGuard
+	maxStaleness time.Duration
+	now          func() time.Time
+	l            *zap.SugaredLogger
 }
 
 type RateSaver interface {
-	SaveRate(ctx context.Context, rate *domain.Rate) error
+	// SaveRate returns false if a rate of the same market, provider and timestamp is already saved.
+	SaveRate(ctx context.Context, rate *domain.Rate) (bool, error)
+}
+
+// RateStore saves rates and reads the last saved one.
+type RateStore interface {
+	RateSaver
+	// GetLatestRate returns domain.ErrRateNotFound if no rate of the market has been saved.
+	GetLatestRate(ctx context.Context, market string) (*domain.Rate, error)
+	// GetRateHistory returns domain.ErrInvalidArgument if the query cursor is malformed.
+	GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error)
+	// GetCandles returns candles of intervals with saved rates only.
+	GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error)
 }
 
 type RateFetcher interface {
-	FetchRate(ctx context.Context) (*domain.Rate, error)
+	FetchRate(ctx context.Context, market string) (*domain.Rate, error)
+}
+
+// GetRate returns the cached rate of the market if it is not older than maxAge, cache ttl is used if maxAge is zero or exceeds it.
+// Otherwise the rate is fetched and saved once for all concurrent callers.
+// If fetching fails, the last saved rate not older than maxStaleness is returned marked as stale.
+// Empty market is treated as domain.DefaultMarket.
+func (r *RateService) GetRate(ctx context.Context, market string, maxAge time.Duration) (*domain.Rate, error) {
+	if market == "" {
+		market = domain.DefaultMarket
+	}
+
+	rate, err := r.cache.Get(ctx, market, maxAge, r.fetchAndSave)
+	if err != nil {
+		return r.lastSavedRate(ctx, market, err)
+	}
+
+	return rate, nil
+}
+
+// GetRateHistory returns a page of saved rates of the market in time order.
+// Empty market is treated as domain.DefaultMarket, zero To as now and zero Limit as DefaultHistoryPageSize.
+func (r *RateService) GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error) {
+	if q.Market == "" {
+		q.Market = domain.DefaultMarket
+	}
+
+	if q.To.IsZero() {
+		q.To = r.now()
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultHistoryPageSize
+	}
+
+	if q.Limit < 0 || q.Limit > MaxHistoryPageSize {
+		return nil, fmt.Errorf("%w: page size must be between 1 and %d, got %d", domain.ErrInvalidArgument, MaxHistoryPageSize, q.Limit)
+	}
+
+	if !q.From.Before(q.To) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidArgument)
+	}
+
+	page, err := r.repo.GetRateHistory(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate history: %w", err)
+	}
+
+	return page, nil
+}
+
+// GetCandles returns candles of the market in order of start time.
+// If FillGaps is set, intervals without rates after the first candle are filled with the previous close,
+// intervals before the first candle are skipped as there is no price to repeat.
+// Empty market is treated as domain.DefaultMarket and zero To as now.
+func (r *RateService) GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error) {
+	if q.Market == "" {
+		q.Market = domain.DefaultMarket
+	}
+
+	if q.To.IsZero() {
+		q.To = r.now()
+	}
+
+	if !slices.Contains(CandleIntervals, q.Interval) {
+		return nil, fmt.Errorf("%w: unsupported candle interval %s", domain.ErrInvalidArgument, q.Interval)
+	}
+
+	if !q.From.Before(q.To) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidArgument)
+	}
+
+	// candles are aligned, so the first one may start before From
+	if n := (q.To.Sub(q.Start()) + q.Interval - 1) / q.Interval; n > MaxCandles {
+		return nil, fmt.Errorf("%w: time range spans more than %d candles of %s", domain.ErrInvalidArgument, MaxCandles, q.Interval)
+	}
+
+	candles, err := r.repo.GetCandles(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candles: %w", err)
+	}
+
+	if q.FillGaps {
+		candles = fillGaps(candles, q.Interval, q.To)
+	}
+
+	return candles, nil
+}
+
+// fillGaps inserts flat candles of the previous close into intervals without rates up to the end of the range.
+func fillGaps(candles []domain.Candle, interval time.Duration, to time.Time) []domain.Candle {
+	if len(candles) == 0 {
+		return candles
+	}
+
+	filled := make([]domain.Candle, 0, len(candles))
+
+	appendGaps := func(until time.Time) {
+		prev := filled[len(filled)-1]
+		for start := prev.Start.Add(interval); start.Before(until); start = start.Add(interval) {
+			filled = append(filled, domain.Candle{
+				Start:  start,
+				Mid:    prev.Mid.Flat(),
+				Ask:    prev.Ask.Flat(),
+				Bid:    prev.Bid.Flat(),
+				Filled: true,
+			})
+		}
+	}
+
+	for i, c := range candles {
+		if i > 0 {
+			appendGaps(c.Start)
+		}
+		filled = append(filled, c)
+	}
+
+	appendGaps(to)
+
+	return filled
+}
+
+// RefreshRate fetches and saves the current rate of the market and puts it into the cache.
+func (r *RateService) RefreshRate(ctx context.Context, market string) (*domain.Rate, error) {
+	rate, err := r.fetchAndSave(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.Set(rate)
+
+	return rate, nil
 }
 
-func (r *RateService) GetRate(ctx context.Context) (*domain.Rate, error) {
-	currentRate, err := r.fetcher.FetchRate(ctx)
+// fetchAndSave fetches the current rate and saves it unless the guard holds it.
+// The previous accepted rate, which is already saved, is returned while the fetched one is held.
+func (r *RateService) fetchAndSave(ctx context.Context, market string) (*domain.Rate, error) {
+	currentRate, err := r.fetcher.FetchRate(ctx, market)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch rate: %w", err)
 	}
 
-	err = r.repo.SaveRate(ctx, currentRate)
+	if r.guard != nil {
+		served, accepted := r.guard.Observe(currentRate)
+		if !accepted {
+			return served, nil
+		}
+	}
+
+	saved, err := r.repo.SaveRate(ctx, currentRate)
 
 	if err != nil {
 		r.l.Errorf("failed to save rate: %v", err)
+	} else if !saved {
+		rateDuplicates.Inc()
 	}
 
 	return currentRate, nil
 }
+
+// lastSavedRate returns the last saved rate of the market marked as stale if it is not older than maxStaleness.
+// Otherwise fetchErr is returned, so unknown markets and cancelled requests are not served from the database.
+func (r *RateService) lastSavedRate(ctx context.Context, market string, fetchErr error) (*domain.Rate, error) {
+	if r.maxStaleness <= 0 || errors.Is(fetchErr, domain.ErrUnknownMarket) || ctx.Err() != nil {
+		return nil, fetchErr
+	}
+
+	rate, err := r.repo.GetLatestRate(ctx, market)
+	if err != nil {
+		if !errors.Is(err, domain.ErrRateNotFound) {
+			r.l.Errorf("failed to get latest rate: %v", err)
+		}
+		return nil, fetchErr
+	}
+
+	age := r.now().Sub(rate.Timestamp)
+	if age > r.maxStaleness {
+		return nil, fmt.Errorf("%w (last saved rate is %s old)", fetchErr, age.Round(time.Second))
+	}
+
+	r.l.Warnf("serving stale rate of %s saved %s ago: %v", market, age.Round(time.Second), fetchErr)
+
+	rate.Stale = true
+
+	return rate, nil
+}
@@ -4,22 +4,48 @@ import (
 	"context"
 	"errors"
 	"final/internal/domain"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
 
-// MockRateSaver is a mock implementation of the RateSaver interface.
-type MockRateSaver struct {
+// MockRateStore is a mock implementation of the RateStore interface.
+type MockRateStore struct {
 	mock.Mock
 }
 
-func (m *MockRateSaver) SaveRate(ctx context.Context, rate *domain.Rate) error {
+func (m *MockRateStore) SaveRate(ctx context.Context, rate *domain.Rate) (bool, error) {
 	args := m.Called(ctx, rate)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRateStore) GetLatestRate(ctx context.Context, market string) (*domain.Rate, error) {
+	args := m.Called(ctx, market)
+	if rate, ok := args.Get(0).(*domain.Rate); ok {
+		return rate, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRateStore) GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error) {
+	args := m.Called(ctx, q)
+	if page, ok := args.Get(0).(*domain.RateHistoryPage); ok {
+		return page, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRateStore) GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error) {
+	args := m.Called(ctx, q)
+	if candles, ok := args.Get(0).([]domain.Candle); ok {
+		return candles, args.Error(1)
+	}
+	return nil, args.Error(1)
 }
 
 // MockRateFetcher is a mock implementation of the RateFetcher interface.
@@ -27,8 +53,8 @@ type MockRateFetcher struct {
 	mock.Mock
 }
 
-func (m *MockRateFetcher) FetchRate(ctx context.Context) (*domain.Rate, error) {
-	args := m.Called(ctx)
+func (m *MockRateFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	args := m.Called(ctx, market)
 	if rate, ok := args.Get(0).(*domain.Rate); ok {
 		return rate, args.Error(1)
 	}
@@ -40,56 +66,81 @@ func TestRateService_GetRate(t *testing.T) {
 
 	sugaredLogger := logger.Sugar()
 
-	mockSaver := new(MockRateSaver)
+	mockSaver := new(MockRateStore)
 	mockFetcher := new(MockRateFetcher)
 
-	service := NewRateService(mockSaver, mockFetcher, sugaredLogger)
-
 	fixedTime := time.Unix(1700000000, 0)
 	rate := &domain.Rate{
-		Ask:       "100.5",
-		Bid:       "99.5",
+		Market:    "usdtrub",
+		Ask:       domain.MustParseDecimal("100.5"),
+		Bid:       domain.MustParseDecimal("99.5"),
 		Timestamp: fixedTime,
 	}
 
 	tests := []struct {
 		name          string
+		market        string
 		fetcherMock   func()
 		saverMock     func()
 		expectedRate  *domain.Rate
 		expectedError error
 	}{
 		{
-			name: "Successful fetch and save",
+			name:   "Successful fetch and save",
+			market: "usdtrub",
 			fetcherMock: func() {
-				mockFetcher.On("FetchRate", mock.Anything).Return(rate, nil)
+				mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(rate, nil)
 			},
 			saverMock: func() {
-				mockSaver.On("SaveRate", mock.Anything, rate).Return(nil)
+				mockSaver.On("SaveRate", mock.Anything, rate).Return(true, nil)
 			},
 			expectedRate:  rate,
 			expectedError: nil,
 		},
 		{
-			name: "Fetcher returns error",
+			name:   "Fetcher returns error",
+			market: "usdtrub",
 			fetcherMock: func() {
-				mockFetcher.On("FetchRate", mock.Anything).Return((*domain.Rate)(nil), errors.New("fetch error"))
+				mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return((*domain.Rate)(nil), errors.New("fetch error"))
 			},
 			saverMock:     func() {},
 			expectedRate:  nil,
 			expectedError: errors.New("failed to fetch rate: fetch error"),
 		},
 		{
-			name: "Saver returns error",
+			name:   "Saver returns error",
+			market: "usdtrub",
 			fetcherMock: func() {
-				mockFetcher.On("FetchRate", mock.Anything).Return(rate, nil)
+				mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(rate, nil)
 			},
 			saverMock: func() {
-				mockSaver.On("SaveRate", mock.Anything, rate).Return(errors.New("save error"))
+				mockSaver.On("SaveRate", mock.Anything, rate).Return(false, errors.New("save error"))
 			},
 			expectedRate:  rate,
 			expectedError: nil,
 		},
+		{
+			name:   "Empty market falls back to default market",
+			market: "",
+			fetcherMock: func() {
+				mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(rate, nil)
+			},
+			saverMock: func() {
+				mockSaver.On("SaveRate", mock.Anything, rate).Return(true, nil)
+			},
+			expectedRate:  rate,
+			expectedError: nil,
+		},
+		{
+			name:   "Unknown market",
+			market: "usdtxyz",
+			fetcherMock: func() {
+				mockFetcher.On("FetchRate", mock.Anything, "usdtxyz").Return((*domain.Rate)(nil), fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "usdtxyz"))
+			},
+			saverMock:     func() {},
+			expectedRate:  nil,
+			expectedError: errors.New(`failed to fetch rate: unknown market: "usdtxyz"`),
+		},
 	}
 
 	for _, tt := range tests {
@@ -100,7 +151,10 @@ func TestRateService_GetRate(t *testing.T) {
 			tt.fetcherMock()
 			tt.saverMock()
 
-			got, err := service.GetRate(context.Background())
+			// every case starts with an empty cache
+			service := NewRateService(mockSaver, mockFetcher, NewRateCache(time.Minute), nil, 0, sugaredLogger)
+
+			got, err := service.GetRate(context.Background(), tt.market, 0)
 
 			if tt.expectedError != nil {
 				assert.EqualError(t, err, tt.expectedError.Error())
@@ -115,3 +169,378 @@ func TestRateService_GetRate(t *testing.T) {
 		})
 	}
 }
+
+func TestRateService_GetRate_Cache(t *testing.T) {
+	mockSaver := new(MockRateStore)
+	mockFetcher := new(MockRateFetcher)
+	cache := NewRateCache(time.Minute)
+
+	service := NewRateService(mockSaver, mockFetcher, cache, nil, 0, zap.NewNop().Sugar())
+
+	polled := &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Timestamp: time.Unix(1700000000, 0)}
+
+	mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(polled, nil).Once()
+	mockSaver.On("SaveRate", mock.Anything, polled).Return(true, nil).Once()
+
+	got, err := service.RefreshRate(context.Background(), "usdtrub")
+	assert.NoError(t, err)
+	assert.Equal(t, polled, got)
+
+	// polled market is served from the cache without fetching and saving
+	got, err = service.GetRate(context.Background(), "", 0)
+	assert.NoError(t, err)
+	assert.Same(t, polled, got)
+
+	// client asking for a fresher rate than cached gets a new one
+	fresh := &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("101.5"), Bid: domain.MustParseDecimal("100.5"), Timestamp: time.Unix(1700000005, 0)}
+	cache.now = func() time.Time { return time.Now().Add(5 * time.Second) }
+
+	mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(fresh, nil).Once()
+	mockSaver.On("SaveRate", mock.Anything, fresh).Return(true, nil).Once()
+
+	got, err = service.GetRate(context.Background(), "usdtrub", time.Second)
+	assert.NoError(t, err)
+	assert.Same(t, fresh, got)
+
+	mockFetcher.AssertExpectations(t)
+	mockSaver.AssertExpectations(t)
+}
+
+func TestRateService_RefreshRate_Duplicate(t *testing.T) {
+	mockSaver := new(MockRateStore)
+	mockFetcher := new(MockRateFetcher)
+
+	service := NewRateService(mockSaver, mockFetcher, NewRateCache(time.Minute), nil, 0, zap.NewNop().Sugar())
+
+	rate := &domain.Rate{Market: "usdtrub", Provider: "garantex", Timestamp: time.Unix(1700000000, 0)}
+
+	mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(rate, nil).Twice()
+	mockSaver.On("SaveRate", mock.Anything, rate).Return(true, nil).Once()
+	mockSaver.On("SaveRate", mock.Anything, rate).Return(false, nil).Once()
+
+	duplicates := testutil.ToFloat64(rateDuplicates)
+
+	for range 2 {
+		got, err := service.RefreshRate(context.Background(), "usdtrub")
+		assert.NoError(t, err)
+		assert.Equal(t, rate, got, "the same snapshot is served")
+	}
+
+	assert.Equal(t, duplicates+1, testutil.ToFloat64(rateDuplicates), "the snapshot polled again is a suppressed duplicate")
+
+	mockFetcher.AssertExpectations(t)
+	mockSaver.AssertExpectations(t)
+}
+
+func TestRateService_RefreshRate(t *testing.T) {
+	mockSaver := new(MockRateStore)
+	mockFetcher := new(MockRateFetcher)
+	cache := NewRateCache(time.Minute)
+
+	service := NewRateService(mockSaver, mockFetcher, cache, nil, 0, zap.NewNop().Sugar())
+
+	mockFetcher.On("FetchRate", mock.Anything, "btcrub").Return(nil, errors.New("fetch error"))
+
+	got, err := service.RefreshRate(context.Background(), "btcrub")
+	assert.EqualError(t, err, "failed to fetch rate: fetch error")
+	assert.Nil(t, got)
+
+	_, ok := cache.lookup("btcrub", time.Minute)
+	assert.False(t, ok)
+
+	mockSaver.AssertNotCalled(t, "SaveRate", mock.Anything, mock.Anything)
+}
+
+func TestRateService_GetRate_StaleFallback(t *testing.T) {
+	now := time.Unix(1700000600, 0)
+	fetchErr := errors.New("fetch error")
+
+	saved := func(age time.Duration) *domain.Rate {
+		return &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Timestamp: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name         string
+		maxStaleness time.Duration
+		fetchErr     error
+		latest       *domain.Rate
+		latestErr    error
+		want         *domain.Rate
+		errorText    string
+	}{
+		{
+			name:         "Last saved rate is served as stale",
+			maxStaleness: 5 * time.Minute,
+			fetchErr:     fetchErr,
+			latest:       saved(time.Minute),
+			want:         &domain.Rate{Market: "usdtrub", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Timestamp: now.Add(-time.Minute), Stale: true},
+		},
+		{
+			name:         "Last saved rate is too old",
+			maxStaleness: 5 * time.Minute,
+			fetchErr:     fetchErr,
+			latest:       saved(10 * time.Minute),
+			errorText:    "failed to fetch rate: fetch error (last saved rate is 10m0s old)",
+		},
+		{
+			name:         "No saved rate",
+			maxStaleness: 5 * time.Minute,
+			fetchErr:     fetchErr,
+			latestErr:    fmt.Errorf("%w: %q", domain.ErrRateNotFound, "usdtrub"),
+			errorText:    "failed to fetch rate: fetch error",
+		},
+		{
+			name:         "Database error",
+			maxStaleness: 5 * time.Minute,
+			fetchErr:     fetchErr,
+			latestErr:    errors.New("db error"),
+			errorText:    "failed to fetch rate: fetch error",
+		},
+		{
+			name:         "Unknown market is not served from the database",
+			maxStaleness: 5 * time.Minute,
+			fetchErr:     fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "usdtrub"),
+			errorText:    `failed to fetch rate: unknown market: "usdtrub"`,
+		},
+		{
+			name:      "Fallback disabled",
+			fetchErr:  fetchErr,
+			errorText: "failed to fetch rate: fetch error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(MockRateStore)
+			mockFetcher := new(MockRateFetcher)
+
+			mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, tt.fetchErr)
+			if tt.latest != nil || tt.latestErr != nil {
+				mockStore.On("GetLatestRate", mock.Anything, "usdtrub").Return(tt.latest, tt.latestErr)
+			}
+
+			service := NewRateService(mockStore, mockFetcher, NewRateCache(time.Minute), nil, tt.maxStaleness, zap.NewNop().Sugar())
+			service.now = func() time.Time { return now }
+
+			got, err := service.GetRate(context.Background(), "usdtrub", 0)
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRateService_RefreshRate_JumpGuard(t *testing.T) {
+	mockStore := new(MockRateStore)
+	mockFetcher := new(MockRateFetcher)
+	cache := NewRateCache(time.Minute)
+
+	service := NewRateService(mockStore, mockFetcher, cache, NewJumpGuard(0.1, zap.NewNop().Sugar()), 0, zap.NewNop().Sugar())
+
+	accepted := &domain.Rate{Market: "usdtrub", Provider: "garantex", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Timestamp: time.Unix(1700000000, 0)}
+	jump := &domain.Rate{Market: "usdtrub", Provider: "garantex", Ask: domain.MustParseDecimal("150.5"), Bid: domain.MustParseDecimal("149.5"), Timestamp: time.Unix(1700000010, 0)}
+
+	mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(accepted, nil).Once()
+	mockStore.On("SaveRate", mock.Anything, accepted).Return(true, nil).Once()
+
+	got, err := service.RefreshRate(context.Background(), "usdtrub")
+	assert.NoError(t, err)
+	assert.Same(t, accepted, got)
+
+	// held rate is neither saved nor served
+	mockFetcher.On("FetchRate", mock.Anything, "usdtrub").Return(jump, nil).Once()
+
+	got, err = service.RefreshRate(context.Background(), "usdtrub")
+	assert.NoError(t, err)
+	assert.Same(t, accepted, got)
+
+	got, err = service.GetRate(context.Background(), "usdtrub", 0)
+	assert.NoError(t, err)
+	assert.Same(t, accepted, got)
+
+	mockFetcher.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
+
+func TestRateService_GetRateHistory(t *testing.T) {
+	now := time.Unix(1700000600, 0)
+	from := now.Add(-time.Hour)
+	page := &domain.RateHistoryPage{NextCursor: "next"}
+
+	tests := []struct {
+		name      string
+		query     domain.RateHistoryQuery
+		want      domain.RateHistoryQuery
+		repoErr   error
+		errorIs   error
+		errorText string
+	}{
+		{
+			name:  "Defaults are applied",
+			query: domain.RateHistoryQuery{From: from},
+			want:  domain.RateHistoryQuery{Market: domain.DefaultMarket, From: from, To: now, Limit: DefaultHistoryPageSize},
+		},
+		{
+			name:  "Query is passed as is",
+			query: domain.RateHistoryQuery{Market: "btcrub", From: from, To: now.Add(-time.Minute), Limit: 10, Cursor: "abc"},
+			want:  domain.RateHistoryQuery{Market: "btcrub", From: from, To: now.Add(-time.Minute), Limit: 10, Cursor: "abc"},
+		},
+		{
+			name:      "Page size is too large",
+			query:     domain.RateHistoryQuery{From: from, Limit: MaxHistoryPageSize + 1},
+			errorIs:   domain.ErrInvalidArgument,
+			errorText: "invalid argument: page size must be between 1 and 1000, got 1001",
+		},
+		{
+			name:      "Negative page size",
+			query:     domain.RateHistoryQuery{From: from, Limit: -1},
+			errorIs:   domain.ErrInvalidArgument,
+			errorText: "invalid argument: page size must be between 1 and 1000, got -1",
+		},
+		{
+			name:      "Empty time range",
+			query:     domain.RateHistoryQuery{From: now, To: now},
+			errorIs:   domain.ErrInvalidArgument,
+			errorText: "invalid argument: from must be before to",
+		},
+		{
+			name:      "Repository error",
+			query:     domain.RateHistoryQuery{From: from},
+			want:      domain.RateHistoryQuery{Market: domain.DefaultMarket, From: from, To: now, Limit: DefaultHistoryPageSize},
+			repoErr:   errors.New("db error"),
+			errorText: "failed to get rate history: db error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(MockRateStore)
+			if tt.want.Market != "" {
+				if tt.repoErr != nil {
+					mockStore.On("GetRateHistory", mock.Anything, tt.want).Return(nil, tt.repoErr)
+				} else {
+					mockStore.On("GetRateHistory", mock.Anything, tt.want).Return(page, nil)
+				}
+			}
+
+			service := NewRateService(mockStore, new(MockRateFetcher), NewRateCache(time.Minute), nil, 0, zap.NewNop().Sugar())
+			service.now = func() time.Time { return now }
+
+			got, err := service.GetRateHistory(context.Background(), tt.query)
+
+			if tt.errorText != "" {
+				if tt.errorIs != nil {
+					assert.ErrorIs(t, err, tt.errorIs)
+				}
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Same(t, page, got)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRateService_GetCandles(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC().Truncate(time.Hour).Add(30 * time.Minute)
+	from := now.Add(-30 * time.Minute)
+
+	ohlc := func(open, high, low, close string) domain.OHLC {
+		return domain.OHLC{
+			Open:  domain.MustParseDecimal(open),
+			High:  domain.MustParseDecimal(high),
+			Low:   domain.MustParseDecimal(low),
+			Close: domain.MustParseDecimal(close),
+		}
+	}
+	candle := func(minutes int, prices domain.OHLC, count int64) domain.Candle {
+		return domain.Candle{Start: from.Add(time.Duration(minutes) * time.Minute), Mid: prices, Ask: prices, Bid: prices, Count: count}
+	}
+	gap := func(minutes int, price string) domain.Candle {
+		flat := ohlc(price, price, price, price)
+		return domain.Candle{Start: from.Add(time.Duration(minutes) * time.Minute), Mid: flat, Ask: flat, Bid: flat, Filled: true}
+	}
+
+	stored := []domain.Candle{
+		candle(5, ohlc("100", "102", "99", "101"), 3),
+		candle(15, ohlc("101", "101", "98", "98.5"), 2),
+	}
+
+	tests := []struct {
+		name      string
+		query     domain.CandleQuery
+		repoQuery domain.CandleQuery
+		want      []domain.Candle
+		errorIs   error
+		errorText string
+	}{
+		{
+			name:      "Gaps are skipped",
+			query:     domain.CandleQuery{From: from, Interval: 5 * time.Minute},
+			repoQuery: domain.CandleQuery{Market: domain.DefaultMarket, From: from, To: now, Interval: 5 * time.Minute},
+			want:      stored,
+		},
+		{
+			name:      "Gaps are forward-filled after the first candle",
+			query:     domain.CandleQuery{Market: "btcrub", From: from, To: now, Interval: 5 * time.Minute, FillGaps: true},
+			repoQuery: domain.CandleQuery{Market: "btcrub", From: from, To: now, Interval: 5 * time.Minute, FillGaps: true},
+			want: []domain.Candle{
+				stored[0],
+				gap(10, "101"),
+				stored[1],
+				gap(20, "98.5"),
+				gap(25, "98.5"),
+			},
+		},
+		{
+			name:      "Unsupported interval",
+			query:     domain.CandleQuery{From: from, Interval: 7 * time.Minute},
+			errorIs:   domain.ErrInvalidArgument,
+			errorText: "invalid argument: unsupported candle interval 7m0s",
+		},
+		{
+			name:      "Empty time range",
+			query:     domain.CandleQuery{From: now, Interval: time.Minute},
+			errorIs:   domain.ErrInvalidArgument,
+			errorText: "invalid argument: from must be before to",
+		},
+		{
+			name:      "Too many candles",
+			query:     domain.CandleQuery{From: now.Add(-MaxCandles*time.Minute - time.Second), Interval: time.Minute},
+			errorIs:   domain.ErrInvalidArgument,
+			errorText: "invalid argument: time range spans more than 5000 candles of 1m0s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(MockRateStore)
+			if tt.repoQuery.Market != "" {
+				mockStore.On("GetCandles", mock.Anything, tt.repoQuery).Return(stored, nil)
+			}
+
+			service := NewRateService(mockStore, new(MockRateFetcher), NewRateCache(time.Minute), nil, 0, zap.NewNop().Sugar())
+			service.now = func() time.Time { return now }
+
+			got, err := service.GetCandles(context.Background(), tt.query)
+
+			if tt.errorText != "" {
+				assert.ErrorIs(t, err, tt.errorIs)
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"final/internal/domain"
+	"final/internal/spool"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	spoolDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_spool_depth",
+			Help: "Number of spooled rates waiting to be saved",
+		},
+	)
+	spoolAge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_spool_oldest_age_seconds",
+			Help: "Age of the oldest spooled rate waiting to be saved, zero if the spool is empty",
+		},
+	)
+	spoolAppended = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_spool_appended_total",
+			Help: "Total number of rates spooled because they failed to save",
+		},
+	)
+	spoolReplayed = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_spool_replayed_total",
+			Help: "Total number of spooled rates saved by the replayer",
+		},
+	)
+	spoolDiscarded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_spool_discarded_total",
+			Help: "Total number of spooled rates discarded by the replayer by reason",
+		},
+		[]string{"reason"},
+	)
+	spoolDeadLettered = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_spool_dead_lettered_total",
+			Help: "Total number of spooled rates moved to the dead letters after the storage rejected them",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(spoolDepth, spoolAge, spoolAppended, spoolReplayed, spoolDiscarded, spoolDeadLettered)
+}
+
+// RateBatchSaver saves rates in batches.
+type RateBatchSaver interface {
+	// SaveRates returns the number of new rates, rates with the market, provider and timestamp of a saved one are skipped.
+	SaveRates(ctx context.Context, rates []*domain.Rate) (int, error)
+}
+
+// spooledRate is a rate in the spool.
+type spooledRate struct {
+	Market     string         `json:"market"`
+	Provider   string         `json:"provider"`
+	Ask        domain.Decimal `json:"ask"`
+	Bid        domain.Decimal `json:"bid"`
+	AskVolume  domain.Decimal `json:"ask_volume"`
+	BidVolume  domain.Decimal `json:"bid_volume"`
+	Timestamp  time.Time      `json:"timestamp"`
+	ReceivedAt time.Time      `json:"received_at"`
+	Latency    time.Duration  `json:"latency"`
+}
+
+// rateKey identifies a rate quoted by a provider.
+type rateKey struct {
+	market    string
+	provider  string
+	timestamp time.Time
+}
+
+func newRateKey(rate *domain.Rate) rateKey {
+	return rateKey{market: rate.Market, provider: rate.Provider, timestamp: rate.Timestamp.UTC()}
+}
+
+func (r *spooledRate) toRate() *domain.Rate {
+	return &domain.Rate{
+		Market:     r.Market,
+		Provider:   r.Provider,
+		Ask:        r.Ask,
+		Bid:        r.Bid,
+		AskVolume:  r.AskVolume,
+		BidVolume:  r.BidVolume,
+		Timestamp:  r.Timestamp,
+		ReceivedAt: r.ReceivedAt,
+		Latency:    r.Latency,
+	}
+}
+
+// reportSpool updates the spool depth and age metrics.
+func reportSpool(sp *spool.Spool, now time.Time) {
+	spoolDepth.Set(float64(sp.Len()))
+
+	oldest, err := sp.Oldest()
+	if err != nil || oldest.IsZero() {
+		spoolAge.Set(0)
+		return
+	}
+
+	spoolAge.Set(now.Sub(oldest).Seconds())
+}
+
+// SpoolingStore is a RateBatchStore which appends rates failed to save to the spool, reads are passed to the store.
+// While the spool has rates, new rates are appended to it too, so that the SpoolReplayer saves them in order.
+type SpoolingStore struct {
+	store RateBatchStore
+	spool *spool.Spool
+	l     *zap.SugaredLogger
+}
+
+func NewSpoolingStore(store RateBatchStore, sp *spool.Spool, logger *zap.SugaredLogger) *SpoolingStore {
+	return &SpoolingStore{
+		store: store,
+		spool: sp,
+		l:     logger,
+	}
+}
+
+func (s *SpoolingStore) SaveRate(ctx context.Context, rate *domain.Rate) (bool, error) {
+	inserted, err := s.SaveRates(ctx, []*domain.Rate{rate})
+	return inserted > 0, err
+}
+
+// SaveRates saves the rates or appends them to the spool, an error is returned only if spooling fails.
+// Spooled rates are reported as new, duplicates are counted when the SpoolReplayer saves them.
+func (s *SpoolingStore) SaveRates(ctx context.Context, rates []*domain.Rate) (int, error) {
+	if s.spool.Len() == 0 {
+		inserted, err := s.store.SaveRates(ctx, rates)
+		if err == nil {
+			return inserted, nil
+		}
+
+		s.l.Warnf("spooling %d rates which failed to save: %v", len(rates), err)
+	}
+
+	records := make([][]byte, 0, len(rates))
+	for _, rate := range rates {
+		data, err := json.Marshal(spooledRate{
+			Market:     rate.Market,
+			Provider:   rate.Provider,
+			Ask:        rate.Ask,
+			Bid:        rate.Bid,
+			AskVolume:  rate.AskVolume,
+			BidVolume:  rate.BidVolume,
+			Timestamp:  rate.Timestamp,
+			ReceivedAt: rate.ReceivedAt,
+			Latency:    rate.Latency,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode spooled rate: %w", err)
+		}
+
+		records = append(records, data)
+	}
+
+	if err := s.spool.Append(records...); err != nil {
+		return 0, fmt.Errorf("failed to spool rates: %w", err)
+	}
+
+	spoolAppended.Add(float64(len(rates)))
+	reportSpool(s.spool, time.Now())
+
+	return len(rates), nil
+}
+
+func (s *SpoolingStore) GetLatestRate(ctx context.Context, market string) (*domain.Rate, error) {
+	return s.store.GetLatestRate(ctx, market)
+}
+
+func (s *SpoolingStore) GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error) {
+	return s.store.GetRateHistory(ctx, q)
+}
+
+func (s *SpoolingStore) GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error) {
+	return s.store.GetCandles(ctx, q)
+}
+
+// SpoolReplayPolicy decides which spooled rates failed to save are retried.
+type SpoolReplayPolicy struct {
+	// MaxAttempts is a max number of failed saves of a spooled rate rejected by the storage,
+	// after which it is moved to the dead letters
+	MaxAttempts int
+	// IsTransient reports errors of an unavailable storage, rates failed with them are retried without limit
+	IsTransient func(error) bool
+}
+
+// SpoolReplayer saves spooled rates in spool order at the interval in the background.
+// A batch rejected by the storage is saved one rate at a time, so that a rate failing permanently
+// is moved to the dead letters after policy.MaxAttempts replays instead of blocking the rates spooled after it.
+type SpoolReplayer struct {
+	saver       RateBatchSaver
+	spool       *spool.Spool
+	deadLetters *spool.Spool
+	policy      SpoolReplayPolicy
+	batchSize   int
+	interval    time.Duration
+	now         func() time.Time
+	l           *zap.SugaredLogger
+
+	// attempts counts failed saves of rates rejected by the storage, it is used by the replay goroutine only
+	attempts map[rateKey]int
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewSpoolReplayer creates a replayer saving up to batchSize spooled rates at once,
+// records of rates rejected by the storage are appended to the deadLetters spool.
+func NewSpoolReplayer(saver RateBatchSaver, sp, deadLetters *spool.Spool, policy SpoolReplayPolicy, batchSize int, interval time.Duration, logger *zap.SugaredLogger) *SpoolReplayer {
+	return &SpoolReplayer{
+		saver:       saver,
+		spool:       sp,
+		deadLetters: deadLetters,
+		policy:      policy,
+		batchSize:   batchSize,
+		interval:    interval,
+		now:         time.Now,
+		l:           logger,
+		attempts:    make(map[rateKey]int),
+	}
+}
+
+// Start replays the spool immediately and then at the interval until Stop is called or ctx is done.
+// Start after Stop does nothing.
+func (r *SpoolReplayer) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped || r.cancel != nil {
+		return
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.loop(ctx)
+	}()
+
+	r.l.Infof("replaying spooled rates every %s, %d rates are spooled", r.interval, r.spool.Len())
+}
+
+// Stop stops the replayer and waits for the running replay to finish.
+func (r *SpoolReplayer) Stop() {
+	r.mu.Lock()
+	r.stopped = true
+	cancel := r.cancel
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	r.wg.Wait()
+}
+
+func (r *SpoolReplayer) loop(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run saves batches of spooled rates until the spool is empty or saving fails.
+// A rate spooled more than once is saved once, undecodable records are discarded.
+func (r *SpoolReplayer) run(ctx context.Context) {
+	defer func() { reportSpool(r.spool, r.now()) }()
+
+	seen := make(map[rateKey]struct{})
+	replayed := 0
+
+	for ctx.Err() == nil {
+		records, err := r.spool.Peek(r.batchSize)
+		if err != nil {
+			r.l.Errorf("failed to read spooled rates: %v", err)
+			return
+		}
+
+		if len(records) == 0 {
+			break
+		}
+
+		saved, ok := r.save(ctx, records, r.decode(records, seen))
+		replayed += saved
+
+		if !ok {
+			break
+		}
+	}
+
+	if replayed > 0 {
+		r.l.Infof("saved %d spooled rates, %d are left", replayed, r.spool.Len())
+	}
+}
+
+// decode returns rates of the records in the same order, the rate of a discarded record is nil.
+func (r *SpoolReplayer) decode(records []spool.Record, seen map[rateKey]struct{}) []*domain.Rate {
+	rates := make([]*domain.Rate, len(records))
+
+	for i, rec := range records {
+		var sr spooledRate
+		if err := json.Unmarshal(rec.Data, &sr); err != nil {
+			spoolDiscarded.WithLabelValues("malformed").Inc()
+			r.l.Errorf("discarding malformed spooled rate: %v", err)
+			continue
+		}
+
+		rate := sr.toRate()
+
+		key := newRateKey(rate)
+		if _, ok := seen[key]; ok {
+			spoolDiscarded.WithLabelValues("duplicate").Inc()
+			continue
+		}
+		seen[key] = struct{}{}
+
+		rates[i] = rate
+	}
+
+	return rates
+}
+
+// save saves the rates of the records at once and acknowledges the records.
+// Returns the number of saved rates and false if the replay has to stop.
+func (r *SpoolReplayer) save(ctx context.Context, records []spool.Record, rates []*domain.Rate) (int, bool) {
+	batch := slices.DeleteFunc(slices.Clone(rates), func(rate *domain.Rate) bool { return rate == nil })
+
+	if len(batch) > 0 {
+		inserted, err := r.saver.SaveRates(ctx, batch)
+		if err != nil {
+			if r.retryable(ctx, err) {
+				if ctx.Err() == nil {
+					r.l.Warnf("failed to save %d spooled rates, retrying in %s: %v", r.spool.Len(), r.interval, err)
+				}
+				return 0, false
+			}
+
+			r.l.Warnf("storage rejected %d spooled rates, saving them one at a time: %v", len(batch), err)
+			return r.saveEach(ctx, records, rates)
+		}
+
+		rateDuplicates.Add(float64(len(batch) - inserted))
+	}
+
+	if err := r.spool.Ack(len(records)); err != nil {
+		r.l.Errorf("failed to acknowledge spooled rates: %v", err)
+		return 0, false
+	}
+
+	spoolReplayed.Add(float64(len(batch)))
+
+	return len(batch), true
+}
+
+// saveEach saves the rates of the records one at a time and acknowledges every saved or dead-lettered record.
+// Returns the number of saved rates and false if the replay has to stop.
+func (r *SpoolReplayer) saveEach(ctx context.Context, records []spool.Record, rates []*domain.Rate) (int, bool) {
+	saved := 0
+
+	for i, rate := range rates {
+		if rate != nil {
+			inserted, err := r.saver.SaveRates(ctx, []*domain.Rate{rate})
+
+			switch {
+			case err == nil:
+				delete(r.attempts, newRateKey(rate))
+				rateDuplicates.Add(float64(1 - inserted))
+				spoolReplayed.Inc()
+				saved++
+			case r.retryable(ctx, err):
+				if ctx.Err() == nil {
+					r.l.Warnf("failed to save %d spooled rates, retrying in %s: %v", r.spool.Len(), r.interval, err)
+				}
+				return saved, false
+			default:
+				if !r.reject(records[i], rate, err) {
+					return saved, false
+				}
+			}
+		}
+
+		if err := r.spool.Ack(1); err != nil {
+			r.l.Errorf("failed to acknowledge spooled rates: %v", err)
+			return saved, false
+		}
+	}
+
+	return saved, true
+}
+
+// retryable reports whether the failed save is retried without counting it against the rates.
+func (r *SpoolReplayer) retryable(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || r.policy.IsTransient != nil && r.policy.IsTransient(err)
+}
+
+// reject counts the failed save of the rate rejected by the storage and moves its record to the dead letters
+// after policy.MaxAttempts failures. Returns true if the record is moved and can be acknowledged.
+func (r *SpoolReplayer) reject(rec spool.Record, rate *domain.Rate, err error) bool {
+	key := newRateKey(rate)
+
+	r.attempts[key]++
+	if attempts := r.attempts[key]; attempts < r.policy.MaxAttempts {
+		r.l.Warnf("storage rejected spooled %s rate of %s at %s, attempt %d of %d, retrying in %s: %v",
+			rate.Provider, rate.Market, rate.Timestamp.UTC().Format(time.RFC3339Nano), attempts, r.policy.MaxAttempts, r.interval, err)
+		return false
+	}
+
+	if err := r.deadLetters.Append(rec.Data); err != nil {
+		r.l.Errorf("failed to move spooled rate to the dead letters: %v", err)
+		return false
+	}
+
+	delete(r.attempts, key)
+	spoolDeadLettered.Inc()
+
+	r.l.Errorf("moved spooled %s rate of %s at %s to the dead letters after %d rejections: %v",
+		rate.Provider, rate.Market, rate.Timestamp.UTC().Format(time.RFC3339Nano), r.policy.MaxAttempts, err)
+
+	return true
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"final/internal/spool"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type batchSaverFunc func(ctx context.Context, rates []*domain.Rate) (int, error)
+
+func (f batchSaverFunc) SaveRates(ctx context.Context, rates []*domain.Rate) (int, error) {
+	return f(ctx, rates)
+}
+
+// failingBatchStore fails to save rates while err is set.
+type failingBatchStore struct {
+	fakeBatchStore
+	err error
+}
+
+func (s *failingBatchStore) SaveRates(ctx context.Context, rates []*domain.Rate) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.fakeBatchStore.SaveRates(ctx, rates)
+}
+
+func openSpool(t *testing.T) *spool.Spool {
+	t.Helper()
+
+	sp, err := spool.Open(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("failed to open spool: %v", err)
+	}
+	t.Cleanup(func() { sp.Close() })
+
+	return sp
+}
+
+func spooledRateAt(market string, sec int64) *domain.Rate {
+	return &domain.Rate{
+		Market:     market,
+		Provider:   "garantex",
+		Ask:        domain.MustParseDecimal("100.5"),
+		Bid:        domain.MustParseDecimal("99.5"),
+		AskVolume:  domain.MustParseDecimal("12.5"),
+		BidVolume:  domain.MustParseDecimal("3"),
+		Timestamp:  time.Unix(1700000000+sec, 0).UTC(),
+		ReceivedAt: time.Unix(1700000000+sec, 500).UTC(),
+		Latency:    250 * time.Millisecond,
+	}
+}
+
+func TestSpoolingStore_SaveRates(t *testing.T) {
+	sp := openSpool(t)
+	store := &failingBatchStore{}
+	s := NewSpoolingStore(store, sp, zap.NewNop().Sugar())
+
+	saved, err := s.SaveRate(context.Background(), spooledRateAt("usdtrub", 0))
+	assert.NoError(t, err)
+	assert.True(t, saved)
+	assert.Equal(t, []int{1}, store.batchSizes(), "rate is saved while the spool is empty")
+	assert.Equal(t, 0, sp.Len())
+
+	appended := testutil.ToFloat64(spoolAppended)
+
+	store.err = errors.New("db error")
+	inserted, err := s.SaveRates(context.Background(), []*domain.Rate{spooledRateAt("usdtrub", 1), spooledRateAt("usdtrub", 2)})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, inserted, "spooled rates are reported as new")
+	assert.Equal(t, 2, sp.Len(), "rates failed to save are spooled")
+
+	store.err = nil
+	_, err = s.SaveRate(context.Background(), spooledRateAt("usdtrub", 3))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, store.batchSizes(), "rates are spooled while the spool has rates")
+	assert.Equal(t, 3, sp.Len())
+
+	assert.Equal(t, appended+3, testutil.ToFloat64(spoolAppended))
+	assert.Equal(t, float64(3), testutil.ToFloat64(spoolDepth))
+}
+
+func TestSpoolReplayer_Run(t *testing.T) {
+	sp := openSpool(t)
+	spooled := []*domain.Rate{
+		spooledRateAt("usdtrub", 0),
+		spooledRateAt("btcusdt", 0),
+		spooledRateAt("usdtrub", 0),
+		spooledRateAt("usdtrub", 1),
+		spooledRateAt("usdtrub", 2),
+	}
+
+	// spool the rates by failing to save them
+	failing := NewSpoolingStore(&failingBatchStore{err: errors.New("db error")}, sp, zap.NewNop().Sugar())
+	_, err := failing.SaveRates(context.Background(), spooled)
+	assert.NoError(t, err)
+	assert.NoError(t, sp.Append([]byte("not a rate")))
+
+	var saved [][]*domain.Rate
+	fail := true
+
+	saver := batchSaverFunc(func(ctx context.Context, rates []*domain.Rate) (int, error) {
+		if fail {
+			return 0, errors.New("db error")
+		}
+		saved = append(saved, rates)
+		// the first rate was saved before spooling it, e.g. when the response of the database was lost
+		if len(saved) == 1 {
+			return len(rates) - 1, nil
+		}
+		return len(rates), nil
+	})
+
+	policy := SpoolReplayPolicy{MaxAttempts: 1, IsTransient: func(error) bool { return true }}
+	r := NewSpoolReplayer(saver, sp, openSpool(t), policy, 2, time.Hour, zap.NewNop().Sugar())
+
+	r.run(context.Background())
+	assert.Empty(t, saved)
+	assert.Equal(t, 6, sp.Len(), "rates are kept while saving fails")
+
+	duplicates := testutil.ToFloat64(spoolDiscarded.WithLabelValues("duplicate"))
+	malformed := testutil.ToFloat64(spoolDiscarded.WithLabelValues("malformed"))
+	suppressed := testutil.ToFloat64(rateDuplicates)
+
+	fail = false
+	r.run(context.Background())
+
+	assert.Equal(t, [][]*domain.Rate{
+		{spooled[0], spooled[1]},
+		{spooled[3]},
+		{spooled[4]},
+	}, saved, "rates are saved in spool order once")
+	assert.Equal(t, 0, sp.Len())
+	assert.Equal(t, duplicates+1, testutil.ToFloat64(spoolDiscarded.WithLabelValues("duplicate")))
+	assert.Equal(t, malformed+1, testutil.ToFloat64(spoolDiscarded.WithLabelValues("malformed")))
+	assert.Equal(t, suppressed+1, testutil.ToFloat64(rateDuplicates), "rates already saved are suppressed duplicates")
+	assert.Equal(t, float64(0), testutil.ToFloat64(spoolDepth))
+	assert.Equal(t, float64(0), testutil.ToFloat64(spoolAge))
+}
+
+func TestSpoolReplayer_RunDeadLetters(t *testing.T) {
+	sp := openSpool(t)
+	deadLetters := openSpool(t)
+
+	spooled := []*domain.Rate{
+		spooledRateAt("usdtrub", 0),
+		spooledRateAt("usdtrub", 1),
+		spooledRateAt("usdtrub", 2),
+		spooledRateAt("usdtrub", 3),
+	}
+
+	failing := NewSpoolingStore(&failingBatchStore{err: errors.New("db error")}, sp, zap.NewNop().Sugar())
+	_, err := failing.SaveRates(context.Background(), spooled)
+	assert.NoError(t, err)
+
+	rejected := errors.New("numeric field overflow")
+	unavailable := errors.New("connection refused")
+	down := false
+
+	var saved []*domain.Rate
+	var batches []int
+
+	saver := batchSaverFunc(func(ctx context.Context, rates []*domain.Rate) (int, error) {
+		batches = append(batches, len(rates))
+		if down {
+			return 0, unavailable
+		}
+		for _, rate := range rates {
+			// the storage rejects the second rate and the whole batch with it
+			if rate.Timestamp.Equal(spooled[1].Timestamp) {
+				return 0, rejected
+			}
+		}
+		saved = append(saved, rates...)
+		return len(rates), nil
+	})
+
+	policy := SpoolReplayPolicy{MaxAttempts: 2, IsTransient: func(err error) bool { return errors.Is(err, unavailable) }}
+	r := NewSpoolReplayer(saver, sp, deadLetters, policy, 3, time.Hour, zap.NewNop().Sugar())
+
+	// the rejected batch is saved one rate at a time up to the rejected rate
+	r.run(context.Background())
+	assert.Equal(t, []int{3, 1, 1}, batches)
+	assert.Equal(t, []*domain.Rate{spooled[0]}, saved)
+	assert.Equal(t, 3, sp.Len(), "the rejected rate is retried")
+	assert.Equal(t, 0, deadLetters.Len())
+
+	// an unavailable storage does not count as a rejection
+	batches, down = nil, true
+	r.run(context.Background())
+	assert.Equal(t, []int{3}, batches)
+	assert.Equal(t, 3, sp.Len())
+
+	deadLettered := testutil.ToFloat64(spoolDeadLettered)
+
+	// the second rejection moves the rate to the dead letters and the rest of the spool is saved
+	batches, down = nil, false
+	r.run(context.Background())
+	assert.Equal(t, []int{3, 1, 1, 1}, batches)
+	assert.Equal(t, []*domain.Rate{spooled[0], spooled[2], spooled[3]}, saved)
+	assert.Equal(t, 0, sp.Len())
+	assert.Equal(t, 1, deadLetters.Len())
+	assert.Equal(t, deadLettered+1, testutil.ToFloat64(spoolDeadLettered))
+	assert.Empty(t, r.attempts)
+
+	records, err := deadLetters.Peek(1)
+	if assert.NoError(t, err) && assert.Len(t, records, 1) {
+		assert.Equal(t, spooled[1:2], r.decode(records, map[rateKey]struct{}{}))
+	}
+}
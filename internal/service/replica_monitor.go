@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	replicaLag = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_replica_lag_seconds",
+			Help: "Replication lag of the read replica at the last check",
+		},
+	)
+	replicaFallback = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_replica_fallback",
+			Help: "1 if reads go to the primary because the read replica lags or is unavailable, 0 otherwise",
+		},
+	)
+	replicaCheckFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_replica_lag_check_failures_total",
+			Help: "Total number of failed replication lag checks of the read replica",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(replicaLag, replicaFallback, replicaCheckFailures)
+}
+
+type ReplicaLagChecker interface {
+	// CheckLag returns the replication lag and whether reads go to the replica.
+	CheckLag(ctx context.Context) (time.Duration, bool, error)
+}
+
+// ReplicaMonitor checks the replication lag of the read replica at the interval in the background,
+// reads fall back to the primary while the lag exceeds the limit or can not be checked.
+type ReplicaMonitor struct {
+	checker  ReplicaLagChecker
+	interval time.Duration
+	l        *zap.SugaredLogger
+
+	// fallback is whether reads went to the primary at the last check
+	fallback bool
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func NewReplicaMonitor(checker ReplicaLagChecker, interval time.Duration, logger *zap.SugaredLogger) *ReplicaMonitor {
+	return &ReplicaMonitor{
+		checker:  checker,
+		interval: interval,
+		l:        logger,
+		// reads go to the primary until the first check
+		fallback: true,
+	}
+}
+
+// Start checks the lag immediately and then at the interval until Stop is called or ctx is done.
+// Start after Stop does nothing.
+func (m *ReplicaMonitor) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped || m.cancel != nil {
+		return
+	}
+
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.loop(ctx)
+	}()
+
+	m.l.Infof("checking replication lag of the read replica every %s", m.interval)
+}
+
+// Stop stops the monitor and waits for the running check to finish.
+func (m *ReplicaMonitor) Stop() {
+	m.mu.Lock()
+	m.stopped = true
+	cancel := m.cancel
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	m.wg.Wait()
+}
+
+func (m *ReplicaMonitor) loop(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run checks the lag and logs switches of reads between the replica and the primary.
+func (m *ReplicaMonitor) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, m.interval)
+	defer cancel()
+
+	lag, healthy, err := m.checker.CheckLag(ctx)
+
+	switch {
+	case err != nil:
+		replicaCheckFailures.Inc()
+		if ctx.Err() == nil {
+			m.l.Errorf("failed to check replication lag, reading from the primary: %v", err)
+		}
+	case !healthy && !m.fallback:
+		m.l.Warnf("read replica lags by %s, reading from the primary", lag)
+	case healthy && m.fallback:
+		m.l.Infof("read replica lags by %s, reading from the replica", lag)
+	}
+
+	if err == nil {
+		replicaLag.Set(lag.Seconds())
+	}
+
+	m.fallback = !healthy
+
+	if m.fallback {
+		replicaFallback.Set(1)
+	} else {
+		replicaFallback.Set(0)
+	}
+}
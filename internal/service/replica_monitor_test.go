@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type lagCheckerFunc func(ctx context.Context) (time.Duration, bool, error)
+
+func (f lagCheckerFunc) CheckLag(ctx context.Context) (time.Duration, bool, error) {
+	return f(ctx)
+}
+
+func TestReplicaMonitor_Run(t *testing.T) {
+	tests := []struct {
+		name         string
+		lag          time.Duration
+		healthy      bool
+		err          error
+		wantLag      float64
+		wantFallback float64
+		wantFailures float64
+	}{
+		{
+			name:    "Reads go to the replica",
+			lag:     time.Second,
+			healthy: true,
+			wantLag: 1,
+		},
+		{
+			name:         "Replica lags",
+			lag:          time.Minute,
+			wantLag:      60,
+			wantFallback: 1,
+		},
+		{
+			name:         "Failed check",
+			err:          errors.New("connection refused"),
+			wantLag:      -1,
+			wantFallback: 1,
+			wantFailures: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := lagCheckerFunc(func(ctx context.Context) (time.Duration, bool, error) {
+				return tt.lag, tt.healthy, tt.err
+			})
+
+			m := NewReplicaMonitor(checker, time.Second, zap.NewNop().Sugar())
+
+			// the lag of a failed check is not reported
+			replicaLag.Set(-1)
+			failures := testutil.ToFloat64(replicaCheckFailures)
+
+			m.run(context.Background())
+
+			assert.Equal(t, tt.wantLag, testutil.ToFloat64(replicaLag))
+			assert.Equal(t, tt.wantFallback, testutil.ToFloat64(replicaFallback))
+			assert.Equal(t, failures+tt.wantFailures, testutil.ToFloat64(replicaCheckFailures))
+			assert.Equal(t, tt.wantFallback == 1, m.fallback)
+		})
+	}
+}
+
+func TestReplicaMonitor_StartStop(t *testing.T) {
+	checks := make(chan struct{}, 10)
+
+	checker := lagCheckerFunc(func(ctx context.Context) (time.Duration, bool, error) {
+		checks <- struct{}{}
+		return 0, true, nil
+	})
+
+	m := NewReplicaMonitor(checker, time.Hour, zap.NewNop().Sugar())
+
+	m.Start(context.Background())
+
+	select {
+	case <-checks:
+	case <-time.After(time.Second):
+		t.Fatalf("lag is not checked on start")
+	}
+
+	m.Stop()
+	m.Start(context.Background())
+
+	assert.Len(t, checks, 0, "start after stop does nothing")
+}
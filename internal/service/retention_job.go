@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Retention run results.
+const (
+	RetentionApplied = "applied"
+	RetentionSkipped = "skipped"
+	RetentionFailed  = "failed"
+)
+
+var (
+	retentionRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_retention_runs_total",
+			Help: "Total number of retention runs by result",
+		},
+		[]string{"result"},
+	)
+	retentionRolledUp = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_retention_rolled_up_total",
+			Help: "Total number of candles rolled up from expired raw rates",
+		},
+		[]string{"table"},
+	)
+	retentionDroppedPartitions = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_retention_dropped_partitions_total",
+			Help: "Total number of expired partitions of raw rates dropped by the retention",
+		},
+	)
+	retentionDeleted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_retention_deleted_total",
+			Help: "Total number of rows deleted by the retention",
+		},
+		[]string{"table"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(retentionRuns, retentionRolledUp, retentionDroppedPartitions, retentionDeleted)
+}
+
+type RateRetainer interface {
+	ApplyRetention(ctx context.Context, cutoffs domain.RetentionCutoffs) (*domain.RetentionResult, error)
+}
+
+// RetentionPolicy is how long rates are kept.
+type RetentionPolicy struct {
+	// Raw is a max age of raw rates, older ones are rolled up into minute and hour candles.
+	Raw time.Duration
+	// Minute is a max age of minute candles.
+	Minute time.Duration
+	// Horizon is a max age of hour candles, nothing older is kept.
+	Horizon time.Duration
+}
+
+// RetentionJob applies the retention policy at the interval in the background.
+type RetentionJob struct {
+	retainer RateRetainer
+	policy   RetentionPolicy
+	interval time.Duration
+	now      func() time.Time
+	l        *zap.SugaredLogger
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewRetentionJob creates a job, the policy ages must be at least an hour and must not decrease from Raw to Horizon.
+func NewRetentionJob(retainer RateRetainer, policy RetentionPolicy, interval time.Duration, logger *zap.SugaredLogger) (*RetentionJob, error) {
+	if policy.Raw < time.Hour {
+		return nil, errors.New("raw rates retention must be at least an hour")
+	}
+
+	if policy.Minute < policy.Raw || policy.Horizon < policy.Minute {
+		return nil, errors.New("retention of minute candles must be between raw rates retention and horizon")
+	}
+
+	return &RetentionJob{
+		retainer: retainer,
+		policy:   policy,
+		interval: interval,
+		now:      time.Now,
+		l:        logger,
+	}, nil
+}
+
+// Start applies the retention immediately and then at the interval until Stop is called or ctx is done.
+// Start after Stop does nothing.
+func (j *RetentionJob) Start(ctx context.Context) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stopped || j.cancel != nil {
+		return
+	}
+
+	ctx, j.cancel = context.WithCancel(ctx)
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		j.loop(ctx)
+	}()
+
+	j.l.Infof("applying rates retention every %s", j.interval)
+}
+
+// Stop stops the job and waits for the running retention to finish.
+func (j *RetentionJob) Stop() {
+	j.mu.Lock()
+	j.stopped = true
+	cancel := j.cancel
+	j.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	j.wg.Wait()
+}
+
+func (j *RetentionJob) loop(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run applies the retention once, cutoffs are aligned to hours so that rolled up intervals are complete.
+func (j *RetentionJob) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	now := j.now()
+	cutoffs := domain.RetentionCutoffs{
+		Raw:    now.Add(-j.policy.Raw).Truncate(time.Hour),
+		Minute: now.Add(-j.policy.Minute).Truncate(time.Hour),
+		Hour:   now.Add(-j.policy.Horizon).Truncate(time.Hour),
+	}
+
+	res, err := j.retainer.ApplyRetention(ctx, cutoffs)
+	if err != nil {
+		retentionRuns.WithLabelValues(RetentionFailed).Inc()
+		if ctx.Err() == nil {
+			j.l.Errorf("failed to apply rates retention: %v", err)
+		}
+		return
+	}
+
+	if res.Skipped {
+		retentionRuns.WithLabelValues(RetentionSkipped).Inc()
+		j.l.Debugln("rates retention is being applied by another instance")
+		return
+	}
+
+	retentionRuns.WithLabelValues(RetentionApplied).Inc()
+	retentionRolledUp.WithLabelValues("Rate_1m").Add(float64(res.RolledUpMinutes))
+	retentionRolledUp.WithLabelValues("Rate_1h").Add(float64(res.RolledUpHours))
+	retentionDroppedPartitions.Add(float64(res.DroppedPartitions))
+	retentionDeleted.WithLabelValues("Rate").Add(float64(res.DeletedRaw))
+	retentionDeleted.WithLabelValues("Rate_1m").Add(float64(res.DeletedMinutes))
+	retentionDeleted.WithLabelValues("Rate_1h").Add(float64(res.DeletedHours))
+
+	j.l.Infof("applied rates retention: rolled up %d minute and %d hour candles, dropped %d partitions, deleted %d rates, %d minute and %d hour candles",
+		res.RolledUpMinutes, res.RolledUpHours, res.DroppedPartitions, res.DeletedRaw, res.DeletedMinutes, res.DeletedHours)
+}
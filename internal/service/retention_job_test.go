@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type retainerFunc func(ctx context.Context, cutoffs domain.RetentionCutoffs) (*domain.RetentionResult, error)
+
+func (f retainerFunc) ApplyRetention(ctx context.Context, cutoffs domain.RetentionCutoffs) (*domain.RetentionResult, error) {
+	return f(ctx, cutoffs)
+}
+
+const day = 24 * time.Hour
+
+func TestNewRetentionJob(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    RetentionPolicy
+		errorText string
+	}{
+		{
+			name:   "Valid policy",
+			policy: RetentionPolicy{Raw: 7 * day, Minute: 30 * day, Horizon: 365 * day},
+		},
+		{
+			name:      "Raw retention shorter than an hour",
+			policy:    RetentionPolicy{Raw: time.Minute, Minute: 30 * day, Horizon: 365 * day},
+			errorText: "raw rates retention must be at least an hour",
+		},
+		{
+			name:      "Horizon before minute candles retention",
+			policy:    RetentionPolicy{Raw: 7 * day, Minute: 30 * day, Horizon: 10 * day},
+			errorText: "retention of minute candles must be between raw rates retention and horizon",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRetentionJob(retainerFunc(nil), tt.policy, time.Hour, zap.NewNop().Sugar())
+
+			if tt.errorText != "" {
+				assert.EqualError(t, err, tt.errorText)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestRetentionJob_Run(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC()
+	hour := now.Truncate(time.Hour)
+	policy := RetentionPolicy{Raw: 7 * day, Minute: 30 * day, Horizon: 365 * day}
+
+	tests := []struct {
+		name        string
+		res         *domain.RetentionResult
+		err         error
+		wantResult  string
+		wantRolled  float64
+		wantDeleted float64
+	}{
+		{
+			name:        "Applied retention is counted",
+			res:         &domain.RetentionResult{RolledUpMinutes: 120, RolledUpHours: 2, DeletedRaw: 7200, DeletedMinutes: 60, DeletedHours: 1},
+			wantResult:  RetentionApplied,
+			wantRolled:  120,
+			wantDeleted: 7200,
+		},
+		{
+			name:       "Retention applied by another instance",
+			res:        &domain.RetentionResult{Skipped: true},
+			wantResult: RetentionSkipped,
+		},
+		{
+			name:       "Failed retention",
+			err:        errors.New("db error"),
+			wantResult: RetentionFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got domain.RetentionCutoffs
+
+			retainer := retainerFunc(func(ctx context.Context, cutoffs domain.RetentionCutoffs) (*domain.RetentionResult, error) {
+				got = cutoffs
+				return tt.res, tt.err
+			})
+
+			j, err := NewRetentionJob(retainer, policy, time.Hour, zap.NewNop().Sugar())
+			assert.NoError(t, err)
+			j.now = func() time.Time { return now }
+
+			runs := testutil.ToFloat64(retentionRuns.WithLabelValues(tt.wantResult))
+			rolled := testutil.ToFloat64(retentionRolledUp.WithLabelValues("Rate_1m"))
+			deleted := testutil.ToFloat64(retentionDeleted.WithLabelValues("Rate"))
+
+			j.run(context.Background())
+
+			assert.Equal(t, domain.RetentionCutoffs{
+				Raw:    hour.Add(-7 * day),
+				Minute: hour.Add(-30 * day),
+				Hour:   hour.Add(-365 * day),
+			}, got)
+			assert.Equal(t, runs+1, testutil.ToFloat64(retentionRuns.WithLabelValues(tt.wantResult)))
+			assert.Equal(t, rolled+tt.wantRolled, testutil.ToFloat64(retentionRolledUp.WithLabelValues("Rate_1m")))
+			assert.Equal(t, deleted+tt.wantDeleted, testutil.ToFloat64(retentionDeleted.WithLabelValues("Rate")))
+		})
+	}
+}
+
+func TestRetentionJob_StartStop(t *testing.T) {
+	var calls atomic.Int32
+
+	retainer := retainerFunc(func(ctx context.Context, cutoffs domain.RetentionCutoffs) (*domain.RetentionResult, error) {
+		calls.Add(1)
+		return &domain.RetentionResult{}, nil
+	})
+
+	j, err := NewRetentionJob(retainer, RetentionPolicy{Raw: day, Minute: day, Horizon: day}, 10*time.Millisecond, zap.NewNop().Sugar())
+	assert.NoError(t, err)
+
+	j.Start(context.Background())
+
+	assert.Eventually(t, func() bool { return calls.Load() >= 2 }, time.Second, 5*time.Millisecond)
+
+	j.Stop()
+	stopped := calls.Load()
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load(), "no runs after stop")
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"final/internal/domain"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type RateRefresher interface {
+	RefreshRate(ctx context.Context, market string) (*domain.Rate, error)
+}
+
+// Scheduler polls every market at the interval in the background.
+type Scheduler struct {
+	refresher RateRefresher
+	markets   []string
+	interval  time.Duration
+	l         *zap.SugaredLogger
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func NewScheduler(refresher RateRefresher, markets []string, interval time.Duration, logger *zap.SugaredLogger) *Scheduler {
+	return &Scheduler{
+		refresher: refresher,
+		markets:   markets,
+		interval:  interval,
+		l:         logger,
+	}
+}
+
+// Start launches a poller per market, every market is polled immediately and then at the interval.
+// Pollers run until Stop is called or ctx is done. Start after Stop does nothing.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped || s.cancel != nil {
+		return
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, market := range s.markets {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.poll(ctx, market)
+		}()
+	}
+
+	s.l.Infof("polling markets %v every %s", s.markets, s.interval)
+}
+
+// Stop stops pollers and waits for in-flight requests to finish.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	s.stopped = true
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) poll(ctx context.Context, market string) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.refresh(ctx, market)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) refresh(ctx context.Context, market string) {
+	ctx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+
+	if _, err := s.refresher.RefreshRate(ctx, market); err != nil && ctx.Err() == nil {
+		s.l.Errorf("failed to poll %s rate: %v", market, err)
+	}
+}
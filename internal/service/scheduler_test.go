@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// countingRefresher counts RefreshRate calls per market.
+type countingRefresher struct {
+	mu    sync.Mutex
+	calls map[string]int
+	err   error
+}
+
+func (r *countingRefresher) RefreshRate(ctx context.Context, market string) (*domain.Rate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls[market]++
+
+	return &domain.Rate{Market: market}, r.err
+}
+
+func (r *countingRefresher) count(market string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls[market]
+}
+
+func TestScheduler(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "Polls every market until stopped",
+		},
+		{
+			name: "Keeps polling after errors",
+			err:  errors.New("fetch error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refresher := &countingRefresher{calls: make(map[string]int), err: tt.err}
+
+			s := NewScheduler(refresher, []string{"usdtrub", "btcrub"}, 10*time.Millisecond, zap.NewNop().Sugar())
+			s.Start(context.Background())
+
+			assert.Eventually(t, func() bool {
+				return refresher.count("usdtrub") >= 3 && refresher.count("btcrub") >= 3
+			}, time.Second, 5*time.Millisecond)
+
+			s.Stop()
+
+			stopped := refresher.count("usdtrub")
+			time.Sleep(30 * time.Millisecond)
+			assert.Equal(t, stopped, refresher.count("usdtrub"), "polling after Stop")
+		})
+	}
+}
+
+func TestScheduler_StopBeforeStart(t *testing.T) {
+	refresher := &countingRefresher{calls: make(map[string]int)}
+
+	s := NewScheduler(refresher, []string{"usdtrub"}, 10*time.Millisecond, zap.NewNop().Sugar())
+	s.Stop()
+	s.Start(context.Background())
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Zero(t, refresher.count("usdtrub"))
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Reasons of quote rejections, values are exported as the reason label of rate_quote_rejections_total.
+const (
+	RejectEmptyPrice       = "empty_price"
+	RejectNonNumericPrice  = "non_numeric_price"
+	RejectNonPositivePrice = "non_positive_price"
+	RejectInvalidVolume    = "invalid_volume"
+	RejectCrossedBook      = "crossed_book"
+	RejectWideSpread       = "wide_spread"
+	RejectStaleTimestamp   = "stale_timestamp"
+	RejectFutureTimestamp  = "future_timestamp"
+)
+
+// ErrInvalidQuote is returned when a provider quote fails sanity validation.
+var ErrInvalidQuote = errors.New("invalid quote")
+
+// QuoteError is a rejection of a provider quote, it wraps ErrInvalidQuote.
+// Fetchers return it for prices and volumes which cannot be parsed, so they are counted like other rejections.
+type QuoteError struct {
+	// Reason is one of Reject* constants
+	Reason string
+	// Detail describes the rejection including the rejected values
+	Detail string
+	// Raw is the quote as received from the provider, nil if the fetcher does not expose it
+	Raw *RawQuote
+}
+
+func (e *QuoteError) Error() string {
+	return ErrInvalidQuote.Error() + ": " + e.Detail
+}
+
+func (e *QuoteError) Unwrap() error {
+	return ErrInvalidQuote
+}
+
+var quoteRejections = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "rate_quote_rejections_total",
+		Help: "Total number of provider quotes rejected by sanity validation",
+	},
+	[]string{"provider", "market", "reason"},
+)
+
+func init() {
+	prometheus.MustRegister(quoteRejections)
+}
+
+// RawQuote is the top of the book as received from a provider, rejected quotes are logged with it.
+type RawQuote struct {
+	AskPrice  string
+	AskVolume string
+	BidPrice  string
+	BidVolume string
+	// Timestamp is empty if the provider does not stamp its quotes
+	Timestamp string
+}
+
+func (q RawQuote) String() string {
+	return fmt.Sprintf("ask %q volume %q, bid %q volume %q, timestamp %q", q.AskPrice, q.AskVolume, q.BidPrice, q.BidVolume, q.Timestamp)
+}
+
+// QuoteFetcher is a RateFetcher which also returns the raw quote the rate was parsed from.
+// The raw quote is returned along with parse errors as well.
+type QuoteFetcher interface {
+	FetchQuote(ctx context.Context, market string) (*domain.Rate, *RawQuote, error)
+}
+
+// QuoteRules are sanity checks of a provider quote, zero values disable the checks.
+type QuoteRules struct {
+	// MaxSpread is a max relative spread (ask - bid) / mid, e.g. 0.05 for 5%
+	MaxSpread float64
+	// MarketSpreads overrides MaxSpread for the given markets
+	MarketSpreads map[string]float64
+	// MaxAge is a max age of the quote timestamp
+	MaxAge time.Duration
+	// MaxClockSkew is a max time the quote timestamp may be ahead of the local clock
+	MaxClockSkew time.Duration
+}
+
+// maxSpread returns the max spread of the market.
+func (r QuoteRules) maxSpread(market string) float64 {
+	if spread, ok := r.MarketSpreads[market]; ok {
+		return spread
+	}
+
+	return r.MaxSpread
+}
+
+// ValidatingFetcher rejects provider quotes which are malformed or implausible before they are stored or served.
+// Rejected quotes are counted by reason and logged as received if the fetcher is a QuoteFetcher.
+type ValidatingFetcher struct {
+	provider string
+	fetcher  RateFetcher
+	rules    QuoteRules
+	now      func() time.Time
+	l        *zap.SugaredLogger
+}
+
+func NewValidatingFetcher(provider string, fetcher RateFetcher, rules QuoteRules, logger *zap.SugaredLogger) *ValidatingFetcher {
+	return &ValidatingFetcher{
+		provider: provider,
+		fetcher:  fetcher,
+		rules:    rules,
+		now:      time.Now,
+		l:        logger,
+	}
+}
+
+// FetchRate returns the provider quote if it passes validation.
+// Returns an error wrapping ErrInvalidQuote otherwise.
+func (v *ValidatingFetcher) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	rate, raw, err := v.fetchQuote(ctx, market)
+
+	var quoteErr *QuoteError
+	switch {
+	case errors.As(err, &quoteErr):
+	case err != nil:
+		return nil, err
+	default:
+		quoteErr = v.validate(rate)
+		if quoteErr == nil {
+			return rate, nil
+		}
+		err = quoteErr
+	}
+
+	if quoteErr.Raw == nil {
+		quoteErr.Raw = raw
+	}
+	v.reject(market, quoteErr)
+
+	return nil, fmt.Errorf("%s: %w", v.provider, err)
+}
+
+// fetchQuote returns the rate and the raw quote, the raw quote is nil if the fetcher is not a QuoteFetcher.
+func (v *ValidatingFetcher) fetchQuote(ctx context.Context, market string) (*domain.Rate, *RawQuote, error) {
+	if f, ok := v.fetcher.(QuoteFetcher); ok {
+		return f.FetchQuote(ctx, market)
+	}
+
+	rate, err := v.fetcher.FetchRate(ctx, market)
+
+	return rate, nil, err
+}
+
+// OrderBooks returns the order book fetcher of the provider validated by the same rules.
+// The top of the book is validated as a quote, every level must have a positive price and volume.
+func (v *ValidatingFetcher) OrderBooks(books OrderBookFetcher) OrderBookFetcher {
+	return &validatingBooks{validator: v, books: books}
+}
+
+type validatingBooks struct {
+	validator *ValidatingFetcher
+	books     OrderBookFetcher
+}
+
+// FetchOrderBook returns the provider order book if it passes validation.
+// Returns an error wrapping ErrInvalidQuote otherwise.
+func (b *validatingBooks) FetchOrderBook(ctx context.Context, market string) (*domain.OrderBook, error) {
+	v := b.validator
+
+	book, err := b.books.FetchOrderBook(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteErr := v.validateBook(book)
+	if quoteErr == nil {
+		return book, nil
+	}
+
+	// the detail of a book rejection contains the raw values of the level
+	v.reject(market, quoteErr)
+
+	return nil, fmt.Errorf("%s: %w", v.provider, quoteErr)
+}
+
+// validateBook returns the rejection of the order book, nil if the book is valid.
+func (v *ValidatingFetcher) validateBook(book *domain.OrderBook) *QuoteError {
+	for _, side := range []struct {
+		name   string
+		levels []domain.PriceLevel
+	}{
+		{"ask", book.Asks},
+		{"bid", book.Bids},
+	} {
+		for _, level := range side.levels {
+			if quoteErr := validateLevel(side.name, level); quoteErr != nil {
+				return quoteErr
+			}
+		}
+	}
+
+	// a book with an empty side has no quote, it is reported as insufficient liquidity
+	if len(book.Asks) == 0 || len(book.Bids) == 0 {
+		return nil
+	}
+
+	rate := &domain.Rate{Market: book.Market, Timestamp: book.Timestamp}
+	ask, bid := book.Asks[0], book.Bids[0]
+	if err := parseTopOfBook(rate, ask.Price, ask.Volume, bid.Price, bid.Volume); err != nil {
+		return &QuoteError{Reason: RejectInvalidVolume, Detail: err.Error()}
+	}
+
+	return v.validate(rate)
+}
+
+// validateLevel returns the rejection of the order book level, nil if its price and volume are positive.
+func validateLevel(side string, level domain.PriceLevel) *QuoteError {
+	price, err := parsePrice(side, level.Price)
+
+	var quoteErr *QuoteError
+	if errors.As(err, &quoteErr) {
+		return quoteErr
+	}
+
+	if price.Sign() <= 0 {
+		return &QuoteError{Reason: RejectNonPositivePrice, Detail: fmt.Sprintf("non-positive %s %q", side, level.Price)}
+	}
+
+	volume, err := domain.ParseDecimal(level.Volume)
+	if err != nil || volume.Sign() <= 0 {
+		return &QuoteError{Reason: RejectInvalidVolume, Detail: fmt.Sprintf("invalid %s volume %q at price %s", side, level.Volume, level.Price)}
+	}
+
+	return nil
+}
+
+// reject counts and logs the rejected quote with its raw values if they are known.
+func (v *ValidatingFetcher) reject(market string, err *QuoteError) {
+	quoteRejections.WithLabelValues(v.provider, market, err.Reason).Inc()
+
+	if err.Raw == nil {
+		v.l.Warnf("rejected %s quote of %s: %s", v.provider, market, err.Detail)
+		return
+	}
+
+	v.l.Warnf("rejected %s quote of %s: %s, received: %s", v.provider, market, err.Detail, err.Raw)
+}
+
+// validate returns the rejection of the quote, nil if the quote is valid.
+func (v *ValidatingFetcher) validate(rate *domain.Rate) *QuoteError {
+	if rate.Ask.Sign() <= 0 {
+		return &QuoteError{Reason: RejectNonPositivePrice, Detail: fmt.Sprintf("non-positive ask %q", rate.Ask)}
+	}
+
+	if rate.Bid.Sign() <= 0 {
+		return &QuoteError{Reason: RejectNonPositivePrice, Detail: fmt.Sprintf("non-positive bid %q", rate.Bid)}
+	}
+
+	if rate.Bid.Cmp(rate.Ask) > 0 {
+		return &QuoteError{Reason: RejectCrossedBook, Detail: fmt.Sprintf("crossed book: bid %s is above ask %s", rate.Bid, rate.Ask)}
+	}
+
+	spread, _ := rate.Spread().Div(rate.Mid(), 10, domain.RoundHalfEven)
+	if maxSpread := v.rules.maxSpread(rate.Market); maxSpread > 0 && spread.Float64() > maxSpread {
+		return &QuoteError{Reason: RejectWideSpread, Detail: fmt.Sprintf("spread %.4f%% exceeds %.4f%%", spread.Float64()*100, maxSpread*100)}
+	}
+
+	age := v.now().Sub(rate.Timestamp)
+	if v.rules.MaxAge > 0 && age > v.rules.MaxAge {
+		return &QuoteError{Reason: RejectStaleTimestamp, Detail: fmt.Sprintf("timestamp %s is %s old", rate.Timestamp.UTC().Format(time.RFC3339), age.Round(time.Second))}
+	}
+
+	if v.rules.MaxClockSkew > 0 && -age > v.rules.MaxClockSkew {
+		return &QuoteError{Reason: RejectFutureTimestamp, Detail: fmt.Sprintf("timestamp %s is %s ahead", rate.Timestamp.UTC().Format(time.RFC3339), (-age).Round(time.Second))}
+	}
+
+	return nil
+}
+
+// parsePrice parses a price of the quote side received from a provider.
+// Returns a QuoteError if the price is empty or not a decimal.
+func parsePrice(side, value string) (domain.Decimal, error) {
+	if value == "" {
+		return domain.Decimal{}, &QuoteError{Reason: RejectEmptyPrice, Detail: fmt.Sprintf("empty %s", side)}
+	}
+
+	price, err := domain.ParseDecimal(value)
+	if err != nil {
+		return domain.Decimal{}, &QuoteError{Reason: RejectNonNumericPrice, Detail: fmt.Sprintf("non-numeric %s %q", side, value)}
+	}
+
+	return price, nil
+}
+
+// parseTopOfBook parses the best ask and bid levels received from a provider into the rate.
+func parseTopOfBook(rate *domain.Rate, askPrice, askVolume, bidPrice, bidVolume string) error {
+	var err error
+
+	if rate.Ask, err = parsePrice("ask", askPrice); err != nil {
+		return err
+	}
+
+	if rate.Bid, err = parsePrice("bid", bidPrice); err != nil {
+		return err
+	}
+
+	if rate.AskVolume, err = parseVolume("ask", askVolume); err != nil {
+		return err
+	}
+
+	if rate.BidVolume, err = parseVolume("bid", bidVolume); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseVolume parses a volume of the quote side received from a provider.
+// Returns a QuoteError if the volume is missing or not a decimal.
+func parseVolume(side, value string) (domain.Decimal, error) {
+	volume, err := domain.ParseDecimal(value)
+	if err != nil {
+		return domain.Decimal{}, &QuoteError{Reason: RejectInvalidVolume, Detail: fmt.Sprintf("invalid %s volume %q", side, value)}
+	}
+
+	return volume, nil
+}
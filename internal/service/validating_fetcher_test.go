@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"final/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestValidatingFetcher_FetchRate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	rules := QuoteRules{
+		MaxSpread:     0.05,
+		MarketSpreads: map[string]float64{"usdtrub": 0.01},
+		MaxAge:        10 * time.Minute,
+		MaxClockSkew:  30 * time.Second,
+	}
+
+	type quote struct {
+		market, ask, bid string
+		ts               time.Time
+	}
+
+	tests := []struct {
+		name      string
+		rate      quote
+		reason    string
+		errorText string
+	}{
+		{
+			name: "Valid quote",
+			rate: quote{"btcrub", "100.5", "99.5", now.Add(-time.Minute)},
+		},
+		{
+			name: "Locked book is valid",
+			rate: quote{"btcrub", "100", "100", now},
+		},
+		{
+			name:      "Empty price",
+			rate:      quote{"btcrub", "", "99.5", now},
+			reason:    RejectEmptyPrice,
+			errorText: "test: invalid quote: empty ask",
+		},
+		{
+			name:      "Non-numeric price",
+			rate:      quote{"btcrub", "100.5", "n/a", now},
+			reason:    RejectNonNumericPrice,
+			errorText: `test: invalid quote: non-numeric bid "n/a"`,
+		},
+		{
+			name:      "Infinite price",
+			rate:      quote{"btcrub", "Inf", "99.5", now},
+			reason:    RejectNonNumericPrice,
+			errorText: `test: invalid quote: non-numeric ask "Inf"`,
+		},
+		{
+			name:      "Zero price",
+			rate:      quote{"btcrub", "100.5", "0", now},
+			reason:    RejectNonPositivePrice,
+			errorText: `test: invalid quote: non-positive bid "0"`,
+		},
+		{
+			name:      "Crossed book",
+			rate:      quote{"btcrub", "99", "100", now},
+			reason:    RejectCrossedBook,
+			errorText: "test: invalid quote: crossed book: bid 100 is above ask 99",
+		},
+		{
+			name:      "Spread wider than market limit",
+			rate:      quote{"usdtrub", "101.5", "99.5", now},
+			reason:    RejectWideSpread,
+			errorText: "test: invalid quote: spread 1.9900% exceeds 1.0000%",
+		},
+		{
+			name: "Spread within default limit",
+			rate: quote{"btcrub", "101.5", "99.5", now},
+		},
+		{
+			name:      "Stale timestamp",
+			rate:      quote{"btcrub", "100.5", "99.5", now.Add(-3 * time.Hour)},
+			reason:    RejectStaleTimestamp,
+			errorText: "test: invalid quote: timestamp 2023-11-14T19:13:20Z is 3h0m0s old",
+		},
+		{
+			name:      "Future timestamp",
+			rate:      quote{"btcrub", "100.5", "99.5", now.Add(time.Minute)},
+			reason:    RejectFutureTimestamp,
+			errorText: "test: invalid quote: timestamp 2023-11-14T22:14:20Z is 1m0s ahead",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rate *domain.Rate
+
+			// fetcher parses prices as received like provider fetchers do
+			fetcher := rateFetcherFunc(func(ctx context.Context, market string) (*domain.Rate, error) {
+				ask, err := parsePrice("ask", tt.rate.ask)
+				if err != nil {
+					return nil, err
+				}
+
+				bid, err := parsePrice("bid", tt.rate.bid)
+				if err != nil {
+					return nil, err
+				}
+
+				rate = &domain.Rate{Market: market, Ask: ask, Bid: bid, Timestamp: tt.rate.ts}
+				return rate, nil
+			})
+
+			v := NewValidatingFetcher("test", fetcher, rules, zap.NewNop().Sugar())
+			v.now = func() time.Time { return now }
+
+			var before float64
+			if tt.reason != "" {
+				before = testutil.ToFloat64(quoteRejections.WithLabelValues("test", tt.rate.market, tt.reason))
+			}
+
+			got, err := v.FetchRate(context.Background(), tt.rate.market)
+
+			if tt.errorText == "" {
+				assert.NoError(t, err)
+				assert.Same(t, rate, got)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrInvalidQuote)
+			assert.EqualError(t, err, tt.errorText)
+			assert.Nil(t, got)
+			assert.Equal(t, before+1, testutil.ToFloat64(quoteRejections.WithLabelValues("test", tt.rate.market, tt.reason)))
+		})
+	}
+}
+
+type rateFetcherFunc func(ctx context.Context, market string) (*domain.Rate, error)
+
+func (f rateFetcherFunc) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	return f(ctx, market)
+}
+
+func TestValidatingFetcher_FetchRate_ProviderError(t *testing.T) {
+	fetchErr := errors.New("timeout")
+
+	fetcher := new(MockRateFetcher)
+	fetcher.On("FetchRate", mock.Anything, "usdtrub").Return(nil, fetchErr)
+
+	v := NewValidatingFetcher("test", fetcher, QuoteRules{}, zap.NewNop().Sugar())
+
+	_, err := v.FetchRate(context.Background(), "usdtrub")
+	assert.ErrorIs(t, err, fetchErr)
+	assert.NotErrorIs(t, err, ErrInvalidQuote)
+}
+
+func TestValidatingFetcher_OrderBooks(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	level := func(price, volume string) domain.PriceLevel {
+		return domain.PriceLevel{Price: price, Volume: volume}
+	}
+
+	tests := []struct {
+		name      string
+		asks      []domain.PriceLevel
+		bids      []domain.PriceLevel
+		reason    string
+		errorText string
+	}{
+		{
+			name: "Valid book",
+			asks: []domain.PriceLevel{level("100", "1"), level("101", "2")},
+			bids: []domain.PriceLevel{level("99", "1")},
+		},
+		{
+			name: "Empty side is not a rejection",
+			asks: []domain.PriceLevel{level("100", "1")},
+		},
+		{
+			name:      "Zero volume deep in the book",
+			asks:      []domain.PriceLevel{level("100", "1"), level("101", "0")},
+			bids:      []domain.PriceLevel{level("99", "1")},
+			reason:    RejectInvalidVolume,
+			errorText: `test: invalid quote: invalid ask volume "0" at price 101`,
+		},
+		{
+			name:      "Negative volume",
+			asks:      []domain.PriceLevel{level("100", "1")},
+			bids:      []domain.PriceLevel{level("99", "-5")},
+			reason:    RejectInvalidVolume,
+			errorText: `test: invalid quote: invalid bid volume "-5" at price 99`,
+		},
+		{
+			name:      "Non-positive price",
+			asks:      []domain.PriceLevel{level("100", "1")},
+			bids:      []domain.PriceLevel{level("99", "1"), level("-1", "1")},
+			reason:    RejectNonPositivePrice,
+			errorText: `test: invalid quote: non-positive bid "-1"`,
+		},
+		{
+			name:      "Crossed top of the book",
+			asks:      []domain.PriceLevel{level("99", "1")},
+			bids:      []domain.PriceLevel{level("100", "1")},
+			reason:    RejectCrossedBook,
+			errorText: "test: invalid quote: crossed book: bid 100 is above ask 99",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := &domain.OrderBook{Market: "btcrub", Asks: tt.asks, Bids: tt.bids, Timestamp: now}
+
+			books := new(MockOrderBookFetcher)
+			books.On("FetchOrderBook", mock.Anything, "btcrub").Return(book, nil)
+
+			v := NewValidatingFetcher("test", new(MockRateFetcher), QuoteRules{MaxAge: time.Minute}, zap.NewNop().Sugar())
+			v.now = func() time.Time { return now }
+
+			var before float64
+			if tt.reason != "" {
+				before = testutil.ToFloat64(quoteRejections.WithLabelValues("test", "btcrub", tt.reason))
+			}
+
+			got, err := v.OrderBooks(books).FetchOrderBook(context.Background(), "btcrub")
+
+			if tt.errorText == "" {
+				assert.NoError(t, err)
+				assert.Same(t, book, got)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrInvalidQuote)
+			assert.EqualError(t, err, tt.errorText)
+			assert.Nil(t, got)
+			assert.Equal(t, before+1, testutil.ToFloat64(quoteRejections.WithLabelValues("test", "btcrub", tt.reason)))
+		})
+	}
+}
+
+type quoteFetcherFunc func(ctx context.Context, market string) (*domain.Rate, *RawQuote, error)
+
+func (f quoteFetcherFunc) FetchRate(ctx context.Context, market string) (*domain.Rate, error) {
+	rate, _, err := f(ctx, market)
+	return rate, err
+}
+
+func (f quoteFetcherFunc) FetchQuote(ctx context.Context, market string) (*domain.Rate, *RawQuote, error) {
+	return f(ctx, market)
+}
+
+func TestValidatingFetcher_FetchRate_LogsRawQuote(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name       string
+		raw        RawQuote
+		wantReason string
+		wantLog    string
+	}{
+		{
+			name:       "Parse error",
+			raw:        RawQuote{AskPrice: "1e400x", AskVolume: "1", BidPrice: "99", BidVolume: "2", Timestamp: "1700000000"},
+			wantReason: RejectNonNumericPrice,
+			wantLog:    `rejected test quote of btcrub: non-numeric ask "1e400x", received: ask "1e400x" volume "1", bid "99" volume "2", timestamp "1700000000"`,
+		},
+		{
+			name:       "Malformed volume",
+			raw:        RawQuote{AskPrice: "100", AskVolume: "1,5", BidPrice: "99", BidVolume: "2", Timestamp: "1700000000"},
+			wantReason: RejectInvalidVolume,
+			wantLog:    `rejected test quote of btcrub: invalid ask volume "1,5", received: ask "100" volume "1,5", bid "99" volume "2", timestamp "1700000000"`,
+		},
+		{
+			name:       "Missing volume",
+			raw:        RawQuote{AskPrice: "100", AskVolume: "1", BidPrice: "99", Timestamp: "1700000000"},
+			wantReason: RejectInvalidVolume,
+			wantLog:    `rejected test quote of btcrub: invalid bid volume "", received: ask "100" volume "1", bid "99" volume "", timestamp "1700000000"`,
+		},
+		{
+			name:       "Validation error",
+			raw:        RawQuote{AskPrice: "99.00", AskVolume: "1", BidPrice: "100.00", BidVolume: "2", Timestamp: "1700000000"},
+			wantReason: RejectCrossedBook,
+			wantLog:    `rejected test quote of btcrub: crossed book: bid 100.00 is above ask 99.00, received: ask "99.00" volume "1", bid "100.00" volume "2", timestamp "1700000000"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := quoteFetcherFunc(func(ctx context.Context, market string) (*domain.Rate, *RawQuote, error) {
+				raw := tt.raw
+				rate := &domain.Rate{Market: market, Timestamp: now}
+				if err := parseTopOfBook(rate, raw.AskPrice, raw.AskVolume, raw.BidPrice, raw.BidVolume); err != nil {
+					return nil, &raw, err
+				}
+				return rate, &raw, nil
+			})
+
+			core, logs := observer.New(zap.WarnLevel)
+
+			v := NewValidatingFetcher("test", fetcher, QuoteRules{}, zap.New(core).Sugar())
+			v.now = func() time.Time { return now }
+
+			rejections := quoteRejections.WithLabelValues("test", "btcrub", tt.wantReason)
+			before := testutil.ToFloat64(rejections)
+
+			_, err := v.FetchRate(context.Background(), "btcrub")
+
+			var quoteErr *QuoteError
+			if assert.ErrorAs(t, err, &quoteErr) {
+				assert.Equal(t, tt.wantReason, quoteErr.Reason)
+				assert.Equal(t, &tt.raw, quoteErr.Raw)
+			}
+			assert.Equal(t, before+1, testutil.ToFloat64(rejections))
+
+			if assert.Equal(t, 1, logs.Len()) {
+				assert.Equal(t, tt.wantLog, logs.All()[0].Message)
+			}
+		})
+	}
+}
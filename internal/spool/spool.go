@@ -0,0 +1,562 @@
+// Package spool implements a durable FIFO queue of records kept in append-only segment files of a directory.
+package spool
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentExt = ".seg"
+	ackExt     = ".ack"
+	// tmpExt is appended to the name of an ack file being replaced
+	tmpExt = ".tmp"
+
+	// headerSize is the size of a record header: data length, crc32 of the rest of the record and append time.
+	headerSize = 4 + 4 + 8
+	// ackSize is the size of an ack file: the number of acknowledged records and their size.
+	ackSize = 8 + 8
+
+	// MaxRecordSize is a max size of a record, larger lengths read from a segment are treated as torn records.
+	MaxRecordSize = 1 << 20
+)
+
+// ErrClosed is returned by operations on a closed spool.
+var ErrClosed = errors.New("spool is closed")
+
+// Record is a record read from the spool.
+type Record struct {
+	Data       []byte
+	AppendedAt time.Time
+}
+
+// Spool is a durable FIFO queue of records. Records are appended to the last segment and read from the first one,
+// a segment is removed once all of its records are acknowledged.
+// Appends and acknowledgements are fsynced, so they survive a crash. A record torn by a crash is discarded on Open.
+// Spool is safe for concurrent use.
+type Spool struct {
+	dir         string
+	segmentSize int64
+
+	mu       sync.Mutex
+	closed   bool
+	segments []*segment
+	// active is the file of the last segment open for appending, nil until the first append
+	active *os.File
+}
+
+// segment is a segment file, acked records are at the start of the file.
+type segment struct {
+	seq       uint64
+	records   int
+	size      int64
+	acked     int
+	ackedSize int64
+}
+
+func (s *segment) path(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", s.seq, segmentExt))
+}
+
+func (s *segment) ackPath(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", s.seq, ackExt))
+}
+
+// Open opens the spool in dir creating the directory if needed.
+// A new segment is started once the last one is larger than segmentSize bytes.
+func Open(dir string, segmentSize int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	s := &Spool{dir: dir, segmentSize: segmentSize}
+
+	for _, e := range entries {
+		name, found := strings.CutSuffix(e.Name(), segmentExt)
+		if !found || e.IsDir() {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		s.segments = append(s.segments, &segment{seq: seq})
+	}
+
+	slices.SortFunc(s.segments, func(a, b *segment) int { return cmp.Compare(a.seq, b.seq) })
+
+	for _, seg := range s.segments {
+		if err := s.load(seg); err != nil {
+			return nil, err
+		}
+	}
+
+	// segments acknowledged before a crash
+	for len(s.segments) > 0 && s.segments[0].acked == s.segments[0].records {
+		if err := s.removeFirst(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// load counts records of the segment and reads its ack file, a torn record at the end is truncated.
+func (s *Spool) load(seg *segment) error {
+	f, err := os.OpenFile(seg.path(s.dir), os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		_, n, err := readRecord(r)
+		if err != nil {
+			break
+		}
+
+		seg.records++
+		seg.size += n
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat spool segment: %w", err)
+	}
+
+	if info.Size() > seg.size {
+		if err := f.Truncate(seg.size); err != nil {
+			return fmt.Errorf("failed to truncate torn spool segment: %w", err)
+		}
+	}
+
+	ack, err := os.ReadFile(seg.ackPath(s.dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read spool ack: %w", err)
+	}
+
+	if len(ack) == ackSize {
+		seg.acked = int(binary.BigEndian.Uint64(ack[0:8]))
+		seg.ackedSize = int64(binary.BigEndian.Uint64(ack[8:16]))
+	}
+
+	if seg.acked > seg.records || seg.ackedSize > seg.size {
+		return fmt.Errorf("spool ack of segment %d is beyond its end", seg.seq)
+	}
+
+	return nil
+}
+
+// readRecord reads a record and returns it with its size, io.ErrUnexpectedEOF is returned for a torn record.
+func readRecord(r io.Reader) (Record, int64, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Record{}, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > MaxRecordSize {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[8:16])
+	crc.Write(data)
+	if crc.Sum32() != binary.BigEndian.Uint32(header[4:8]) {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+
+	return Record{
+		Data:       data,
+		AppendedAt: time.Unix(0, int64(binary.BigEndian.Uint64(header[8:16]))),
+	}, headerSize + int64(len(data)), nil
+}
+
+func appendRecord(buf []byte, data []byte, appendedAt time.Time) []byte {
+	var header [headerSize]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint64(header[8:16], uint64(appendedAt.UnixNano()))
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[8:16])
+	crc.Write(data)
+	binary.BigEndian.PutUint32(header[4:8], crc.Sum32())
+
+	return append(append(buf, header[:]...), data...)
+}
+
+// Append appends the records and syncs them to disk, either all of them are appended or none.
+func (s *Spool) Append(records ...[]byte) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	now := time.Now()
+
+	var buf []byte
+	for _, data := range records {
+		if len(data) > MaxRecordSize {
+			return fmt.Errorf("record of %d bytes is larger than %d", len(data), MaxRecordSize)
+		}
+
+		buf = appendRecord(buf, data, now)
+	}
+
+	if err := s.openActive(); err != nil {
+		return err
+	}
+
+	seg := s.segments[len(s.segments)-1]
+
+	if _, err := s.active.Write(buf); err != nil {
+		// drop a partially written tail, so that the segment stays readable
+		s.active.Truncate(seg.size)
+		return fmt.Errorf("failed to write spool segment: %w", err)
+	}
+
+	if err := s.active.Sync(); err != nil {
+		s.active.Truncate(seg.size)
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+
+	seg.records += len(records)
+	seg.size += int64(len(buf))
+
+	return nil
+}
+
+// openActive opens the last segment for appending, a new segment is started if the last one is full.
+func (s *Spool) openActive() error {
+	if s.active != nil && s.segments[len(s.segments)-1].size < s.segmentSize {
+		return nil
+	}
+
+	if s.active != nil {
+		if err := s.active.Close(); err != nil {
+			return fmt.Errorf("failed to close spool segment: %w", err)
+		}
+		s.active = nil
+	}
+
+	if len(s.segments) == 0 || s.segments[len(s.segments)-1].size >= s.segmentSize {
+		var seq uint64 = 1
+		if len(s.segments) > 0 {
+			seq = s.segments[len(s.segments)-1].seq + 1
+		}
+
+		s.segments = append(s.segments, &segment{seq: seq})
+	}
+
+	seg := s.segments[len(s.segments)-1]
+
+	f, err := os.OpenFile(seg.path(s.dir), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		s.dropEmptyLast()
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+
+	if seg.size == 0 {
+		if err := syncDir(s.dir); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	s.active = f
+
+	return nil
+}
+
+// dropEmptyLast forgets the last segment if it has not been created.
+func (s *Spool) dropEmptyLast() {
+	if last := s.segments[len(s.segments)-1]; last.size == 0 {
+		s.segments = s.segments[:len(s.segments)-1]
+	}
+}
+
+// syncDir syncs the directory, so that created and removed files survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open spool directory: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool directory: %w", err)
+	}
+
+	return nil
+}
+
+// Peek returns up to max oldest records which are not acknowledged yet in append order.
+// Records of a single segment are returned at a time.
+func (s *Spool) Peek(max int) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	// a segment left empty by a failed append
+	for len(s.segments) > 1 && s.segments[0].acked == s.segments[0].records {
+		if err := s.removeFirst(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.segments) == 0 {
+		return nil, nil
+	}
+
+	return s.read(s.segments[0], max)
+}
+
+func (s *Spool) read(seg *segment, max int) ([]Record, error) {
+	f, err := os.Open(seg.path(s.dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(seg.ackedSize, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek spool segment: %w", err)
+	}
+
+	n := min(max, seg.records-seg.acked)
+	records := make([]Record, 0, n)
+
+	r := bufio.NewReader(f)
+	for range n {
+		rec, _, err := readRecord(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spool segment: %w", err)
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// Ack acknowledges n oldest records returned by Peek, they are not returned again.
+func (s *Spool) Ack(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	if len(s.segments) == 0 || n <= 0 {
+		return nil
+	}
+
+	seg := s.segments[0]
+	if n > seg.records-seg.acked {
+		return fmt.Errorf("can not acknowledge %d records, segment %d has %d unacknowledged", n, seg.seq, seg.records-seg.acked)
+	}
+
+	size, err := s.recordsSize(seg, n)
+	if err != nil {
+		return err
+	}
+
+	seg.acked += n
+	seg.ackedSize += size
+
+	if seg.acked == seg.records {
+		return s.removeFirst()
+	}
+
+	var ack [ackSize]byte
+	binary.BigEndian.PutUint64(ack[0:8], uint64(seg.acked))
+	binary.BigEndian.PutUint64(ack[8:16], uint64(seg.ackedSize))
+
+	return writeFileSync(seg.ackPath(s.dir), ack[:])
+}
+
+// recordsSize returns the size of n records of the segment following the acknowledged ones.
+func (s *Spool) recordsSize(seg *segment, n int) (int64, error) {
+	f, err := os.Open(seg.path(s.dir))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(seg.ackedSize, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek spool segment: %w", err)
+	}
+
+	var size int64
+
+	r := bufio.NewReader(f)
+	for range n {
+		_, recSize, err := readRecord(r)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read spool segment: %w", err)
+		}
+		size += recSize
+	}
+
+	return size, nil
+}
+
+// writeFileSync replaces the file with data atomically: data is written to a temporary file which is synced
+// and renamed over the file, so that a crash leaves either the old or the new content.
+func writeFileSync(path string, data []byte) error {
+	tmp := path + tmpExt
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open spool ack: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write spool ack: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync spool ack: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close spool ack: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace spool ack: %w", err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// removeFirst removes the first segment which has all of its records acknowledged.
+func (s *Spool) removeFirst() error {
+	seg := s.segments[0]
+
+	if len(s.segments) == 1 && s.active != nil {
+		if err := s.active.Close(); err != nil {
+			return fmt.Errorf("failed to close spool segment: %w", err)
+		}
+		s.active = nil
+	}
+
+	if err := os.Remove(seg.path(s.dir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove spool segment: %w", err)
+	}
+
+	if err := os.Remove(seg.ackPath(s.dir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove spool ack: %w", err)
+	}
+
+	// left by a crash while the ack file was replaced
+	if err := os.Remove(seg.ackPath(s.dir) + tmpExt); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove spool ack: %w", err)
+	}
+
+	s.segments = s.segments[1:]
+
+	return syncDir(s.dir)
+}
+
+// Len returns the number of records which are not acknowledged yet.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, seg := range s.segments {
+		n += seg.records - seg.acked
+	}
+
+	return n
+}
+
+// Segments returns the number of segment files.
+func (s *Spool) Segments() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.segments)
+}
+
+// Oldest returns the append time of the oldest record which is not acknowledged yet, zero if there are none.
+func (s *Spool) Oldest() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return time.Time{}, ErrClosed
+	}
+
+	if len(s.segments) == 0 {
+		return time.Time{}, nil
+	}
+
+	records, err := s.read(s.segments[0], 1)
+	if err != nil || len(records) == 0 {
+		return time.Time{}, err
+	}
+
+	return records[0].AppendedAt, nil
+}
+
+// Close closes the spool, records which are not acknowledged are kept for the next Open.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+
+	if s.active != nil {
+		if err := s.active.Close(); err != nil {
+			return fmt.Errorf("failed to close spool segment: %w", err)
+		}
+		s.active = nil
+	}
+
+	return nil
+}
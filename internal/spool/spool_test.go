@@ -0,0 +1,170 @@
+package spool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func records(t *testing.T, s *Spool, max int) []string {
+	t.Helper()
+
+	recs, err := s.Peek(max)
+	assert.NoError(t, err)
+
+	data := make([]string, 0, len(recs))
+	for _, r := range recs {
+		data = append(data, string(r.Data))
+	}
+
+	return data
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	assert.NoError(t, err)
+
+	return files
+}
+
+func TestSpool_AppendPeekAck(t *testing.T) {
+	dir := t.TempDir()
+
+	// a segment is full after a single record, so every append starts a segment
+	s, err := Open(dir, headerSize+1)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	assert.NoError(t, s.Append([]byte("a"), []byte("b")))
+	assert.NoError(t, s.Append([]byte("c")))
+	assert.NoError(t, s.Append([]byte("d"), []byte("e")))
+
+	assert.Equal(t, 5, s.Len())
+	assert.Equal(t, 3, s.Segments())
+
+	assert.Equal(t, []string{"a"}, records(t, s, 1))
+	assert.Equal(t, []string{"a", "b"}, records(t, s, 10), "records of a single segment are returned")
+
+	assert.NoError(t, s.Ack(1))
+	assert.Equal(t, []string{"b"}, records(t, s, 10))
+	assert.Equal(t, 4, s.Len())
+
+	assert.NoError(t, s.Ack(1))
+	assert.Equal(t, 2, s.Segments(), "acknowledged segment is removed")
+	assert.Len(t, segmentFiles(t, dir), 2)
+	assert.Equal(t, []string{"c"}, records(t, s, 10))
+
+	assert.EqualError(t, s.Ack(2), "can not acknowledge 2 records, segment 2 has 1 unacknowledged")
+
+	assert.NoError(t, s.Ack(1))
+	assert.Equal(t, []string{"d", "e"}, records(t, s, 10))
+	assert.NoError(t, s.Ack(2))
+
+	assert.Equal(t, 0, s.Len())
+	assert.Empty(t, records(t, s, 10))
+	assert.Empty(t, segmentFiles(t, dir))
+
+	oldest, err := s.Oldest()
+	assert.NoError(t, err)
+	assert.True(t, oldest.IsZero())
+
+	assert.NoError(t, s.Append([]byte("f")))
+	assert.Equal(t, []string{"f"}, records(t, s, 10), "appending continues after the spool is drained")
+}
+
+func TestSpool_Reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 1<<20)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Append([]byte("a"), []byte("b"), []byte("c")))
+	assert.NoError(t, s.Ack(1))
+	assert.NoError(t, s.Close())
+
+	assert.ErrorIs(t, s.Append([]byte("d")), ErrClosed)
+
+	s, err = Open(dir, 1<<20)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, 2, s.Len())
+	assert.Equal(t, []string{"b", "c"}, records(t, s, 10), "acknowledged records are not returned after reopening")
+
+	oldest, err := s.Oldest()
+	assert.NoError(t, err)
+	assert.False(t, oldest.IsZero())
+
+	assert.NoError(t, s.Append([]byte("d")))
+	assert.Equal(t, []string{"b", "c", "d"}, records(t, s, 10), "records are appended to the reopened segment")
+}
+
+func TestSpool_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 1<<20)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Append([]byte("a"), []byte("b")))
+	assert.NoError(t, s.Close())
+
+	files := segmentFiles(t, dir)
+	if !assert.Len(t, files, 1) {
+		return
+	}
+
+	info, err := os.Stat(files[0])
+	assert.NoError(t, err)
+
+	// a crash in the middle of writing the second record
+	assert.NoError(t, os.Truncate(files[0], info.Size()-1))
+
+	s, err = Open(dir, 1<<20)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, 1, s.Len())
+	assert.Equal(t, []string{"a"}, records(t, s, 10))
+
+	assert.NoError(t, s.Append([]byte("c")))
+	assert.Equal(t, []string{"a", "c"}, records(t, s, 10), "torn record is truncated before appending")
+}
+
+func TestSpool_TornAck(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 1<<20)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Append([]byte("a"), []byte("b"), []byte("c")))
+	assert.NoError(t, s.Ack(1))
+	assert.NoError(t, s.Close())
+
+	acks, err := filepath.Glob(filepath.Join(dir, "*"+ackExt))
+	assert.NoError(t, err)
+	if !assert.Len(t, acks, 1) {
+		return
+	}
+
+	// a crash in the middle of replacing the ack file of the second acknowledgement
+	assert.NoError(t, os.WriteFile(acks[0]+tmpExt, []byte{0, 0, 0}, 0o644))
+
+	s, err = Open(dir, 1<<20)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, []string{"b", "c"}, records(t, s, 10), "the previous acknowledgement is kept")
+
+	assert.NoError(t, s.Ack(1))
+	assert.Equal(t, []string{"c"}, records(t, s, 10))
+
+	assert.NoError(t, s.Ack(1))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries, "files of the acknowledged segment are removed")
+}
@@ -19,7 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	RateService_GetRate_FullMethodName = "/final.RateService/GetRate"
+	RateService_GetRate_FullMethodName           = "/final.RateService/GetRate"
+	RateService_GetOrderBook_FullMethodName      = "/final.RateService/GetOrderBook"
+	RateService_GetExecutionPrice_FullMethodName = "/final.RateService/GetExecutionPrice"
+	RateService_GetRateHistory_FullMethodName    = "/final.RateService/GetRateHistory"
+	RateService_GetCandles_FullMethodName        = "/final.RateService/GetCandles"
 )
 
 // RateServiceClient is the client API for RateService service.
@@ -27,6 +31,10 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type RateServiceClient interface {
 	GetRate(ctx context.Context, in *GetRateRequest, opts ...grpc.CallOption) (*GetRateResponse, error)
+	GetOrderBook(ctx context.Context, in *GetOrderBookRequest, opts ...grpc.CallOption) (*GetOrderBookResponse, error)
+	GetExecutionPrice(ctx context.Context, in *GetExecutionPriceRequest, opts ...grpc.CallOption) (*GetExecutionPriceResponse, error)
+	GetRateHistory(ctx context.Context, in *GetRateHistoryRequest, opts ...grpc.CallOption) (*GetRateHistoryResponse, error)
+	GetCandles(ctx context.Context, in *GetCandlesRequest, opts ...grpc.CallOption) (*GetCandlesResponse, error)
 }
 
 type rateServiceClient struct {
@@ -47,11 +55,55 @@ func (c *rateServiceClient) GetRate(ctx context.Context, in *GetRateRequest, opt
 	return out, nil
 }
 
+func (c *rateServiceClient) GetOrderBook(ctx context.Context, in *GetOrderBookRequest, opts ...grpc.CallOption) (*GetOrderBookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrderBookResponse)
+	err := c.cc.Invoke(ctx, RateService_GetOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateServiceClient) GetExecutionPrice(ctx context.Context, in *GetExecutionPriceRequest, opts ...grpc.CallOption) (*GetExecutionPriceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetExecutionPriceResponse)
+	err := c.cc.Invoke(ctx, RateService_GetExecutionPrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateServiceClient) GetRateHistory(ctx context.Context, in *GetRateHistoryRequest, opts ...grpc.CallOption) (*GetRateHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRateHistoryResponse)
+	err := c.cc.Invoke(ctx, RateService_GetRateHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateServiceClient) GetCandles(ctx context.Context, in *GetCandlesRequest, opts ...grpc.CallOption) (*GetCandlesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCandlesResponse)
+	err := c.cc.Invoke(ctx, RateService_GetCandles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RateServiceServer is the server API for RateService service.
 // All implementations must embed UnimplementedRateServiceServer
 // for forward compatibility.
 type RateServiceServer interface {
 	GetRate(context.Context, *GetRateRequest) (*GetRateResponse, error)
+	GetOrderBook(context.Context, *GetOrderBookRequest) (*GetOrderBookResponse, error)
+	GetExecutionPrice(context.Context, *GetExecutionPriceRequest) (*GetExecutionPriceResponse, error)
+	GetRateHistory(context.Context, *GetRateHistoryRequest) (*GetRateHistoryResponse, error)
+	GetCandles(context.Context, *GetCandlesRequest) (*GetCandlesResponse, error)
 	mustEmbedUnimplementedRateServiceServer()
 }
 
@@ -65,6 +117,18 @@ type UnimplementedRateServiceServer struct{}
 func (UnimplementedRateServiceServer) GetRate(context.Context, *GetRateRequest) (*GetRateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRate not implemented")
 }
+func (UnimplementedRateServiceServer) GetOrderBook(context.Context, *GetOrderBookRequest) (*GetOrderBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderBook not implemented")
+}
+func (UnimplementedRateServiceServer) GetExecutionPrice(context.Context, *GetExecutionPriceRequest) (*GetExecutionPriceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExecutionPrice not implemented")
+}
+func (UnimplementedRateServiceServer) GetRateHistory(context.Context, *GetRateHistoryRequest) (*GetRateHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRateHistory not implemented")
+}
+func (UnimplementedRateServiceServer) GetCandles(context.Context, *GetCandlesRequest) (*GetCandlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCandles not implemented")
+}
 func (UnimplementedRateServiceServer) mustEmbedUnimplementedRateServiceServer() {}
 func (UnimplementedRateServiceServer) testEmbeddedByValue()                     {}
 
@@ -104,6 +168,78 @@ func _RateService_GetRate_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RateService_GetOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateServiceServer).GetOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateService_GetOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateServiceServer).GetOrderBook(ctx, req.(*GetOrderBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateService_GetExecutionPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetExecutionPriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateServiceServer).GetExecutionPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateService_GetExecutionPrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateServiceServer).GetExecutionPrice(ctx, req.(*GetExecutionPriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateService_GetRateHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRateHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateServiceServer).GetRateHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateService_GetRateHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateServiceServer).GetRateHistory(ctx, req.(*GetRateHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateService_GetCandles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCandlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateServiceServer).GetCandles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateService_GetCandles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateServiceServer).GetCandles(ctx, req.(*GetCandlesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RateService_ServiceDesc is the grpc.ServiceDesc for RateService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +251,22 @@ var RateService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetRate",
 			Handler:    _RateService_GetRate_Handler,
 		},
+		{
+			MethodName: "GetOrderBook",
+			Handler:    _RateService_GetOrderBook_Handler,
+		},
+		{
+			MethodName: "GetExecutionPrice",
+			Handler:    _RateService_GetExecutionPrice_Handler,
+		},
+		{
+			MethodName: "GetRateHistory",
+			Handler:    _RateService_GetRateHistory_Handler,
+		},
+		{
+			MethodName: "GetCandles",
+			Handler:    _RateService_GetCandles_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "protos/final.proto",
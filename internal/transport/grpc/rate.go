@@ -2,12 +2,17 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"final/internal/domain"
 	"final/internal/transport/gen"
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"time"
 )
 
 var (
@@ -31,8 +36,8 @@ func init() {
 	prometheus.MustRegister(rateRequests, rateErrors)
 }
 
-func NewRateServiceServer(service RateService) *RateServiceServer {
-	if service == nil {
+func NewRateServiceServer(service RateService, books OrderBookService) *RateServiceServer {
+	if service == nil || books == nil {
 		return nil
 	}
 
@@ -41,36 +46,285 @@ func NewRateServiceServer(service RateService) *RateServiceServer {
 	return &RateServiceServer{
 		tracer:  tracer,
 		service: service,
+		books:   books,
+		now:     time.Now,
 	}
 }
 
 type RateServiceServer struct {
 	tracer  trace.Tracer
 	service RateService
+	books   OrderBookService
+	now     func() time.Time
 	gen.UnimplementedRateServiceServer
 }
 
 type RateService interface {
-	GetRate(ctx context.Context) (*domain.Rate, error)
+	GetRate(ctx context.Context, market string, maxAge time.Duration) (*domain.Rate, error)
+	GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error)
+	GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error)
+}
+
+type OrderBookService interface {
+	GetOrderBook(ctx context.Context, market string, depth int) (*domain.OrderBook, error)
+	GetExecutionPrice(ctx context.Context, market string, amount string) (*domain.ExecutionPrice, error)
 }
 
 func (s *RateServiceServer) GetRate(ctx context.Context, req *gen.GetRateRequest) (*gen.GetRateResponse, error) {
 	rateRequests.WithLabelValues("GetRate").Inc()
 	ctx, span := s.tracer.Start(ctx, "GetRate")
+	defer span.End()
 
-	rate, err := s.service.GetRate(ctx)
+	var maxAge time.Duration
+	if req.GetMaxAge() != nil {
+		if err := req.GetMaxAge().CheckValid(); err != nil || req.GetMaxAge().AsDuration() < 0 {
+			return nil, serviceError("GetRate", span, fmt.Errorf("%w: max_age must be a non-negative duration", domain.ErrInvalidArgument))
+		}
+		maxAge = req.GetMaxAge().AsDuration()
+	}
+
+	rate, err := s.service.GetRate(ctx, req.GetMarket(), maxAge)
 
 	if err != nil {
-		rateErrors.WithLabelValues("GetRate").Inc()
-		traceID := span.SpanContext().TraceID().String()
-		return nil, fmt.Errorf("error while using rate service: %w, TraceID: %s", err, traceID)
+		return nil, serviceError("GetRate", span, err)
 	}
 
-	span.End()
-
 	return &gen.GetRateResponse{
-		Ask:       rate.Ask,
-		Bid:       rate.Bid,
-		Timestamp: rate.Timestamp.String(),
+		Ask:        rate.Ask.String(),
+		Bid:        rate.Bid.String(),
+		Timestamp:  rate.Timestamp.String(),
+		Market:     rate.Market,
+		Sources:    toRateSources(rate.Sources),
+		Provider:   rate.Provider,
+		Stale:      rate.Stale,
+		Age:        durationpb.New(max(s.now().Sub(rate.Timestamp), 0)),
+		Mid:        rate.Mid().String(),
+		Spread:     rate.Spread().String(),
+		SpreadBps:  rate.SpreadBps().String(),
+		ReceivedAt: rate.ReceivedAt.String(),
+		AskVolume:  rate.AskVolume.String(),
+		BidVolume:  rate.BidVolume.String(),
+		Latency:    durationpb.New(rate.Latency),
+	}, nil
+}
+
+func (s *RateServiceServer) GetRateHistory(ctx context.Context, req *gen.GetRateHistoryRequest) (*gen.GetRateHistoryResponse, error) {
+	rateRequests.WithLabelValues("GetRateHistory").Inc()
+	ctx, span := s.tracer.Start(ctx, "GetRateHistory")
+	defer span.End()
+
+	q := domain.RateHistoryQuery{
+		Market: req.GetMarket(),
+		Limit:  int(req.GetPageSize()),
+		Cursor: req.GetCursor(),
+	}
+
+	if req.GetFrom() != nil {
+		if err := req.GetFrom().CheckValid(); err != nil {
+			return nil, serviceError("GetRateHistory", span, fmt.Errorf("%w: from must be a valid timestamp", domain.ErrInvalidArgument))
+		}
+		q.From = req.GetFrom().AsTime()
+	}
+
+	if req.GetTo() != nil {
+		if err := req.GetTo().CheckValid(); err != nil {
+			return nil, serviceError("GetRateHistory", span, fmt.Errorf("%w: to must be a valid timestamp", domain.ErrInvalidArgument))
+		}
+		q.To = req.GetTo().AsTime()
+	}
+
+	page, err := s.service.GetRateHistory(ctx, q)
+
+	if err != nil {
+		return nil, serviceError("GetRateHistory", span, err)
+	}
+
+	rates := make([]*gen.HistoricalRate, len(page.Rates))
+	for i, rate := range page.Rates {
+		rates[i] = &gen.HistoricalRate{
+			Market:     rate.Market,
+			Provider:   rate.Provider,
+			Ask:        rate.Ask.String(),
+			Bid:        rate.Bid.String(),
+			AskVolume:  rate.AskVolume.String(),
+			BidVolume:  rate.BidVolume.String(),
+			Timestamp:  rate.Timestamp.String(),
+			ReceivedAt: rate.ReceivedAt.String(),
+			Latency:    durationpb.New(rate.Latency),
+		}
+	}
+
+	return &gen.GetRateHistoryResponse{
+		Rates:      rates,
+		NextCursor: page.NextCursor,
+	}, nil
+}
+
+func (s *RateServiceServer) GetCandles(ctx context.Context, req *gen.GetCandlesRequest) (*gen.GetCandlesResponse, error) {
+	rateRequests.WithLabelValues("GetCandles").Inc()
+	ctx, span := s.tracer.Start(ctx, "GetCandles")
+	defer span.End()
+
+	q := domain.CandleQuery{
+		Market:   req.GetMarket(),
+		FillGaps: req.GetGapFill() == gen.GapFill_GAP_FILL_FORWARD,
+	}
+
+	if err := req.GetInterval().CheckValid(); err != nil {
+		return nil, serviceError("GetCandles", span, fmt.Errorf("%w: interval must be a valid duration", domain.ErrInvalidArgument))
+	}
+	q.Interval = req.GetInterval().AsDuration()
+
+	if req.GetFrom() != nil {
+		if err := req.GetFrom().CheckValid(); err != nil {
+			return nil, serviceError("GetCandles", span, fmt.Errorf("%w: from must be a valid timestamp", domain.ErrInvalidArgument))
+		}
+		q.From = req.GetFrom().AsTime()
+	}
+
+	if req.GetTo() != nil {
+		if err := req.GetTo().CheckValid(); err != nil {
+			return nil, serviceError("GetCandles", span, fmt.Errorf("%w: to must be a valid timestamp", domain.ErrInvalidArgument))
+		}
+		q.To = req.GetTo().AsTime()
+	}
+
+	candles, err := s.service.GetCandles(ctx, q)
+
+	if err != nil {
+		return nil, serviceError("GetCandles", span, err)
+	}
+
+	res := make([]*gen.Candle, len(candles))
+	for i, candle := range candles {
+		res[i] = &gen.Candle{
+			Start:  candle.Start.String(),
+			Mid:    toOHLC(candle.Mid),
+			Ask:    toOHLC(candle.Ask),
+			Bid:    toOHLC(candle.Bid),
+			Count:  candle.Count,
+			Filled: candle.Filled,
+		}
+	}
+
+	return &gen.GetCandlesResponse{Candles: res}, nil
+}
+
+func toOHLC(o domain.OHLC) *gen.OHLC {
+	return &gen.OHLC{
+		Open:  o.Open.String(),
+		High:  o.High.String(),
+		Low:   o.Low.String(),
+		Close: o.Close.String(),
+	}
+}
+
+func (s *RateServiceServer) GetOrderBook(ctx context.Context, req *gen.GetOrderBookRequest) (*gen.GetOrderBookResponse, error) {
+	rateRequests.WithLabelValues("GetOrderBook").Inc()
+	ctx, span := s.tracer.Start(ctx, "GetOrderBook")
+	defer span.End()
+
+	book, err := s.books.GetOrderBook(ctx, req.GetMarket(), int(req.GetDepth()))
+
+	if err != nil {
+		return nil, serviceError("GetOrderBook", span, err)
+	}
+
+	return &gen.GetOrderBookResponse{
+		Market:    book.Market,
+		Provider:  book.Provider,
+		Asks:      toPriceLevels(book.Asks),
+		Bids:      toPriceLevels(book.Bids),
+		Timestamp: book.Timestamp.String(),
+	}, nil
+}
+
+func (s *RateServiceServer) GetExecutionPrice(ctx context.Context, req *gen.GetExecutionPriceRequest) (*gen.GetExecutionPriceResponse, error) {
+	rateRequests.WithLabelValues("GetExecutionPrice").Inc()
+	ctx, span := s.tracer.Start(ctx, "GetExecutionPrice")
+	defer span.End()
+
+	price, err := s.books.GetExecutionPrice(ctx, req.GetMarket(), req.GetAmount())
+
+	if err != nil {
+		return nil, serviceError("GetExecutionPrice", span, err)
+	}
+
+	return &gen.GetExecutionPriceResponse{
+		Market:    price.Market,
+		Provider:  price.Provider,
+		Amount:    price.Amount,
+		Buy:       toExecutionQuote(price.Buy),
+		Sell:      toExecutionQuote(price.Sell),
+		Timestamp: price.Timestamp.String(),
 	}, nil
 }
+
+func toExecutionQuote(quote domain.ExecutionQuote) *gen.ExecutionQuote {
+	return &gen.ExecutionQuote{
+		Vwap:        quote.VWAP,
+		BestPrice:   quote.BestPrice,
+		WorstPrice:  quote.WorstPrice,
+		SlippageBps: quote.SlippageBps,
+	}
+}
+
+func toPriceLevels(levels []domain.PriceLevel) []*gen.PriceLevel {
+	res := make([]*gen.PriceLevel, len(levels))
+	for i, level := range levels {
+		res[i] = &gen.PriceLevel{
+			Price:  level.Price,
+			Volume: level.Volume,
+			Amount: level.Amount,
+			Factor: level.Factor,
+		}
+	}
+
+	return res
+}
+
+func toRateSources(sources []domain.RateSource) []*gen.RateSource {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	res := make([]*gen.RateSource, len(sources))
+	for i, source := range sources {
+		res[i] = &gen.RateSource{
+			Provider: source.Provider,
+			Ask:      formatSourcePrice(source.Ask),
+			Bid:      formatSourcePrice(source.Bid),
+			Weight:   source.Weight,
+			Excluded: source.Excluded,
+			Reason:   source.Reason,
+		}
+	}
+
+	return res
+}
+
+// formatSourcePrice formats a price of the source, empty if the provider returned no quote.
+func formatSourcePrice(price domain.Decimal) string {
+	if price.IsZero() {
+		return ""
+	}
+
+	return price.String()
+}
+
+// serviceError counts the error and converts it into a grpc error.
+// Errors caused by the request itself are returned with codes.InvalidArgument,
+// insufficient liquidity with codes.FailedPrecondition.
+func serviceError(method string, span trace.Span, err error) error {
+	rateErrors.WithLabelValues(method).Inc()
+	traceID := span.SpanContext().TraceID().String()
+
+	switch {
+	case errors.Is(err, domain.ErrUnknownMarket), errors.Is(err, domain.ErrInvalidArgument):
+		return status.Errorf(codes.InvalidArgument, "invalid request: %v, TraceID: %s", err, traceID)
+	case errors.Is(err, domain.ErrInsufficientLiquidity):
+		return status.Errorf(codes.FailedPrecondition, "%v, TraceID: %s", err, traceID)
+	}
+
+	return fmt.Errorf("error while using rate service: %w, TraceID: %s", err, traceID)
+}
@@ -5,64 +5,227 @@ import (
 	"errors"
 	"final/internal/domain"
 	"final/internal/transport/gen"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type MockRateService struct {
 	mock.Mock
 }
 
-func (m *MockRateService) GetRate(ctx context.Context) (*domain.Rate, error) {
-	args := m.Called(ctx)
+func (m *MockRateService) GetRate(ctx context.Context, market string, maxAge time.Duration) (*domain.Rate, error) {
+	args := m.Called(ctx, market, maxAge)
 	if args.Get(0) != nil {
 		return args.Get(0).(*domain.Rate), args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
+func (m *MockRateService) GetRateHistory(ctx context.Context, q domain.RateHistoryQuery) (*domain.RateHistoryPage, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) != nil {
+		return args.Get(0).(*domain.RateHistoryPage), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRateService) GetCandles(ctx context.Context, q domain.CandleQuery) ([]domain.Candle, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) != nil {
+		return args.Get(0).([]domain.Candle), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRateService) GetOrderBook(ctx context.Context, market string, depth int) (*domain.OrderBook, error) {
+	args := m.Called(ctx, market, depth)
+	if args.Get(0) != nil {
+		return args.Get(0).(*domain.OrderBook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRateService) GetExecutionPrice(ctx context.Context, market string, amount string) (*domain.ExecutionPrice, error) {
+	args := m.Called(ctx, market, amount)
+	if args.Get(0) != nil {
+		return args.Get(0).(*domain.ExecutionPrice), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func TestRateServiceServer_GetRate(t *testing.T) {
 	mockService := new(MockRateService)
-	server := NewRateServiceServer(mockService)
+	server := NewRateServiceServer(mockService, mockService)
 
 	fixedTime := time.Unix(1700000000, 0)
+	server.now = func() time.Time { return fixedTime.Add(3 * time.Second) }
 
 	rate := &domain.Rate{
-		Ask:       "100.5",
-		Bid:       "99.5",
-		Timestamp: fixedTime,
+		Market:     "usdtrub",
+		Provider:   "garantex",
+		Ask:        domain.MustParseDecimal("100.5"),
+		Bid:        domain.MustParseDecimal("99.5"),
+		AskVolume:  domain.MustParseDecimal("12.5"),
+		BidVolume:  domain.MustParseDecimal("3.0"),
+		Timestamp:  fixedTime,
+		ReceivedAt: fixedTime.Add(time.Second),
+		Latency:    250 * time.Millisecond,
 	}
 
 	tests := []struct {
 		name          string
+		market        string
+		maxAge        *durationpb.Duration
 		setup         func()
 		expectedResp  *gen.GetRateResponse
 		expectedError string
 	}{
 		{
-			name: "Successful GetRate",
+			name:   "Successful GetRate",
+			market: "usdtrub",
+			setup: func() {
+				mockService.On("GetRate", mock.Anything, "usdtrub", time.Duration(0)).Return(rate, nil)
+			},
+			expectedResp: &gen.GetRateResponse{
+				Ask:        "100.5",
+				Bid:        "99.5",
+				Timestamp:  rate.Timestamp.String(),
+				Age:        durationpb.New(3 * time.Second),
+				Mid:        "100.0",
+				Spread:     "1.0",
+				SpreadBps:  "100.00",
+				ReceivedAt: fixedTime.Add(time.Second).String(),
+				AskVolume:  "12.5",
+				BidVolume:  "3.0",
+				Latency:    durationpb.New(250 * time.Millisecond),
+				Market:     rate.Market,
+				Provider:   rate.Provider,
+			},
+			expectedError: "",
+		},
+		{
+			name:   "Consensus rate with sources",
+			market: "btcusdt",
 			setup: func() {
-				mockService.On("GetRate", mock.Anything).Return(rate, nil)
+				mockService.On("GetRate", mock.Anything, "btcusdt", time.Duration(0)).Return(&domain.Rate{
+					Market:    "btcusdt",
+					Provider:  "consensus",
+					Ask:       domain.MustParseDecimal("100.5"),
+					Bid:       domain.MustParseDecimal("99.5"),
+					Timestamp: fixedTime,
+					Sources: []domain.RateSource{
+						{Provider: "garantex", Ask: domain.MustParseDecimal("100.5"), Bid: domain.MustParseDecimal("99.5"), Weight: 1},
+						{Provider: "binance", Ask: domain.MustParseDecimal("120"), Bid: domain.MustParseDecimal("119"), Excluded: true, Reason: "deviation"},
+					},
+				}, nil)
 			},
 			expectedResp: &gen.GetRateResponse{
-				Ask:       rate.Ask,
-				Bid:       rate.Bid,
-				Timestamp: rate.Timestamp.String(),
+				Ask:        "100.5",
+				Bid:        "99.5",
+				Timestamp:  fixedTime.String(),
+				Age:        durationpb.New(3 * time.Second),
+				Mid:        "100.0",
+				Spread:     "1.0",
+				SpreadBps:  "100.00",
+				ReceivedAt: time.Time{}.String(),
+				AskVolume:  "0",
+				BidVolume:  "0",
+				Latency:    durationpb.New(0),
+				Market:     "btcusdt",
+				Provider:   "consensus",
+				Sources: []*gen.RateSource{
+					{Provider: "garantex", Ask: "100.5", Bid: "99.5", Weight: 1},
+					{Provider: "binance", Ask: "120", Bid: "119", Excluded: true, Reason: "deviation"},
+				},
 			},
 			expectedError: "",
 		},
 		{
-			name: "RateService returns error",
+			name:   "RateService returns error",
+			market: "usdtrub",
 			setup: func() {
-				mockService.On("GetRate", mock.Anything).Return((*domain.Rate)(nil), errors.New("service error"))
+				mockService.On("GetRate", mock.Anything, "usdtrub", time.Duration(0)).Return((*domain.Rate)(nil), errors.New("service error"))
 			},
 			expectedResp:  nil,
 			expectedError: "error while using rate service: service error",
 		},
+		{
+			name:   "Unknown market",
+			market: "usdtxyz",
+			setup: func() {
+				mockService.On("GetRate", mock.Anything, "usdtxyz", time.Duration(0)).Return((*domain.Rate)(nil), fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "usdtxyz"))
+			},
+			expectedResp:  nil,
+			expectedError: "InvalidArgument",
+		},
+		{
+			name:   "Max age is passed to the service",
+			market: "usdtrub",
+			maxAge: durationpb.New(5 * time.Second),
+			setup: func() {
+				mockService.On("GetRate", mock.Anything, "usdtrub", 5*time.Second).Return(rate, nil)
+			},
+			expectedResp: &gen.GetRateResponse{
+				Ask:        "100.5",
+				Bid:        "99.5",
+				Timestamp:  fixedTime.String(),
+				Age:        durationpb.New(3 * time.Second),
+				Mid:        "100.0",
+				Spread:     "1.0",
+				SpreadBps:  "100.00",
+				ReceivedAt: fixedTime.Add(time.Second).String(),
+				AskVolume:  "12.5",
+				BidVolume:  "3.0",
+				Latency:    durationpb.New(250 * time.Millisecond),
+				Market:     "usdtrub",
+				Provider:   "garantex",
+			},
+			expectedError: "",
+		},
+		{
+			name:   "Stale rate",
+			market: "usdtrub",
+			setup: func() {
+				mockService.On("GetRate", mock.Anything, "usdtrub", time.Duration(0)).Return(&domain.Rate{
+					Market:    "usdtrub",
+					Ask:       domain.MustParseDecimal("100.5"),
+					Bid:       domain.MustParseDecimal("99.5"),
+					Timestamp: fixedTime,
+					Stale:     true,
+				}, nil)
+			},
+			expectedResp: &gen.GetRateResponse{
+				Ask:        "100.5",
+				Bid:        "99.5",
+				Timestamp:  fixedTime.String(),
+				Age:        durationpb.New(3 * time.Second),
+				Mid:        "100.0",
+				Spread:     "1.0",
+				SpreadBps:  "100.00",
+				ReceivedAt: time.Time{}.String(),
+				AskVolume:  "0",
+				BidVolume:  "0",
+				Latency:    durationpb.New(0),
+				Market:     "usdtrub",
+				Stale:      true,
+			},
+			expectedError: "",
+		},
+		{
+			name:          "Negative max age",
+			market:        "usdtrub",
+			maxAge:        durationpb.New(-time.Second),
+			setup:         func() {},
+			expectedResp:  nil,
+			expectedError: "max_age must be a non-negative duration",
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,7 +233,7 @@ func TestRateServiceServer_GetRate(t *testing.T) {
 			mockService.ExpectedCalls = nil
 			tt.setup()
 
-			req := &gen.GetRateRequest{}
+			req := &gen.GetRateRequest{Market: tt.market, MaxAge: tt.maxAge}
 			resp, err := server.GetRate(context.Background(), req)
 
 			if tt.expectedError != "" {
@@ -86,9 +249,345 @@ func TestRateServiceServer_GetRate(t *testing.T) {
 		})
 	}
 }
+func TestRateServiceServer_GetOrderBook(t *testing.T) {
+	mockService := new(MockRateService)
+	server := NewRateServiceServer(mockService, mockService)
+
+	fixedTime := time.Unix(1700000000, 0)
+
+	book := &domain.OrderBook{
+		Market:   "usdtrub",
+		Provider: "garantex",
+		Asks: []domain.PriceLevel{
+			{Price: "100.5", Volume: "10", Amount: "1005", Factor: "0.01"},
+		},
+		Bids: []domain.PriceLevel{
+			{Price: "99.5", Volume: "20", Amount: "1990", Factor: "-0.01"},
+		},
+		Timestamp: fixedTime,
+	}
+
+	tests := []struct {
+		name          string
+		req           *gen.GetOrderBookRequest
+		setup         func()
+		expectedResp  *gen.GetOrderBookResponse
+		expectedError string
+	}{
+		{
+			name: "Successful GetOrderBook",
+			req:  &gen.GetOrderBookRequest{Market: "usdtrub", Depth: 1},
+			setup: func() {
+				mockService.On("GetOrderBook", mock.Anything, "usdtrub", 1).Return(book, nil)
+			},
+			expectedResp: &gen.GetOrderBookResponse{
+				Market:    "usdtrub",
+				Provider:  "garantex",
+				Asks:      []*gen.PriceLevel{{Price: "100.5", Volume: "10", Amount: "1005", Factor: "0.01"}},
+				Bids:      []*gen.PriceLevel{{Price: "99.5", Volume: "20", Amount: "1990", Factor: "-0.01"}},
+				Timestamp: fixedTime.String(),
+			},
+		},
+		{
+			name: "Unknown market",
+			req:  &gen.GetOrderBookRequest{Market: "usdtxyz"},
+			setup: func() {
+				mockService.On("GetOrderBook", mock.Anything, "usdtxyz", 0).Return(nil, fmt.Errorf("%w: %q", domain.ErrUnknownMarket, "usdtxyz"))
+			},
+			expectedError: "InvalidArgument",
+		},
+		{
+			name: "OrderBookService returns error",
+			req:  &gen.GetOrderBookRequest{Market: "usdtrub", Depth: 5},
+			setup: func() {
+				mockService.On("GetOrderBook", mock.Anything, "usdtrub", 5).Return(nil, errors.New("service error"))
+			},
+			expectedError: "error while using rate service: service error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService.ExpectedCalls = nil
+			tt.setup()
+
+			resp, err := server.GetOrderBook(context.Background(), tt.req)
+
+			if tt.expectedError != "" {
+				assert.Nil(t, resp)
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResp, resp)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRateServiceServer_GetRateHistory(t *testing.T) {
+	mockService := new(MockRateService)
+	server := NewRateServiceServer(mockService, mockService)
+
+	from := time.Unix(1700000000, 0).UTC()
+	to := from.Add(time.Hour)
+
+	page := &domain.RateHistoryPage{
+		Rates: []*domain.Rate{
+			{
+				Market:     "usdtrub",
+				Provider:   "garantex",
+				Ask:        domain.MustParseDecimal("100.5"),
+				Bid:        domain.MustParseDecimal("99.5"),
+				AskVolume:  domain.MustParseDecimal("12.5"),
+				BidVolume:  domain.MustParseDecimal("3.0"),
+				Timestamp:  from,
+				ReceivedAt: from.Add(time.Second),
+				Latency:    250 * time.Millisecond,
+			},
+		},
+		NextCursor: "next",
+	}
+
+	tests := []struct {
+		name          string
+		req           *gen.GetRateHistoryRequest
+		setup         func()
+		expectedResp  *gen.GetRateHistoryResponse
+		expectedError string
+	}{
+		{
+			name: "Successful GetRateHistory",
+			req: &gen.GetRateHistoryRequest{
+				Market:   "usdtrub",
+				From:     timestamppb.New(from),
+				To:       timestamppb.New(to),
+				PageSize: 1,
+				Cursor:   "prev",
+			},
+			setup: func() {
+				q := domain.RateHistoryQuery{Market: "usdtrub", From: from, To: to, Limit: 1, Cursor: "prev"}
+				mockService.On("GetRateHistory", mock.Anything, q).Return(page, nil)
+			},
+			expectedResp: &gen.GetRateHistoryResponse{
+				Rates: []*gen.HistoricalRate{
+					{
+						Market:     "usdtrub",
+						Provider:   "garantex",
+						Ask:        "100.5",
+						Bid:        "99.5",
+						AskVolume:  "12.5",
+						BidVolume:  "3.0",
+						Timestamp:  from.String(),
+						ReceivedAt: from.Add(time.Second).String(),
+						Latency:    durationpb.New(250 * time.Millisecond),
+					},
+				},
+				NextCursor: "next",
+			},
+		},
+		{
+			name: "Unset time range is left to the service",
+			req:  &gen.GetRateHistoryRequest{},
+			setup: func() {
+				mockService.On("GetRateHistory", mock.Anything, domain.RateHistoryQuery{}).Return(&domain.RateHistoryPage{}, nil)
+			},
+			expectedResp: &gen.GetRateHistoryResponse{Rates: []*gen.HistoricalRate{}},
+		},
+		{
+			name:          "Invalid timestamp",
+			req:           &gen.GetRateHistoryRequest{From: &timestamppb.Timestamp{Nanos: -1}},
+			setup:         func() {},
+			expectedError: "from must be a valid timestamp",
+		},
+		{
+			name: "Malformed cursor",
+			req:  &gen.GetRateHistoryRequest{Cursor: "!"},
+			setup: func() {
+				mockService.On("GetRateHistory", mock.Anything, domain.RateHistoryQuery{Cursor: "!"}).
+					Return(nil, fmt.Errorf("%w: malformed cursor %q", domain.ErrInvalidArgument, "!"))
+			},
+			expectedError: "InvalidArgument",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService.ExpectedCalls = nil
+			tt.setup()
+
+			resp, err := server.GetRateHistory(context.Background(), tt.req)
+
+			if tt.expectedError != "" {
+				assert.Nil(t, resp)
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResp, resp)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRateServiceServer_GetCandles(t *testing.T) {
+	mockService := new(MockRateService)
+	server := NewRateServiceServer(mockService, mockService)
+
+	from := time.Unix(1700000000, 0).UTC()
+	to := from.Add(time.Hour)
+
+	prices := domain.OHLC{
+		Open:  domain.MustParseDecimal("100"),
+		High:  domain.MustParseDecimal("102"),
+		Low:   domain.MustParseDecimal("99"),
+		Close: domain.MustParseDecimal("101"),
+	}
+	genPrices := &gen.OHLC{Open: "100", High: "102", Low: "99", Close: "101"}
+
+	tests := []struct {
+		name          string
+		req           *gen.GetCandlesRequest
+		setup         func()
+		expectedResp  *gen.GetCandlesResponse
+		expectedError string
+	}{
+		{
+			name: "Successful GetCandles",
+			req: &gen.GetCandlesRequest{
+				Market:   "usdtrub",
+				Interval: durationpb.New(time.Minute),
+				From:     timestamppb.New(from),
+				To:       timestamppb.New(to),
+				GapFill:  gen.GapFill_GAP_FILL_FORWARD,
+			},
+			setup: func() {
+				q := domain.CandleQuery{Market: "usdtrub", Interval: time.Minute, From: from, To: to, FillGaps: true}
+				mockService.On("GetCandles", mock.Anything, q).Return([]domain.Candle{
+					{Start: from, Mid: prices, Ask: prices, Bid: prices, Count: 3},
+					{Start: from.Add(time.Minute), Mid: prices.Flat(), Ask: prices.Flat(), Bid: prices.Flat(), Filled: true},
+				}, nil)
+			},
+			expectedResp: &gen.GetCandlesResponse{
+				Candles: []*gen.Candle{
+					{Start: from.String(), Mid: genPrices, Ask: genPrices, Bid: genPrices, Count: 3},
+					{
+						Start:  from.Add(time.Minute).String(),
+						Mid:    &gen.OHLC{Open: "101", High: "101", Low: "101", Close: "101"},
+						Ask:    &gen.OHLC{Open: "101", High: "101", Low: "101", Close: "101"},
+						Bid:    &gen.OHLC{Open: "101", High: "101", Low: "101", Close: "101"},
+						Filled: true,
+					},
+				},
+			},
+		},
+		{
+			name:          "Missing interval",
+			req:           &gen.GetCandlesRequest{From: timestamppb.New(from)},
+			setup:         func() {},
+			expectedError: "interval must be a valid duration",
+		},
+		{
+			name: "Unsupported interval",
+			req:  &gen.GetCandlesRequest{Interval: durationpb.New(7 * time.Minute), From: timestamppb.New(from)},
+			setup: func() {
+				q := domain.CandleQuery{Interval: 7 * time.Minute, From: from}
+				mockService.On("GetCandles", mock.Anything, q).
+					Return(nil, fmt.Errorf("%w: unsupported candle interval 7m0s", domain.ErrInvalidArgument))
+			},
+			expectedError: "InvalidArgument",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService.ExpectedCalls = nil
+			tt.setup()
+
+			resp, err := server.GetCandles(context.Background(), tt.req)
+
+			if tt.expectedError != "" {
+				assert.Nil(t, resp)
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResp, resp)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRateServiceServer_GetExecutionPrice(t *testing.T) {
+	mockService := new(MockRateService)
+	server := NewRateServiceServer(mockService, mockService)
+
+	fixedTime := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name          string
+		setup         func()
+		expectedResp  *gen.GetExecutionPriceResponse
+		expectedError string
+	}{
+		{
+			name: "Successful GetExecutionPrice",
+			setup: func() {
+				mockService.On("GetExecutionPrice", mock.Anything, "usdtrub", "250000").Return(&domain.ExecutionPrice{
+					Market:    "usdtrub",
+					Provider:  "garantex",
+					Amount:    "250000",
+					Buy:       domain.ExecutionQuote{VWAP: "100.4", BestPrice: "100", WorstPrice: "101", SlippageBps: "40"},
+					Sell:      domain.ExecutionQuote{VWAP: "98.8", BestPrice: "99", WorstPrice: "98", SlippageBps: "20.2"},
+					Timestamp: fixedTime,
+				}, nil)
+			},
+			expectedResp: &gen.GetExecutionPriceResponse{
+				Market:    "usdtrub",
+				Provider:  "garantex",
+				Amount:    "250000",
+				Buy:       &gen.ExecutionQuote{Vwap: "100.4", BestPrice: "100", WorstPrice: "101", SlippageBps: "40"},
+				Sell:      &gen.ExecutionQuote{Vwap: "98.8", BestPrice: "99", WorstPrice: "98", SlippageBps: "20.2"},
+				Timestamp: fixedTime.String(),
+			},
+		},
+		{
+			name: "Insufficient liquidity",
+			setup: func() {
+				mockService.On("GetExecutionPrice", mock.Anything, "usdtrub", "250000").
+					Return(nil, fmt.Errorf("failed to buy: %w", domain.ErrInsufficientLiquidity))
+			},
+			expectedError: "FailedPrecondition",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService.ExpectedCalls = nil
+			tt.setup()
+
+			resp, err := server.GetExecutionPrice(context.Background(), &gen.GetExecutionPriceRequest{Market: "usdtrub", Amount: "250000"})
+
+			if tt.expectedError != "" {
+				assert.Nil(t, resp)
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResp, resp)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestNewRateServiceServer(t *testing.T) {
 	type args struct {
 		service RateService
+		books   OrderBookService
 	}
 	tests := []struct {
 		name string
@@ -99,13 +598,22 @@ func TestNewRateServiceServer(t *testing.T) {
 			name: "Nil service",
 			args: args{
 				service: nil,
+				books:   new(MockRateService),
+			},
+			want: nil,
+		},
+		{
+			name: "Nil order book service",
+			args: args{
+				service: new(MockRateService),
+				books:   nil,
 			},
 			want: nil,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewRateServiceServer(tt.args.service); !reflect.DeepEqual(got, tt.want) {
+			if got := NewRateServiceServer(tt.args.service, tt.args.books); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewRateServiceServer() = %v, want %v", got, tt.want)
 			}
 		})
@@ -0,0 +1,19 @@
+-- the table may already exist if the database was initialized by the docker entrypoint script
+CREATE TABLE IF NOT EXISTS "Rate" (
+  "market" VARCHAR(32),
+  "ask" VARCHAR(255),
+  "bid" VARCHAR(255),
+  "timestamp" TIMESTAMP
+);
+
+ALTER TABLE "Rate"
+  -- databases created before markets were supported have no market column
+  ADD COLUMN IF NOT EXISTS "market" VARCHAR(32),
+  ADD COLUMN IF NOT EXISTS "provider" VARCHAR(32),
+  ADD COLUMN IF NOT EXISTS "ask_volume" VARCHAR(255),
+  ADD COLUMN IF NOT EXISTS "bid_volume" VARCHAR(255),
+  ADD COLUMN IF NOT EXISTS "received_at" TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS "latency_ms" BIGINT;
+
+-- rates saved before markets were supported are of the default market
+UPDATE "Rate" SET "market" = 'usdtrub' WHERE "market" IS NULL;
@@ -0,0 +1,12 @@
+DROP INDEX "Rate_market_timestamp_idx";
+DROP INDEX "Rate_timestamp_idx";
+
+ALTER TABLE "Rate" DROP COLUMN "id";
+
+ALTER TABLE "Rate"
+  ALTER COLUMN "ask" TYPE VARCHAR(255),
+  ALTER COLUMN "bid" TYPE VARCHAR(255),
+  ALTER COLUMN "ask_volume" TYPE VARCHAR(255),
+  ALTER COLUMN "bid_volume" TYPE VARCHAR(255),
+  ALTER COLUMN "timestamp" TYPE TIMESTAMP USING "timestamp" AT TIME ZONE 'UTC',
+  ALTER COLUMN "received_at" TYPE TIMESTAMP USING "received_at" AT TIME ZONE 'UTC';
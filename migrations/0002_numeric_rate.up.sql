@@ -0,0 +1,13 @@
+ALTER TABLE "Rate"
+  ALTER COLUMN "ask" TYPE NUMERIC USING NULLIF("ask", '')::NUMERIC,
+  ALTER COLUMN "bid" TYPE NUMERIC USING NULLIF("bid", '')::NUMERIC,
+  ALTER COLUMN "ask_volume" TYPE NUMERIC USING NULLIF("ask_volume", '')::NUMERIC,
+  ALTER COLUMN "bid_volume" TYPE NUMERIC USING NULLIF("bid_volume", '')::NUMERIC,
+  -- timestamps have always been saved in UTC
+  ALTER COLUMN "timestamp" TYPE TIMESTAMPTZ USING "timestamp" AT TIME ZONE 'UTC',
+  ALTER COLUMN "received_at" TYPE TIMESTAMPTZ USING "received_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "Rate" ADD COLUMN "id" BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY;
+
+CREATE INDEX "Rate_timestamp_idx" ON "Rate" ("timestamp");
+CREATE INDEX "Rate_market_timestamp_idx" ON "Rate" ("market", "timestamp" DESC);
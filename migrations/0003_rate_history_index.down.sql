@@ -0,0 +1,3 @@
+CREATE INDEX "Rate_market_timestamp_idx" ON "Rate" ("market", "timestamp" DESC);
+
+DROP INDEX "Rate_market_timestamp_id_idx";
@@ -0,0 +1,5 @@
+-- keyset pagination of the rate history orders rates of a market by timestamp and id
+CREATE INDEX "Rate_market_timestamp_id_idx" ON "Rate" ("market", "timestamp", "id");
+
+-- latest rate lookups are served by a backward scan of the new index
+DROP INDEX "Rate_market_timestamp_idx";
@@ -0,0 +1,2 @@
+DROP TABLE "Rate_1h";
+DROP TABLE "Rate_1m";
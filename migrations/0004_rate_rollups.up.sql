@@ -0,0 +1,24 @@
+-- candles of rates older than the raw retention, see RateRepository.ApplyRetention
+CREATE TABLE "Rate_1m" (
+  "market" VARCHAR(32) NOT NULL,
+  "start" TIMESTAMPTZ NOT NULL,
+  "mid_open" NUMERIC NOT NULL,
+  "mid_high" NUMERIC NOT NULL,
+  "mid_low" NUMERIC NOT NULL,
+  "mid_close" NUMERIC NOT NULL,
+  "ask_open" NUMERIC NOT NULL,
+  "ask_high" NUMERIC NOT NULL,
+  "ask_low" NUMERIC NOT NULL,
+  "ask_close" NUMERIC NOT NULL,
+  "bid_open" NUMERIC NOT NULL,
+  "bid_high" NUMERIC NOT NULL,
+  "bid_low" NUMERIC NOT NULL,
+  "bid_close" NUMERIC NOT NULL,
+  "count" BIGINT NOT NULL,
+  PRIMARY KEY ("market", "start")
+);
+
+CREATE TABLE "Rate_1h" (LIKE "Rate_1m" INCLUDING ALL);
+
+CREATE INDEX "Rate_1m_start_idx" ON "Rate_1m" ("start");
+CREATE INDEX "Rate_1h_start_idx" ON "Rate_1h" ("start");
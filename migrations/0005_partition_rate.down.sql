@@ -0,0 +1,30 @@
+ALTER TABLE "Rate" RENAME TO "Rate_partitioned";
+ALTER INDEX "Rate_pkey" RENAME TO "Rate_partitioned_pkey";
+ALTER INDEX "Rate_timestamp_idx" RENAME TO "Rate_partitioned_timestamp_idx";
+ALTER INDEX "Rate_market_timestamp_id_idx" RENAME TO "Rate_partitioned_market_timestamp_id_idx";
+
+CREATE TABLE "Rate" (
+  "market" VARCHAR(32),
+  "ask" NUMERIC,
+  "bid" NUMERIC,
+  "timestamp" TIMESTAMPTZ,
+  "provider" VARCHAR(32),
+  "ask_volume" NUMERIC,
+  "bid_volume" NUMERIC,
+  "received_at" TIMESTAMPTZ,
+  "latency_ms" BIGINT,
+  "id" BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY
+);
+
+INSERT INTO "Rate" ("id", "market", "ask", "bid", "timestamp", "provider", "ask_volume", "bid_volume", "received_at", "latency_ms")
+OVERRIDING SYSTEM VALUE
+SELECT "id", "market", "ask", "bid", "timestamp", "provider", "ask_volume", "bid_volume", "received_at", "latency_ms"
+FROM "Rate_partitioned";
+
+SELECT setval(pg_get_serial_sequence('"Rate"', 'id'), (SELECT COALESCE(max("id"), 0) + 1 FROM "Rate"), false);
+
+-- drops the partitions as well
+DROP TABLE "Rate_partitioned";
+
+CREATE INDEX "Rate_timestamp_idx" ON "Rate" ("timestamp");
+CREATE INDEX "Rate_market_timestamp_id_idx" ON "Rate" ("market", "timestamp", "id");
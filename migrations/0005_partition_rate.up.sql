@@ -0,0 +1,54 @@
+-- rates are partitioned by ranges of their timestamps, the app creates upcoming partitions and drops expired ones,
+-- see PartitionMaintainer and RateRepository.ApplyRetention
+SET LOCAL TimeZone = 'UTC';
+
+ALTER TABLE "Rate" RENAME TO "Rate_unpartitioned";
+ALTER INDEX "Rate_pkey" RENAME TO "Rate_unpartitioned_pkey";
+ALTER INDEX "Rate_timestamp_idx" RENAME TO "Rate_unpartitioned_timestamp_idx";
+ALTER INDEX "Rate_market_timestamp_id_idx" RENAME TO "Rate_unpartitioned_market_timestamp_id_idx";
+
+CREATE TABLE "Rate" (
+  "id" BIGINT GENERATED ALWAYS AS IDENTITY,
+  "market" VARCHAR(32),
+  "ask" NUMERIC,
+  "bid" NUMERIC,
+  "timestamp" TIMESTAMPTZ NOT NULL,
+  "provider" VARCHAR(32),
+  "ask_volume" NUMERIC,
+  "bid_volume" NUMERIC,
+  "received_at" TIMESTAMPTZ,
+  "latency_ms" BIGINT,
+  -- unique constraints of a partitioned table must include the partition key
+  PRIMARY KEY ("id", "timestamp")
+) PARTITION BY RANGE ("timestamp");
+
+-- monthly partitions of the saved rates up to the next month, named like the ones created by the app
+DO $$
+DECLARE
+  month TIMESTAMPTZ;
+BEGIN
+  FOR month IN
+    SELECT generate_series(
+      (SELECT date_trunc('month', LEAST(min("timestamp"), now())) FROM "Rate_unpartitioned"),
+      date_trunc('month', now()) + INTERVAL '1 month',
+      INTERVAL '1 month'
+    )
+  LOOP
+    EXECUTE format('CREATE TABLE %I PARTITION OF "Rate" FOR VALUES FROM (%L) TO (%L)',
+      'Rate_p' || to_char(month, 'YYYYMM'), month, month + INTERVAL '1 month');
+  END LOOP;
+END $$;
+
+-- rates without a timestamp can not be placed in a partition
+INSERT INTO "Rate" ("id", "market", "ask", "bid", "timestamp", "provider", "ask_volume", "bid_volume", "received_at", "latency_ms")
+OVERRIDING SYSTEM VALUE
+SELECT "id", "market", "ask", "bid", "timestamp", "provider", "ask_volume", "bid_volume", "received_at", "latency_ms"
+FROM "Rate_unpartitioned"
+WHERE "timestamp" IS NOT NULL;
+
+SELECT setval(pg_get_serial_sequence('"Rate"', 'id'), (SELECT COALESCE(max("id"), 0) + 1 FROM "Rate"), false);
+
+DROP TABLE "Rate_unpartitioned";
+
+CREATE INDEX "Rate_timestamp_idx" ON "Rate" ("timestamp");
+CREATE INDEX "Rate_market_timestamp_id_idx" ON "Rate" ("market", "timestamp", "id");
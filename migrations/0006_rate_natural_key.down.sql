@@ -0,0 +1 @@
+DROP INDEX "Rate_market_provider_timestamp_key";
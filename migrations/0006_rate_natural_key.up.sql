@@ -0,0 +1,11 @@
+-- a rate is identified by its market, provider and exchange timestamp, polling the same snapshot again saves nothing.
+-- the earliest saved copy of a duplicated rate is kept
+DELETE FROM "Rate" r
+USING "Rate" d
+WHERE r."market" = d."market"
+  AND r."provider" = d."provider"
+  AND r."timestamp" = d."timestamp"
+  AND r."id" > d."id";
+
+-- rates saved before providers were recorded have no provider and are not deduplicated
+CREATE UNIQUE INDEX "Rate_market_provider_timestamp_key" ON "Rate" ("market", "provider", "timestamp");
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned database schema migrations.
+//
+// A migration is a pair of files NNNN_name.up.sql and NNNN_name.down.sql,
+// migrations are applied in the order of their versions.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
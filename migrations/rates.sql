@@ -1,5 +0,0 @@
-CREATE TABLE "Rate" (
-  "ask" VARCHAR(255),
-  "bid" VARCHAR(255),
-  "timestamp" TIMESTAMP
-);